@@ -14,6 +14,7 @@ require (
 	github.com/redis/go-redis/v9 v9.9.0
 	github.com/sirupsen/logrus v1.9.4
 	github.com/stretchr/testify v1.11.1
+	github.com/yuin/gopher-lua v1.1.1
 	google.golang.org/grpc v1.71.0
 	k8s.io/api v0.32.3
 	k8s.io/apimachinery v0.32.3
@@ -17,26 +17,55 @@ type PrometheusClient struct {
 	api    v1.API
 }
 
-// NewPrometheusClient creates a PrometheusClient instance.
+// NewPrometheusClient creates a PrometheusClient instance, failing if the configuration is invalid or its
+// certificates and token can't be read.
 func NewPrometheusClient(
 	config PrometheusConfiguration,
-	logger logging.Logger) PrometheusClient {
-	api := createClientAPI(config, logger)
+	logger logging.Logger) (PrometheusClient, error) {
+	api, err := createClientAPI(config)
+	if err != nil {
+		logger.Errorf("Error creating Prometheus client: %v", err)
+		return PrometheusClient{}, err
+	}
 	return PrometheusClient{
 		config: config,
 		logger: logger,
 		api:    api,
-	}
+	}, nil
 }
 
-func createClientAPI(config PrometheusConfiguration, logger logging.Logger) v1.API {
+func createClientAPI(config PrometheusConfiguration) (v1.API, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	roundTripper, err := newPrometheusRoundTripper(config)
+	if err != nil {
+		return nil, err
+	}
 	client, err := api.NewClient(api.Config{
-		Address: config.Address,
+		Address:      config.Address,
+		RoundTripper: roundTripper,
 	})
 	if err != nil {
-		logger.Errorf("Error creating client: %v", err)
+		return nil, err
+	}
+	return v1.NewAPI(client), nil
+}
+
+// withTimeout bounds the context by the query timeout, unless it already has a deadline.
+func (c PrometheusClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.config.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.QueryTimeout)
+}
+
+// queryOptions sends the query timeout to Prometheus, if any.
+func (c PrometheusClient) queryOptions() []v1.Option {
+	if c.config.QueryTimeout <= 0 {
+		return nil
 	}
-	return v1.NewAPI(client)
+	return []v1.Option{v1.WithTimeout(c.config.QueryTimeout)}
 }
 
 // QueryRange returns range vectors as result type matrix, given the following parameters:
@@ -47,18 +76,19 @@ func createClientAPI(config PrometheusConfiguration, logger logging.Logger) v1.A
 //
 // It returns the result matrix and the execution error encountered.
 func (c PrometheusClient) QueryRange(ctx context.Context, query string, startAt, endAt time.Time, step time.Duration) (model.Value, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	result, warnings, err := c.api.QueryRange(ctx, query, v1.Range{
 		Start: startAt,
 		End:   endAt,
 		Step:  step,
-	})
+	}, c.queryOptions()...)
 	if err != nil {
 		c.logger.Errorf("Error querying Prometheus: %v", err)
 		return nil, err
 	}
-	if len(warnings) > 0 {
-		c.logger.Warnf("Prometheus query warnings result: %v", warnings)
-	}
+	c.logWarnings(warnings)
 	return result, nil
 }
 
@@ -68,13 +98,14 @@ func (c PrometheusClient) QueryRange(ctx context.Context, query string, startAt,
 //
 // It returns the result vector and the execution error encountered.
 func (c PrometheusClient) Query(ctx context.Context, query string, moment time.Time) (model.Value, error) {
-	result, warnings, err := c.api.Query(ctx, query, moment)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	result, warnings, err := c.api.Query(ctx, query, moment, c.queryOptions()...)
 	if err != nil {
 		c.logger.Errorf("Error querying Prometheus: %v", err)
 		return nil, err
 	}
-	if len(warnings) > 0 {
-		c.logger.Warnf("Prometheus query warnings result: %v", warnings)
-	}
+	c.logWarnings(warnings)
 	return result, nil
 }
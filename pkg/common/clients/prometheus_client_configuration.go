@@ -1,13 +1,60 @@
 package clients
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"errors"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
 
 // PrometheusConfiguration represents the client configuration to connect to Prometheus.
 type PrometheusConfiguration struct {
 	Address string `envconfig:"PROMETHEUS_ADDRESS" required:"true"`
+
+	// QueryTimeout bounds every request without a deadline, and is sent along with the queries so Prometheus
+	// stops evaluating them too. 0 disables it.
+	QueryTimeout time.Duration `envconfig:"PROMETHEUS_QUERY_TIMEOUT" default:"30s"`
+
+	// BearerToken, or BearerTokenPath to read it from a file on every change, such as the token of the pod
+	// service account in /var/run/secrets/kubernetes.io/serviceaccount/token.
+	BearerToken     string `envconfig:"PROMETHEUS_BEARER_TOKEN"`
+	BearerTokenPath string `envconfig:"PROMETHEUS_BEARER_TOKEN_PATH"`
+
+	BasicAuthUsername string `envconfig:"PROMETHEUS_BASIC_AUTH_USERNAME"`
+	BasicAuthPassword string `envconfig:"PROMETHEUS_BASIC_AUTH_PASSWORD"`
+
+	// CACert verifies the certificate of the server, and Cert and CertKey authenticate the client (mTLS).
+	CACert             string `envconfig:"PROMETHEUS_CA_CERT"`
+	Cert               string `envconfig:"PROMETHEUS_CERT"`
+	CertKey            string `envconfig:"PROMETHEUS_CERT_KEY"`
+	InsecureSkipVerify bool   `envconfig:"PROMETHEUS_INSECURE_SKIP_VERIFY" default:"false"`
+
+	// Headers are sent with every request, e.g. X-Scope-OrgID:tenant for the tenants of Thanos or Mimir.
+	Headers map[string]string `envconfig:"PROMETHEUS_HEADERS"`
 }
 
 // LoadFromEnvVars for PrometheusConfiguration.
 func (c *PrometheusConfiguration) LoadFromEnvVars() error {
-	return envconfig.Process("", c)
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+func (c PrometheusConfiguration) validate() error {
+	var errs []error
+	hasBearer := c.BearerToken != "" || c.BearerTokenPath != ""
+	if c.BearerToken != "" && c.BearerTokenPath != "" {
+		errs = append(errs, errors.New("only one of PROMETHEUS_BEARER_TOKEN and PROMETHEUS_BEARER_TOKEN_PATH may be set"))
+	}
+	if hasBearer && c.BasicAuthUsername != "" {
+		errs = append(errs, errors.New("only one of bearer token and basic auth may be set"))
+	}
+	if (c.Cert == "") != (c.CertKey == "") {
+		errs = append(errs, errors.New("both PROMETHEUS_CERT and PROMETHEUS_CERT_KEY are required for mTLS"))
+	}
+	if c.QueryTimeout < 0 {
+		errs = append(errs, errors.New("PROMETHEUS_QUERY_TIMEOUT must not be negative"))
+	}
+	return errors.Join(errs...)
 }
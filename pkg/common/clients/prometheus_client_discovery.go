@@ -0,0 +1,138 @@
+package clients
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// Series returns the label sets of the series matching any of the selectors, e.g. up{job="api"}, between
+// startAt and endAt. Zero times are not sent, so Prometheus applies its defaults.
+func (c PrometheusClient) Series(ctx context.Context, matches []string, startAt, endAt time.Time) ([]model.LabelSet, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	series, warnings, err := c.api.Series(ctx, matches, startAt, endAt)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus series: %v", err)
+		return nil, err
+	}
+	c.logWarnings(warnings)
+	return series, nil
+}
+
+// LabelNames returns the label names of the series matching any of the selectors, or of all the series when
+// there are none, between startAt and endAt.
+func (c PrometheusClient) LabelNames(ctx context.Context, matches []string, startAt, endAt time.Time) ([]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	names, warnings, err := c.api.LabelNames(ctx, matches, startAt, endAt)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus label names: %v", err)
+		return nil, err
+	}
+	c.logWarnings(warnings)
+	return names, nil
+}
+
+// LabelValues returns the values of the label in the series matching any of the selectors, or in all the series
+// when there are none, between startAt and endAt.
+func (c PrometheusClient) LabelValues(
+	ctx context.Context, label string, matches []string, startAt, endAt time.Time,
+) ([]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	values, warnings, err := c.api.LabelValues(ctx, label, matches, startAt, endAt)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus values of label %s: %v", label, err)
+		return nil, err
+	}
+	c.logWarnings(warnings)
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, string(value))
+	}
+	return result, nil
+}
+
+// Metadata returns the type, help and unit of the metric, or of all the metrics when empty, by metric name.
+// A limit greater than 0 limits the number of metrics returned.
+func (c PrometheusClient) Metadata(ctx context.Context, metric string, limit int) (map[string][]v1.Metadata, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var limitParam string
+	if limit > 0 {
+		limitParam = strconv.Itoa(limit)
+	}
+	metadata, err := c.api.Metadata(ctx, metric, limitParam)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus metadata: %v", err)
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// Targets returns the active and dropped scrape targets, along with their health.
+func (c PrometheusClient) Targets(ctx context.Context) (v1.TargetsResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	targets, err := c.api.Targets(ctx)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus targets: %v", err)
+		return v1.TargetsResult{}, err
+	}
+	return targets, nil
+}
+
+// Alerts returns the pending and firing alerts.
+func (c PrometheusClient) Alerts(ctx context.Context) ([]v1.Alert, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	alerts, err := c.api.Alerts(ctx)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus alerts: %v", err)
+		return nil, err
+	}
+	return alerts.Alerts, nil
+}
+
+// Rules returns the groups of alerting and recording rules, with their state.
+func (c PrometheusClient) Rules(ctx context.Context) ([]v1.RuleGroup, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	rules, err := c.api.Rules(ctx)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus rules: %v", err)
+		return nil, err
+	}
+	return rules.Groups, nil
+}
+
+// Buildinfo returns the version and build information of the Prometheus server.
+func (c PrometheusClient) Buildinfo(ctx context.Context) (v1.BuildinfoResult, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	info, err := c.api.Buildinfo(ctx)
+	if err != nil {
+		c.logger.Errorf("Error querying Prometheus build information: %v", err)
+		return v1.BuildinfoResult{}, err
+	}
+	return info, nil
+}
+
+func (c PrometheusClient) logWarnings(warnings v1.Warnings) {
+	if len(warnings) > 0 {
+		c.logger.Warnf("Prometheus query warnings result: %v", warnings)
+	}
+}
@@ -0,0 +1,213 @@
+package clients
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/prometheustest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newPrometheusTestClient(t *testing.T) (*prometheustest.Server, PrometheusClient) {
+	server := prometheustest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := NewPrometheusClient(PrometheusConfiguration{Address: server.URL}, logging.NewLogger(logging.LoggerConfiguration{}))
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestPrometheusTypedQueries(t *testing.T) {
+	server, client := newPrometheusTestClient(t)
+	server.Scalar("scalar(up)", 3)
+	server.Vector("sum(up)", prometheustest.Sample{Value: 2})
+	server.Vector("up",
+		prometheustest.Sample{Labels: map[string]string{"job": "api", "replicas": "3"}, Value: 1},
+		prometheustest.Sample{Labels: map[string]string{"job": "db"}, Value: 0},
+	)
+	server.Matrix("rate(cpu[5m])", prometheustest.Series{Labels: map[string]string{"pod": "a"}, Values: []float64{0.5, 0.75}})
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	value, err := client.QueryScalar(ctx, "scalar(up)", now, QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, value)
+	value, err = client.QueryScalar(ctx, "sum(up)", now, QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	value, err = client.QueryScalar(ctx, "missing", now, QueryOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, value)
+	_, err = client.QueryScalar(ctx, "missing", now, QueryOptions{RequireResult: true})
+	assert.ErrorIs(t, err, ErrEmptyResult)
+	_, err = client.QueryScalar(ctx, "up", now, QueryOptions{})
+	assert.Error(t, err)
+
+	samples, err := client.QueryVector(ctx, "up", now, QueryOptions{RequireResult: true})
+	require.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Labels: map[string]string{"job": "api", "replicas": "3"}, Value: 1, Time: now},
+		{Labels: map[string]string{"job": "db"}, Value: 0, Time: now},
+	}, samples)
+	_, err = client.QueryVector(ctx, "missing", now, QueryOptions{RequireResult: true})
+	assert.ErrorIs(t, err, ErrEmptyResult)
+	_, err = client.QueryVector(ctx, "rate(cpu[5m])", now, QueryOptions{})
+	assert.Error(t, err)
+
+	type target struct {
+		Job      string    `prom:"job,required"`
+		Replicas int       `prom:"replicas"`
+		Up       bool      `prom:"__value__"`
+		At       time.Time `prom:"__time__"`
+	}
+	targets, err := DecodeSamples[target](samples)
+	require.NoError(t, err)
+	assert.Equal(t, []target{{Job: "api", Replicas: 3, Up: true, At: now}, {Job: "db", At: now}}, targets)
+	_, err = DecodeSamples[target]([]Sample{{Labels: map[string]string{}}})
+	assert.ErrorContains(t, err, "missing required labels job")
+
+	series, err := client.QueryMatrix(ctx, "rate(cpu[5m])", now, now.Add(time.Minute), time.Minute, QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []Series{{
+		Labels: map[string]string{"pod": "a"},
+		Points: []Point{{Time: now, Value: 0.5}, {Time: now.Add(time.Minute), Value: 0.75}},
+	}}, series)
+}
+
+func TestPrometheusDiscovery(t *testing.T) {
+	server, client := newPrometheusTestClient(t)
+	server.AddSeries(
+		map[string]string{"__name__": "up", "job": "api"},
+		map[string]string{"__name__": "up", "job": "db"},
+		map[string]string{"__name__": "http_requests_total", "job": "api", "code": "200"},
+	)
+	server.AddMetadata("up", prometheustest.Metadata{Type: "gauge", Help: "Whether the target is up."})
+	server.AddTarget(prometheustest.Target{
+		Labels: map[string]string{"job": "api"}, ScrapeURL: "http://api:8080/metrics", Health: "up",
+	})
+	server.AddAlert(prometheustest.Alert{
+		Labels: map[string]string{"alertname": "HighLatency"}, State: "firing", ActiveAt: time.Unix(1700000000, 0), Value: 1,
+	})
+	server.AddRule(prometheustest.Rule{Group: "api", Name: "job:up:sum", Query: "sum(up) by (job)"})
+	server.SetVersion("2.53.0")
+	ctx := context.Background()
+	now := time.Now()
+
+	series, err := client.Series(ctx, []string{`up{job="api"}`}, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	assert.Equal(t, []model.LabelSet{{"__name__": "up", "job": "api"}}, series)
+
+	names, err := client.LabelNames(ctx, nil, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__name__", "code", "job"}, names)
+
+	values, err := client.LabelValues(ctx, "job", []string{"up"}, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "db"}, values)
+
+	metadata, err := client.Metadata(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, v1.MetricTypeGauge, metadata["up"][0].Type)
+
+	targets, err := client.Targets(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets.Active, 1)
+	assert.Equal(t, v1.HealthGood, targets.Active[0].Health)
+
+	alerts, err := client.Alerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, v1.AlertStateFiring, alerts[0].State)
+
+	assert.Equal(t, model.LabelSet{"alertname": "HighLatency"}, alerts[0].Labels)
+
+	rules, err := client.Rules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Len(t, rules[0].Rules, 1)
+	assert.Equal(t, "job:up:sum", rules[0].Rules[0].(v1.RecordingRule).Name)
+
+	info, err := client.Buildinfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2.53.0", info.Version)
+}
+
+func TestPrometheusConnection(t *testing.T) {
+	server := prometheustest.NewTLSServer()
+	t.Cleanup(server.Close)
+	server.Scalar("up", 1)
+	server.Delay("slow", 200*time.Millisecond)
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caCert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("first\n"), 0o600))
+
+	logger := logging.NewLogger(logging.LoggerConfiguration{})
+	ctx := context.Background()
+	client, err := NewPrometheusClient(PrometheusConfiguration{
+		Address:         server.URL,
+		CACert:          caCert,
+		BearerTokenPath: tokenPath,
+		Headers:         map[string]string{"X-Scope-OrgID": "tenant-a"},
+		QueryTimeout:    100 * time.Millisecond,
+	}, logger)
+	require.NoError(t, err)
+
+	_, err = client.QueryScalar(ctx, "up", time.Now(), QueryOptions{})
+	require.NoError(t, err)
+	queries := server.Queries()
+	require.Len(t, queries, 1)
+	assert.Equal(t, "Bearer first", queries[0].Header.Get("Authorization"))
+	assert.Equal(t, "tenant-a", queries[0].Header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "100ms", queries[0].Timeout)
+
+	// the rotated token is read again
+	require.NoError(t, os.WriteFile(tokenPath, []byte("second"), 0o600))
+	require.NoError(t, os.Chtimes(tokenPath, time.Now(), time.Now().Add(time.Minute)))
+	_, err = client.QueryScalar(ctx, "up", time.Now(), QueryOptions{})
+	require.NoError(t, err)
+	queries = server.Queries()
+	require.Len(t, queries, 2)
+	assert.Equal(t, "Bearer second", queries[1].Header.Get("Authorization"))
+
+	_, err = client.QueryScalar(ctx, "slow", time.Now(), QueryOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	basic, err := NewPrometheusClient(PrometheusConfiguration{
+		Address: server.URL, CACert: caCert, BasicAuthUsername: "grafana", BasicAuthPassword: "secret",
+	}, logger)
+	require.NoError(t, err)
+	_, err = basic.QueryScalar(ctx, "up", time.Now(), QueryOptions{})
+	require.NoError(t, err)
+	queries = server.Queries()
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("grafana:secret")),
+		queries[len(queries)-1].Header.Get("Authorization"))
+
+	untrusted, err := NewPrometheusClient(PrometheusConfiguration{Address: server.URL, BearerToken: "token"}, logger)
+	require.NoError(t, err)
+	_, err = untrusted.QueryScalar(ctx, "up", time.Now(), QueryOptions{})
+	assert.ErrorContains(t, err, "certificate")
+
+	for _, config := range []PrometheusConfiguration{
+		{Address: server.URL, BearerToken: "token", BasicAuthUsername: "grafana"},
+		{Address: server.URL, Cert: caCert},
+		{Address: server.URL, CACert: filepath.Join(dir, "missing.crt")},
+		{Address: server.URL, BearerTokenPath: filepath.Join(dir, "missing")},
+		{Address: "://invalid"},
+	} {
+		_, err := NewPrometheusClient(config, logger)
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,132 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newPrometheusRoundTripper returns the transport to Prometheus with the TLS, authentication and headers of the
+// configuration.
+func newPrometheusRoundTripper(config PrometheusConfiguration) (http.RoundTripper, error) {
+	tlsConfig, err := newPrometheusTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	headers := http.Header{}
+	for name, value := range config.Headers {
+		headers.Set(name, value)
+	}
+
+	rt := &prometheusRoundTripper{next: transport, headers: headers, config: config}
+	if config.BearerTokenPath != "" {
+		rt.tokenFile = &prometheusTokenFile{path: config.BearerTokenPath}
+		// fail early, instead of at the first query
+		if _, err := rt.tokenFile.token(); err != nil {
+			return nil, err
+		}
+	}
+	return rt, nil
+}
+
+func newPrometheusTLSConfig(config PrometheusConfiguration) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CACert != "" {
+		caCert, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CA cert %s: %w", config.CACert, err)
+		}
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM(caCert); !ok {
+			return nil, fmt.Errorf("no certificates found in the CA cert %s", config.CACert)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if config.Cert != "" || config.CertKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.Cert, config.CertKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the client cert %s, %s: %w", config.Cert, config.CertKey, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// prometheusRoundTripper adds the headers and credentials to the requests.
+type prometheusRoundTripper struct {
+	next      http.RoundTripper
+	headers   http.Header
+	config    PrometheusConfiguration
+	tokenFile *prometheusTokenFile
+}
+
+func (rt *prometheusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the request must not be modified
+	req = req.Clone(req.Context())
+	for name, values := range rt.headers {
+		req.Header[name] = values
+	}
+
+	switch {
+	case rt.tokenFile != nil:
+		token, err := rt.tokenFile.token()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case rt.config.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+rt.config.BearerToken)
+	case rt.config.BasicAuthUsername != "":
+		req.SetBasicAuth(rt.config.BasicAuthUsername, rt.config.BasicAuthPassword)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// prometheusTokenFile reads the token from the file again whenever it changes, as the tokens of service accounts
+// are rotated by Kubernetes.
+type prometheusTokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	value   string
+}
+
+func (f *prometheusTokenFile) token() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the bearer token file: %w", err)
+	}
+	if f.value != "" && info.ModTime().Equal(f.modTime) {
+		return f.value, nil
+	}
+
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the bearer token file: %w", err)
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", errors.New("the bearer token file is empty")
+	}
+	f.value = value
+	f.modTime = info.ModTime()
+	return value, nil
+}
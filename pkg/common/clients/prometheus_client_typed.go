@@ -0,0 +1,228 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	promTag = "prom"
+	// PromValueLabel is the tag of the field receiving the value of the sample in DecodeSamples.
+	PromValueLabel = "__value__"
+	// PromTimeLabel is the tag of the field receiving the time of the sample in DecodeSamples.
+	PromTimeLabel = "__time__"
+)
+
+// ErrEmptyResult is returned when a query requiring a result returns none.
+var ErrEmptyResult = errors.New("empty query result")
+
+// QueryOptions changes how the results of the typed queries are decoded.
+type QueryOptions struct {
+	// RequireResult returns ErrEmptyResult when the query returns no samples.
+	RequireResult bool
+}
+
+// Sample is a sample of an instant vector.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+	Time   time.Time
+}
+
+// Point is a value of a series at a time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a series of a range vector.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// QueryScalar returns the value of a query resulting in a scalar, or in a vector of at most one sample, as
+// usual for aggregations like sum. An empty vector is 0, unless the options require a result.
+func (c PrometheusClient) QueryScalar(ctx context.Context, query string, moment time.Time, opts QueryOptions) (float64, error) {
+	result, err := c.Query(ctx, query, moment)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := result.(type) {
+	case *model.Scalar:
+		return float64(value.Value), nil
+	case model.Vector:
+		switch len(value) {
+		case 0:
+			if opts.RequireResult {
+				return 0, fmt.Errorf("%w: %s", ErrEmptyResult, query)
+			}
+			return 0, nil
+		case 1:
+			return float64(value[0].Value), nil
+		default:
+			return 0, fmt.Errorf("expected a single sample, got %d from %s", len(value), query)
+		}
+	default:
+		return 0, unexpectedResultType(query, result)
+	}
+}
+
+// QueryVector returns the samples of a query resulting in an instant vector, or in a scalar as a sample without
+// labels.
+func (c PrometheusClient) QueryVector(ctx context.Context, query string, moment time.Time, opts QueryOptions) ([]Sample, error) {
+	result, err := c.Query(ctx, query, moment)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	switch value := result.(type) {
+	case *model.Scalar:
+		samples = []Sample{{Labels: map[string]string{}, Value: float64(value.Value), Time: value.Timestamp.Time()}}
+	case model.Vector:
+		samples = make([]Sample, 0, len(value))
+		for _, sample := range value {
+			samples = append(samples, Sample{
+				Labels: labelsOf(sample.Metric),
+				Value:  float64(sample.Value),
+				Time:   sample.Timestamp.Time(),
+			})
+		}
+	default:
+		return nil, unexpectedResultType(query, result)
+	}
+
+	if len(samples) == 0 && opts.RequireResult {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyResult, query)
+	}
+	return samples, nil
+}
+
+// QueryMatrix returns the series of a range query, see QueryRange.
+func (c PrometheusClient) QueryMatrix(
+	ctx context.Context, query string, startAt, endAt time.Time, step time.Duration, opts QueryOptions,
+) ([]Series, error) {
+	result, err := c.QueryRange(ctx, query, startAt, endAt, step)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, unexpectedResultType(query, result)
+	}
+	if len(matrix) == 0 && opts.RequireResult {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyResult, query)
+	}
+
+	series := make([]Series, 0, len(matrix))
+	for _, stream := range matrix {
+		points := make([]Point, 0, len(stream.Values))
+		for _, pair := range stream.Values {
+			points = append(points, Point{Time: pair.Timestamp.Time(), Value: float64(pair.Value)})
+		}
+		series = append(series, Series{Labels: labelsOf(stream.Metric), Points: points})
+	}
+	return series, nil
+}
+
+// DecodeSamples decodes the samples into structs, see DecodeLabels. The value and time of the samples are
+// decoded into the fields tagged with `prom:"__value__"` and `prom:"__time__"`.
+//
+//	type PodUsage struct {
+//		Namespace string    `prom:"namespace,required"`
+//		Pod       string    `prom:"pod,required"`
+//		CPU       float64   `prom:"__value__"`
+//		At        time.Time `prom:"__time__"`
+//	}
+//	usages, err := DecodeSamples[PodUsage](samples)
+func DecodeSamples[T any](samples []Sample) ([]T, error) {
+	decoded := make([]T, len(samples))
+	for i, sample := range samples {
+		if err := decodeSample(sample, &decoded[i]); err != nil {
+			return nil, fmt.Errorf("sample %v: %w", sample.Labels, err)
+		}
+	}
+	return decoded, nil
+}
+
+// DecodeLabels decodes a label set into the struct pointed by target. The fields are matched by their
+// `prom:"label"` tag, and the ones tagged with `prom:"label,required"` must be present. Values are converted to
+// the type of the field as by DecodeSecret.
+func DecodeLabels(labels map[string]string, target interface{}) error {
+	return decodeSample(Sample{Labels: labels}, target)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func decodeSample(sample Sample, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", target)
+	}
+	value = value.Elem()
+
+	var missing []string
+	var errs []error
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		tag := structField.Tag.Get(promTag)
+		if tag == "" || tag == "-" || !structField.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		field := value.Field(i)
+
+		var err error
+		switch name {
+		case PromValueLabel:
+			err = setField(field, float64(sample.Value))
+		case PromTimeLabel:
+			if field.Type() != timeType {
+				err = fmt.Errorf("unsupported type %s", field.Type())
+				break
+			}
+			field.Set(reflect.ValueOf(sample.Time))
+		default:
+			raw, ok := sample.Labels[name]
+			if !ok {
+				if options == "required" {
+					missing = append(missing, name)
+				}
+				continue
+			}
+			err = setField(field, raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", name, err))
+		}
+	}
+
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("missing required labels %s", strings.Join(missing, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+func labelsOf(metric model.Metric) map[string]string {
+	labels := make(map[string]string, len(metric))
+	for name, value := range metric {
+		labels[string(name)] = string(value)
+	}
+	return labels
+}
+
+func unexpectedResultType(query string, result model.Value) error {
+	if result == nil {
+		return fmt.Errorf("no result from %s", query)
+	}
+	return fmt.Errorf("unexpected result type %s from %s", result.Type(), query)
+}
@@ -0,0 +1,388 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Default queries of the ResourceUsage, as text/template over resourceUsageQueryData:
+//   - .Metric is the name of the metric of the query and .Selector the label matchers of the workloads
+//   - .Range is the range of the usage, e.g. 3600s, and .Step the resolution of subqueries, e.g. 60s
+//   - .By are the labels of the groups, and .Join filters the pods and adds the labels of the groups,
+//     while .JoinOverRange does the same for the pods that existed at any time in the range
+//   - .StepSeconds is .Step in seconds, to integrate the samples of subqueries
+const (
+	DefaultCPUSecondsQuery = `sum by ({{ .By }}) (` +
+		`sum by (namespace, pod) (increase({{ .Metric }}{ {{ .Selector }}, container!="", container!="POD" }[{{ .Range }}]))` +
+		`{{ .JoinOverRange }})`
+	DefaultPeakMemoryQuery = `max_over_time((sum by ({{ .By }}) (` +
+		`sum by (namespace, pod) ({{ .Metric }}{ {{ .Selector }}, container!="", container!="POD" })` +
+		`{{ .Join }}))[{{ .Range }}:{{ .Step }}])`
+	DefaultGPUSecondsQuery = `sum_over_time((sum by ({{ .By }}) (` +
+		`sum by (namespace, pod) ({{ .Metric }}{ {{ .Selector }} })` +
+		`{{ .Join }}))[{{ .Range }}:{{ .Step }}]) / 100 * {{ .StepSeconds }}`
+	DefaultPeakGPUMemoryQuery = `max_over_time((sum by ({{ .By }}) (` +
+		`sum by (namespace, pod) ({{ .Metric }}{ {{ .Selector }} })` +
+		`{{ .Join }}))[{{ .Range }}:{{ .Step }}])`
+	DefaultNetworkQuery = `sum by ({{ .By }}) (` +
+		`sum by (namespace, pod) (increase({{ .Metric }}{ {{ .Selector }}, interface!="lo" }[{{ .Range }}]))` +
+		`{{ .JoinOverRange }})`
+)
+
+// UsageGroupBy selects how the usage of the pods is aggregated.
+type UsageGroupBy string
+
+// Aggregations of the usage.
+const (
+	UsageByPod       UsageGroupBy = "pod"
+	UsageByJob       UsageGroupBy = "job"
+	UsageByNamespace UsageGroupBy = "namespace"
+)
+
+// ResourceUsageConfiguration holds the names of the metrics queried by the ResourceUsage, which default to the
+// ones of cAdvisor, kube-state-metrics and the NVIDIA DCGM exporter.
+type ResourceUsageConfiguration struct {
+	CPUMetric       string `envconfig:"RESOURCE_USAGE_CPU_METRIC" default:"container_cpu_usage_seconds_total"`
+	MemoryMetric    string `envconfig:"RESOURCE_USAGE_MEMORY_METRIC" default:"container_memory_working_set_bytes"`
+	GPUMetric       string `envconfig:"RESOURCE_USAGE_GPU_METRIC" default:"DCGM_FI_DEV_GPU_UTIL"`
+	GPUMemoryMetric string `envconfig:"RESOURCE_USAGE_GPU_MEMORY_METRIC" default:"DCGM_FI_DEV_FB_USED"`
+	// GPUMemoryUnitBytes converts the GPU memory metric to bytes, as DCGM reports MiB.
+	GPUMemoryUnitBytes    float64 `envconfig:"RESOURCE_USAGE_GPU_MEMORY_UNIT_BYTES" default:"1048576"`
+	NetworkReceiveMetric  string  `envconfig:"RESOURCE_USAGE_NETWORK_RECEIVE_METRIC" default:"container_network_receive_bytes_total"`
+	NetworkTransmitMetric string  `envconfig:"RESOURCE_USAGE_NETWORK_TRANSMIT_METRIC" default:"container_network_transmit_bytes_total"`
+	PodLabelsMetric       string  `envconfig:"RESOURCE_USAGE_POD_LABELS_METRIC" default:"kube_pod_labels"`
+	PodOwnerMetric        string  `envconfig:"RESOURCE_USAGE_POD_OWNER_METRIC" default:"kube_pod_owner"`
+	// Resolution of the subqueries computing peaks and GPU-seconds.
+	Resolution time.Duration `envconfig:"RESOURCE_USAGE_RESOLUTION" default:"1m"`
+
+	// Queries replace the default queries, see DefaultCPUSecondsQuery.
+	Queries ResourceUsageQueries `ignored:"true"`
+}
+
+// LoadFromEnvVars for ResourceUsageConfiguration.
+func (c *ResourceUsageConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
+
+// ResourceUsageQueries are the templates of the queries of the ResourceUsage, the empty ones use the defaults.
+type ResourceUsageQueries struct {
+	CPUSeconds      string
+	PeakMemory      string
+	GPUSeconds      string
+	PeakGPUMemory   string
+	NetworkReceive  string
+	NetworkTransmit string
+}
+
+// UsageQuery selects the pods and the time range of a usage query.
+type UsageQuery struct {
+	// Namespace of the pods, all when empty.
+	Namespace string
+	// Pods are the names of the pods, all when empty.
+	Pods []string
+	// PodLabels are the labels of the pods, as the Kubernetes labels before being sanitized by kube-state-metrics.
+	PodLabels map[string]string
+	// Job selects the pods owned by the Job.
+	Job string
+
+	Start time.Time
+	End   time.Time
+	// GroupBy defaults to UsageByPod. UsageByJob only includes the pods owned by Jobs.
+	GroupBy UsageGroupBy
+}
+
+// WorkloadUsage is the usage of the resources by a pod, job or namespace between the start and end of the query.
+type WorkloadUsage struct {
+	Namespace string
+	// Pod is only set when grouped by pod.
+	Pod string
+	// Job is only set when grouped by job.
+	Job string
+
+	CPUSeconds           float64
+	PeakMemoryBytes      float64
+	GPUSeconds           float64
+	PeakGPUMemoryBytes   float64
+	NetworkReceiveBytes  float64
+	NetworkTransmitBytes float64
+}
+
+// workloadKey identifies the group of a sample.
+type workloadKey struct {
+	Namespace string `prom:"namespace"`
+	Pod       string `prom:"pod"`
+	Job       string `prom:"owner_name"`
+}
+
+// ResourceUsage answers questions about the resources used by Kubernetes workloads through Prometheus, such as the
+// CPU-seconds and peak memory used by the pods with a label in a namespace between two moments.
+type ResourceUsage struct {
+	client  PrometheusClient
+	config  ResourceUsageConfiguration
+	queries map[string]*template.Template
+}
+
+type resourceUsageQuery struct {
+	name     string
+	template string
+	metric   string
+	scale    float64
+	set      func(*WorkloadUsage, float64)
+}
+
+// resourceUsageQueryData is the data of the templates of the queries.
+type resourceUsageQueryData struct {
+	Metric        string
+	Selector      string
+	Range         string
+	Step          string
+	StepSeconds   string
+	By            string
+	Join          string
+	JoinOverRange string
+}
+
+// NewResourceUsage creates a ResourceUsage querying Prometheus through the client, failing if the templates
+// of the queries are invalid.
+func NewResourceUsage(client PrometheusClient, config ResourceUsageConfiguration) (*ResourceUsage, error) {
+	if config.Resolution <= 0 {
+		config.Resolution = time.Minute
+	}
+	if config.GPUMemoryUnitBytes <= 0 {
+		config.GPUMemoryUnitBytes = 1
+	}
+
+	ru := &ResourceUsage{client: client, config: config, queries: map[string]*template.Template{}}
+	for _, query := range ru.library() {
+		tmpl, err := template.New(query.name).Option("missingkey=error").Parse(query.template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s query: %w", query.name, err)
+		}
+		ru.queries[query.name] = tmpl
+	}
+	return ru, nil
+}
+
+func (ru *ResourceUsage) library() []resourceUsageQuery {
+	queries := ru.config.Queries
+	return []resourceUsageQuery{
+		{"cpu_seconds", orDefault(queries.CPUSeconds, DefaultCPUSecondsQuery), ru.config.CPUMetric, 1,
+			func(u *WorkloadUsage, v float64) { u.CPUSeconds = v }},
+		{"peak_memory", orDefault(queries.PeakMemory, DefaultPeakMemoryQuery), ru.config.MemoryMetric, 1,
+			func(u *WorkloadUsage, v float64) { u.PeakMemoryBytes = v }},
+		{"gpu_seconds", orDefault(queries.GPUSeconds, DefaultGPUSecondsQuery), ru.config.GPUMetric, 1,
+			func(u *WorkloadUsage, v float64) { u.GPUSeconds = v }},
+		{"peak_gpu_memory", orDefault(queries.PeakGPUMemory, DefaultPeakGPUMemoryQuery), ru.config.GPUMemoryMetric,
+			ru.config.GPUMemoryUnitBytes, func(u *WorkloadUsage, v float64) { u.PeakGPUMemoryBytes = v }},
+		{"network_receive", orDefault(queries.NetworkReceive, DefaultNetworkQuery), ru.config.NetworkReceiveMetric, 1,
+			func(u *WorkloadUsage, v float64) { u.NetworkReceiveBytes = v }},
+		{"network_transmit", orDefault(queries.NetworkTransmit, DefaultNetworkQuery), ru.config.NetworkTransmitMetric, 1,
+			func(u *WorkloadUsage, v float64) { u.NetworkTransmitBytes = v }},
+	}
+}
+
+// Usage returns the usage of the pods selected by the query, grouped as requested and sorted by namespace, pod
+// and job. Resources without metrics, such as GPUs in clusters without them, are reported as 0.
+func (ru *ResourceUsage) Usage(ctx context.Context, query UsageQuery) ([]WorkloadUsage, error) {
+	data, err := ru.queryData(query)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := map[workloadKey]*WorkloadUsage{}
+	for _, q := range ru.library() {
+		expr, err := ru.render(q.name, q.metric, data)
+		if err != nil {
+			return nil, err
+		}
+		samples, err := ru.client.QueryVector(ctx, expr, query.End, QueryOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to query the %s: %w", q.name, err)
+		}
+
+		for _, sample := range samples {
+			var key workloadKey
+			if err := DecodeLabels(sample.Labels, &key); err != nil {
+				return nil, fmt.Errorf("unable to decode the %s of %v: %w", q.name, sample.Labels, err)
+			}
+			usage, ok := usages[key]
+			if !ok {
+				usage = &WorkloadUsage{Namespace: key.Namespace, Pod: key.Pod, Job: key.Job}
+				usages[key] = usage
+			}
+			q.set(usage, sample.Value*q.scale)
+		}
+	}
+
+	result := make([]WorkloadUsage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Job < b.Job
+	})
+	return result, nil
+}
+
+// RenderQuery returns the PromQL of a query of the library, e.g. cpu_seconds, for the pods selected by the query,
+// useful to graph it or to check the queries sent.
+func (ru *ResourceUsage) RenderQuery(name string, query UsageQuery) (string, error) {
+	data, err := ru.queryData(query)
+	if err != nil {
+		return "", err
+	}
+	for _, q := range ru.library() {
+		if q.name == name {
+			return ru.render(q.name, q.metric, data)
+		}
+	}
+	return "", fmt.Errorf("unknown query %s", name)
+}
+
+// render executes the template of the query with the metric.
+func (ru *ResourceUsage) render(name, metric string, data resourceUsageQueryData) (string, error) {
+	tmpl, ok := ru.queries[name]
+	if !ok {
+		return "", fmt.Errorf("unknown query %s", name)
+	}
+	data.Metric = metric
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("unable to render the %s query: %w", name, err)
+	}
+	return buffer.String(), nil
+}
+
+func (ru *ResourceUsage) queryData(query UsageQuery) (resourceUsageQueryData, error) {
+	if !query.End.After(query.Start) {
+		return resourceUsageQueryData{}, errors.New("the end of the usage query must be after its start")
+	}
+	rangeSeconds := int64(query.End.Sub(query.Start).Round(time.Second) / time.Second)
+	if rangeSeconds < 1 {
+		rangeSeconds = 1
+	}
+	stepSeconds := int64(ru.config.Resolution.Round(time.Second) / time.Second)
+	if stepSeconds < 1 {
+		stepSeconds = 1
+	}
+
+	var selector []string
+	if query.Namespace != "" {
+		selector = append(selector, "namespace="+strconv.Quote(query.Namespace))
+	}
+	if len(query.Pods) > 0 {
+		selector = append(selector, "pod=~"+strconv.Quote(anyOf(query.Pods)))
+	}
+	if len(selector) == 0 {
+		selector = append(selector, `namespace!=""`)
+	}
+
+	var joins []resourceUsageJoin
+	if len(query.PodLabels) > 0 {
+		names := make([]string, 0, len(query.PodLabels))
+		for name := range query.PodLabels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		matchers := append([]string(nil), selector...)
+		for _, name := range names {
+			matchers = append(matchers, sanitizeLabelName("label_"+name)+"="+strconv.Quote(query.PodLabels[name]))
+		}
+		joins = append(joins, resourceUsageJoin{metric: ru.config.PodLabelsMetric, matchers: matchers})
+	}
+
+	by := "namespace, pod"
+	switch query.GroupBy {
+	case "", UsageByPod:
+	case UsageByNamespace:
+		by = "namespace"
+	case UsageByJob:
+		by = "namespace, owner_name"
+	default:
+		return resourceUsageQueryData{}, fmt.Errorf("unknown usage aggregation %s", query.GroupBy)
+	}
+	if query.Job != "" || query.GroupBy == UsageByJob {
+		matchers := append(append([]string(nil), selector...), `owner_kind="Job"`)
+		if query.Job != "" {
+			matchers = append(matchers, "owner_name="+strconv.Quote(query.Job))
+		}
+		join := resourceUsageJoin{metric: ru.config.PodOwnerMetric, matchers: matchers}
+		if query.GroupBy == UsageByJob {
+			join.labels = "owner_name"
+		}
+		joins = append(joins, join)
+	}
+
+	rangeDuration := fmt.Sprintf("%ds", rangeSeconds)
+	data := resourceUsageQueryData{
+		Selector:    strings.Join(selector, ", "),
+		Range:       rangeDuration,
+		Step:        fmt.Sprintf("%ds", stepSeconds),
+		StepSeconds: strconv.FormatInt(stepSeconds, 10),
+		By:          by,
+	}
+	for _, join := range joins {
+		data.Join += join.render("")
+		data.JoinOverRange += join.render(rangeDuration)
+	}
+	return data, nil
+}
+
+// resourceUsageJoin keeps the pods of a kube-state-metrics metric, adding its labels.
+type resourceUsageJoin struct {
+	metric   string
+	matchers []string
+	labels   string
+}
+
+// render returns the join with the pods of the metric at the time, or at any time in the range when set.
+func (j resourceUsageJoin) render(overRange string) string {
+	selector := fmt.Sprintf("%s{ %s }", j.metric, strings.Join(j.matchers, ", "))
+	if overRange != "" {
+		selector = fmt.Sprintf("max_over_time(%s[%s])", selector, overRange)
+	}
+	by := "namespace, pod"
+	if j.labels != "" {
+		by += ", " + j.labels
+	}
+	return fmt.Sprintf(" * on (namespace, pod) group_left(%s) max by (%s) (%s)", j.labels, by, selector)
+}
+
+var invalidLabelNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sanitizeLabelName converts a Kubernetes label to a Prometheus label name, as kube-state-metrics does.
+func sanitizeLabelName(name string) string {
+	return invalidLabelNameChars.ReplaceAllString(name, "_")
+}
+
+func anyOf(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	return strings.Join(quoted, "|")
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
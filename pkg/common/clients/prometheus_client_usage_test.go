@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/prometheustest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestResourceUsage(t *testing.T) {
+	server := prometheustest.NewServer()
+	defer server.Close()
+
+	client, err := NewPrometheusClient(PrometheusConfiguration{Address: server.URL}, logging.NewLogger(logging.LoggerConfiguration{}))
+	require.NoError(t, err)
+	config := ResourceUsageConfiguration{}
+	require.NoError(t, config.LoadFromEnvVars())
+	usage, err := NewResourceUsage(client, config)
+	require.NoError(t, err)
+
+	train := map[string]string{"namespace": "jobs", "pod": "train-1"}
+	evaluate := map[string]string{"namespace": "jobs", "pod": "evaluate-1"}
+	server.Vector("container_cpu_usage_seconds_total",
+		prometheustest.Sample{Labels: train, Value: 7200}, prometheustest.Sample{Labels: evaluate, Value: 60})
+	server.Vector("container_memory_working_set_bytes", prometheustest.Sample{Labels: train, Value: 4 << 30})
+	server.Vector("DCGM_FI_DEV_FB_USED", prometheustest.Sample{Labels: train, Value: 1024})
+	server.Vector("container_network_receive_bytes_total", prometheustest.Sample{Labels: evaluate, Value: 1000})
+
+	end := time.Unix(1700003600, 0)
+	query := UsageQuery{
+		Namespace: "jobs",
+		PodLabels: map[string]string{"app.kubernetes.io/name": "pipeline"},
+		Start:     end.Add(-time.Hour),
+		End:       end,
+	}
+	usages, err := usage.Usage(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []WorkloadUsage{
+		{Namespace: "jobs", Pod: "evaluate-1", CPUSeconds: 60, NetworkReceiveBytes: 1000},
+		{Namespace: "jobs", Pod: "train-1", CPUSeconds: 7200, PeakMemoryBytes: 4 << 30, PeakGPUMemoryBytes: 1 << 30},
+	}, usages)
+
+	queries := server.Queries()
+	require.Len(t, queries, 6)
+	assert.Equal(t, end, queries[0].Time)
+	assert.Equal(t, `sum by (namespace, pod) (`+
+		`sum by (namespace, pod) (increase(container_cpu_usage_seconds_total{ namespace="jobs", container!="", container!="POD" }[3600s]))`+
+		` * on (namespace, pod) group_left() max by (namespace, pod) `+
+		`(max_over_time(kube_pod_labels{ namespace="jobs", label_app_kubernetes_io_name="pipeline" }[3600s])))`,
+		queries[0].Expr)
+	assert.Equal(t, `max_over_time((sum by (namespace, pod) (`+
+		`sum by (namespace, pod) (container_memory_working_set_bytes{ namespace="jobs", container!="", container!="POD" })`+
+		` * on (namespace, pod) group_left() max by (namespace, pod) `+
+		`(kube_pod_labels{ namespace="jobs", label_app_kubernetes_io_name="pipeline" })))[3600s:60s])`,
+		queries[1].Expr)
+
+	expr, err := usage.RenderQuery("gpu_seconds", UsageQuery{
+		Pods: []string{"train-1", "train.2"}, Job: "train", GroupBy: UsageByJob, Start: query.Start, End: end,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `sum_over_time((sum by (namespace, owner_name) (`+
+		`sum by (namespace, pod) (DCGM_FI_DEV_GPU_UTIL{ pod=~"train-1|train\\.2" })`+
+		` * on (namespace, pod) group_left(owner_name) max by (namespace, pod, owner_name) `+
+		`(kube_pod_owner{ pod=~"train-1|train\\.2", owner_kind="Job", owner_name="train" })))[3600s:60s]) / 100 * 60`,
+		expr)
+
+	server.Reset()
+	server.Vector("container_cpu_usage_seconds_total",
+		prometheustest.Sample{Labels: map[string]string{"namespace": "jobs", "owner_name": "train"}, Value: 7260})
+	usages, err = usage.Usage(context.Background(), UsageQuery{Start: query.Start, End: end, GroupBy: UsageByJob})
+	require.NoError(t, err)
+	assert.Equal(t, []WorkloadUsage{{Namespace: "jobs", Job: "train", CPUSeconds: 7260}}, usages)
+	assert.Contains(t, server.Queries()[0].Expr, `kube_pod_owner{ namespace!="", owner_kind="Job" }`)
+
+	_, err = usage.Usage(context.Background(), UsageQuery{Start: end, End: end})
+	assert.Error(t, err)
+	_, err = usage.Usage(context.Background(), UsageQuery{Start: query.Start, End: end, GroupBy: "node"})
+	assert.Error(t, err)
+	_, err = NewResourceUsage(client, ResourceUsageConfiguration{Queries: ResourceUsageQueries{CPUSeconds: "{{ .Metric"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,343 @@
+package prometheustest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata is the metadata of a metric answered by the Server.
+type Metadata struct {
+	Type string
+	Help string
+	Unit string
+}
+
+// Target is an active scrape target answered by the Server.
+type Target struct {
+	Labels    map[string]string
+	ScrapeURL string
+	// Health is up, down or unknown.
+	Health string
+}
+
+// Alert is an active alert answered by the Server.
+type Alert struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	// State is pending or firing.
+	State    string
+	ActiveAt time.Time
+	Value    float64
+}
+
+// Rule is a recording rule, or an alerting rule, of a rule group answered by the Server.
+type Rule struct {
+	Group    string
+	Name     string
+	Query    string
+	Alerting bool
+}
+
+type discovery struct {
+	series   []map[string]string
+	metadata map[string][]Metadata
+	targets  []Target
+	alerts   []Alert
+	rules    []Rule
+	version  string
+}
+
+// AddSeries registers series by their labels, including __name__. They are answered by the series, labels and
+// label values endpoints, filtered by the selectors of the match[] parameter that only support = matchers.
+func (s *Server) AddSeries(series ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series = append(s.series, series...)
+}
+
+// AddMetadata registers the metadata of a metric.
+func (s *Server) AddMetadata(metric string, metadata Metadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata[metric] = append(s.metadata[metric], metadata)
+}
+
+// AddTarget registers an active scrape target.
+func (s *Server) AddTarget(target Target) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets = append(s.targets, target)
+}
+
+// AddAlert registers an active alert.
+func (s *Server) AddAlert(alert Alert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+}
+
+// AddRule registers a rule in its group, the groups being answered in the order they were first registered.
+func (s *Server) AddRule(rule Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule)
+}
+
+// SetVersion sets the version answered by the build information endpoint.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+}
+
+func (s *Server) serveSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := s.matchSeries(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, series)
+}
+
+func (s *Server) serveLabels(w http.ResponseWriter, r *http.Request) {
+	series, err := s.matchSeries(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	names := map[string]bool{}
+	for _, labels := range series {
+		for name := range labels {
+			names[name] = true
+		}
+	}
+	writeData(w, sortedKeys(names))
+}
+
+func (s *Server) serveLabelValues(w http.ResponseWriter, r *http.Request) {
+	series, err := s.matchSeries(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	values := map[string]bool{}
+	for _, labels := range series {
+		if value, ok := labels[name]; ok {
+			values[value] = true
+		}
+	}
+	writeData(w, sortedKeys(values))
+}
+
+func (s *Server) serveMetadata(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metric := r.FormValue("metric")
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	result := map[string]interface{}{}
+	for _, name := range sortedKeys(s.metadata) {
+		if (metric != "" && name != metric) || (limit > 0 && len(result) == limit) {
+			continue
+		}
+		entries := make([]map[string]string, 0, len(s.metadata[name]))
+		for _, metadata := range s.metadata[name] {
+			entries = append(entries, map[string]string{"type": metadata.Type, "help": metadata.Help, "unit": metadata.Unit})
+		}
+		result[name] = entries
+	}
+	writeData(w, result)
+}
+
+func (s *Server) serveTargets(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := make([]map[string]interface{}, 0, len(s.targets))
+	for _, target := range s.targets {
+		active = append(active, map[string]interface{}{
+			"discoveredLabels":   map[string]string{},
+			"labels":             labelsOrEmpty(target.Labels),
+			"scrapePool":         target.Labels["job"],
+			"scrapeUrl":          target.ScrapeURL,
+			"globalUrl":          target.ScrapeURL,
+			"lastError":          "",
+			"lastScrape":         time.Now().Format(time.RFC3339Nano),
+			"lastScrapeDuration": 0.01,
+			"health":             target.Health,
+		})
+	}
+	writeData(w, map[string]interface{}{"activeTargets": active, "droppedTargets": []interface{}{}})
+}
+
+func (s *Server) serveAlerts(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts := make([]map[string]interface{}, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		alerts = append(alerts, map[string]interface{}{
+			"labels":      labelsOrEmpty(alert.Labels),
+			"annotations": labelsOrEmpty(alert.Annotations),
+			"state":       alert.State,
+			"activeAt":    alert.ActiveAt.Format(time.RFC3339Nano),
+			"value":       formatValue(alert.Value),
+		})
+	}
+	writeData(w, map[string]interface{}{"alerts": alerts})
+}
+
+func (s *Server) serveRules(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var groups []map[string]interface{}
+	indexes := map[string]int{}
+	now := time.Now().Format(time.RFC3339Nano)
+	for _, rule := range s.rules {
+		index, ok := indexes[rule.Group]
+		if !ok {
+			index = len(groups)
+			indexes[rule.Group] = index
+			groups = append(groups, map[string]interface{}{
+				"name":     rule.Group,
+				"file":     rule.Group + ".yaml",
+				"interval": 60,
+				"rules":    []interface{}{},
+			})
+		}
+
+		encoded := map[string]interface{}{
+			"type":           "recording",
+			"name":           rule.Name,
+			"query":          rule.Query,
+			"health":         "ok",
+			"lastEvaluation": now,
+			"evaluationTime": 0.001,
+		}
+		if rule.Alerting {
+			encoded["type"] = "alerting"
+			encoded["duration"] = 0
+			encoded["labels"] = map[string]string{}
+			encoded["annotations"] = map[string]string{}
+			encoded["alerts"] = []interface{}{}
+			encoded["state"] = "inactive"
+		}
+		groups[index]["rules"] = append(groups[index]["rules"].([]interface{}), encoded)
+	}
+	if groups == nil {
+		groups = []map[string]interface{}{}
+	}
+	writeData(w, map[string]interface{}{"groups": groups})
+}
+
+func (s *Server) serveBuildinfo(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := s.version
+	if version == "" {
+		version = "2.53.0"
+	}
+	writeData(w, map[string]string{
+		"version":   version,
+		"revision":  "prometheustest",
+		"branch":    "HEAD",
+		"buildUser": "",
+		"buildDate": "",
+		"goVersion": "",
+	})
+}
+
+// matchSeries returns the registered series matching any of the selectors of the match[] parameter,
+// or all of them without selectors.
+func (s *Server) matchSeries(r *http.Request) ([]map[string]string, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	var selectors []map[string]string
+	for _, selector := range r.Form["match[]"] {
+		matchers, err := parseSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, matchers)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	series := make([]map[string]string, 0, len(s.series))
+	for _, labels := range s.series {
+		if len(selectors) == 0 || matchesAny(labels, selectors) {
+			series = append(series, labels)
+		}
+	}
+	return series, nil
+}
+
+var (
+	selectorPattern = regexp.MustCompile(`^\s*([a-zA-Z_:][a-zA-Z0-9_:]*)?\s*(?:\{(.*)\})?\s*$`)
+	matcherPattern  = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*"((?:[^"\\]|\\.)*)"\s*$`)
+)
+
+// parseSelector returns the labels required by a series selector, e.g. up{job="api"}.
+func parseSelector(selector string) (map[string]string, error) {
+	parts := selectorPattern.FindStringSubmatch(selector)
+	if parts == nil || (parts[1] == "" && strings.TrimSpace(parts[2]) == "") {
+		return nil, fmt.Errorf("invalid series selector %q", selector)
+	}
+
+	matchers := map[string]string{}
+	if parts[1] != "" {
+		matchers["__name__"] = parts[1]
+	}
+	for _, matcher := range strings.Split(parts[2], ",") {
+		if strings.TrimSpace(matcher) == "" {
+			continue
+		}
+		label := matcherPattern.FindStringSubmatch(matcher)
+		if label == nil {
+			return nil, fmt.Errorf("unsupported matcher %q in series selector %q", matcher, selector)
+		}
+		value, err := strconv.Unquote(`"` + label[2] + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q in series selector %q", matcher, selector)
+		}
+		matchers[label[1]] = value
+	}
+	return matchers, nil
+}
+
+func matchesAny(labels map[string]string, selectors []map[string]string) bool {
+	for _, matchers := range selectors {
+		matched := true
+		for name, value := range matchers {
+			if labels[name] != value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,299 @@
+// Package prometheustest provides a stub of the HTTP API of Prometheus for unit tests.
+//
+// The Server answers instant and range queries with the results registered for the queries containing a given
+// text, in the order they were registered, and an empty result otherwise. It records the queries received, so
+// tests can check the PromQL sent along with the headers. The series, metadata, targets, alerts, rules and build
+// information answered by the discovery endpoints are registered too.
+//
+//	server := prometheustest.NewServer()
+//	defer server.Close()
+//	server.Vector("container_cpu_usage_seconds_total", prometheustest.Sample{
+//		Labels: map[string]string{"namespace": "jobs", "pod": "train-1"}, Value: 3600,
+//	})
+//
+//	client, _ := clients.NewPrometheusClient(clients.PrometheusConfiguration{Address: server.URL}, logger)
+package prometheustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sample is a sample of an instant vector answered by the Server, at the time of the query.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Series is a series of a range vector answered by the Server, with a value at each step of the query from its start.
+type Series struct {
+	Labels map[string]string
+	Values []float64
+}
+
+// Query is a query received by the Server.
+type Query struct {
+	Expr string
+	// Time of an instant query.
+	Time time.Time
+	// Start, End and Step of a range query.
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+	Range bool
+	// Timeout is the timeout parameter of the query, if any.
+	Timeout string
+	Header  http.Header
+}
+
+type stub struct {
+	contains string
+	scalar   *float64
+	samples  []Sample
+	series   []Series
+	delay    time.Duration
+}
+
+// Server is a stub of the query API of Prometheus, listening on URL.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	stubs   []stub
+	queries []Query
+	delays  map[string]time.Duration
+	discovery
+}
+
+// NewServer starts a Server without results.
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// NewTLSServer starts a Server without results serving HTTPS, with the certificate of httptest.
+func NewTLSServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(s.handler())
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{}
+	s.Reset()
+	return s
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", s.serveQuery)
+	mux.HandleFunc("/api/v1/query_range", s.serveQueryRange)
+	mux.HandleFunc("/api/v1/series", s.serveSeries)
+	mux.HandleFunc("/api/v1/labels", s.serveLabels)
+	mux.HandleFunc("/api/v1/label/{name}/values", s.serveLabelValues)
+	mux.HandleFunc("/api/v1/metadata", s.serveMetadata)
+	mux.HandleFunc("/api/v1/targets", s.serveTargets)
+	mux.HandleFunc("/api/v1/alerts", s.serveAlerts)
+	mux.HandleFunc("/api/v1/rules", s.serveRules)
+	mux.HandleFunc("/api/v1/status/buildinfo", s.serveBuildinfo)
+	return mux
+}
+
+// Scalar answers the instant queries containing the text with the value.
+func (s *Server) Scalar(contains string, value float64) {
+	s.add(stub{contains: contains, scalar: &value})
+}
+
+// Vector answers the instant queries containing the text with the samples.
+func (s *Server) Vector(contains string, samples ...Sample) {
+	s.add(stub{contains: contains, samples: samples})
+}
+
+// Matrix answers the range queries containing the text with the series. The instant queries containing the text
+// are answered as range selectors, with the values one minute apart up to the time of the query.
+func (s *Server) Matrix(contains string, series ...Series) {
+	s.add(stub{contains: contains, series: series})
+}
+
+// Delay answers the queries containing the text only after the delay, e.g. to test timeouts.
+func (s *Server) Delay(contains string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[contains] = delay
+}
+
+// Queries returns the queries received, in order.
+func (s *Server) Queries() []Query {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Query(nil), s.queries...)
+}
+
+// Reset removes the results, the delays, the registered series, metadata, targets, alerts and rules, and the
+// queries received.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stubs = nil
+	s.queries = nil
+	s.delays = map[string]time.Duration{}
+	s.discovery = discovery{metadata: map[string][]Metadata{}}
+}
+
+func (s *Server) add(st stub) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stubs = append(s.stubs, st)
+}
+
+// match records the query and returns its first stub, once its delay elapsed. Range queries only match the stubs
+// of series.
+func (s *Server) match(r *http.Request, query Query) (stub, bool) {
+	s.mu.Lock()
+	query.Timeout = r.FormValue("timeout")
+	query.Header = r.Header.Clone()
+	s.queries = append(s.queries, query)
+
+	var delay time.Duration
+	for contains, d := range s.delays {
+		if strings.Contains(query.Expr, contains) && d > delay {
+			delay = d
+		}
+	}
+	var matched stub
+	found := false
+	for _, st := range s.stubs {
+		if (query.Range && st.series == nil) || !strings.Contains(query.Expr, st.contains) {
+			continue
+		}
+		matched, found = st, true
+		break
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}
+	return matched, found
+}
+
+func (s *Server) serveQuery(w http.ResponseWriter, r *http.Request) {
+	query := Query{Expr: r.FormValue("query"), Time: time.Now()}
+	if value := r.FormValue("time"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		query.Time = t
+	}
+
+	st, _ := s.match(r, query)
+	ts := float64(query.Time.UnixMilli()) / 1000
+	if st.scalar != nil {
+		writeData(w, map[string]interface{}{"resultType": "scalar", "result": []interface{}{ts, formatValue(*st.scalar)}})
+		return
+	}
+	if st.series != nil {
+		result := make([]map[string]interface{}, 0, len(st.series))
+		for _, series := range st.series {
+			values := make([]interface{}, 0, len(series.Values))
+			for i, value := range series.Values {
+				t := query.Time.Add(-time.Duration(len(series.Values)-1-i) * time.Minute)
+				values = append(values, []interface{}{float64(t.UnixMilli()) / 1000, formatValue(value)})
+			}
+			result = append(result, map[string]interface{}{"metric": labelsOrEmpty(series.Labels), "values": values})
+		}
+		writeData(w, map[string]interface{}{"resultType": "matrix", "result": result})
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(st.samples))
+	for _, sample := range st.samples {
+		result = append(result, map[string]interface{}{
+			"metric": labelsOrEmpty(sample.Labels),
+			"value":  []interface{}{ts, formatValue(sample.Value)},
+		})
+	}
+	writeData(w, map[string]interface{}{"resultType": "vector", "result": result})
+}
+
+func (s *Server) serveQueryRange(w http.ResponseWriter, r *http.Request) {
+	query := Query{Expr: r.FormValue("query"), Range: true}
+	var err error
+	if query.Start, err = parseTime(r.FormValue("start")); err != nil {
+		writeError(w, err)
+		return
+	}
+	if query.End, err = parseTime(r.FormValue("end")); err != nil {
+		writeError(w, err)
+		return
+	}
+	step, err := strconv.ParseFloat(r.FormValue("step"), 64)
+	if err != nil || step <= 0 {
+		writeError(w, fmt.Errorf("invalid step %q", r.FormValue("step")))
+		return
+	}
+	query.Step = time.Duration(step * float64(time.Second))
+
+	st, _ := s.match(r, query)
+	result := make([]map[string]interface{}, 0, len(st.series))
+	for _, series := range st.series {
+		values := make([]interface{}, 0, len(series.Values))
+		for i, value := range series.Values {
+			t := query.Start.Add(time.Duration(i) * query.Step)
+			if t.After(query.End) {
+				break
+			}
+			values = append(values, []interface{}{float64(t.UnixMilli()) / 1000, formatValue(value)})
+		}
+		result = append(result, map[string]interface{}{"metric": labelsOrEmpty(series.Labels), "values": values})
+	}
+	writeData(w, map[string]interface{}{"resultType": "matrix", "result": result})
+}
+
+func parseTime(value string) (time.Time, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", value)
+		}
+		return t, nil
+	}
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(math.Round(fraction*1e3))*int64(time.Millisecond)), nil
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func labelsOrEmpty(labels map[string]string) map[string]string {
+	if labels == nil {
+		return map[string]string{}
+	}
+	return labels
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": data})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "error", "errorType": "bad_data", "error": err.Error()})
+}
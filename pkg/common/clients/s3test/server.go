@@ -0,0 +1,288 @@
+// Package s3test provides an in-memory fake of an S3-compatible server, such as MinIO, for unit tests and local
+// development.
+//
+// The Server implements the object operations used by clients.S3BlobStorage with path style addressing:
+// PutObject, CopyObject, GetObject, HeadObject, DeleteObject and ListObjectsV2. Requests must be signed with
+// AWS Signature Version 4 by the credentials of the Server, and the payload hash must match the body.
+//
+//	server := s3test.NewServer("access", "secret")
+//	defer server.Close()
+//	server.CreateBucket("models")
+//
+//	storage, _ := clients.NewS3BlobStorage(logger, clients.S3BlobStorageConfiguration{
+//		Endpoint: server.URL, Bucket: "models", PathStyle: true, AccessKeyID: "access", SecretAccessKey: "secret",
+//	})
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	defaultMaxKeys  = 1000
+)
+
+// Object is an object stored in the Server.
+type Object struct {
+	Data    []byte
+	ModTime time.Time
+	ETag    string
+}
+
+// Server is an in-memory fake of an S3-compatible server, listening on URL.
+type Server struct {
+	*httptest.Server
+
+	accessKeyID     string
+	secretAccessKey string
+
+	mu       sync.Mutex
+	buckets  map[string]map[string]*Object
+	requests int
+}
+
+// NewServer starts a Server with no buckets, accepting the requests signed by the credentials.
+func NewServer(accessKeyID, secretAccessKey string) *Server {
+	s := &Server{
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		buckets:         map[string]map[string]*Object{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// CreateBucket creates an empty bucket, if it doesn't exist.
+func (s *Server) CreateBucket(bucket string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = map[string]*Object{}
+	}
+}
+
+// PutObject stores the object in the bucket, creating it if needed.
+func (s *Server) PutObject(bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = map[string]*Object{}
+	}
+	s.buckets[bucket][key] = newObject(data)
+}
+
+// Object returns the object of the bucket, if it exists.
+func (s *Server) Object(bucket, key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.buckets[bucket][key]
+	if !ok {
+		return Object{}, false
+	}
+	return *object, true
+}
+
+// Keys returns the sorted keys of the objects of the bucket.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Requests returns the number of requests received.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newObject(data []byte) *Object {
+	sum := md5.Sum(data)
+	return &Object{Data: data, ModTime: time.Now().UTC().Truncate(time.Second), ETag: hex.EncodeToString(sum[:])}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if code, message := s.verifySignature(r, body); code != "" {
+		writeError(w, http.StatusForbidden, code, message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, r, bucket)
+	case key == "":
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		bucket[key] = newObject(body)
+		w.Header().Set("ETag", `"`+bucket[key].ETag+`"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, bucket, key)
+	case r.Method == http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucket map[string]*Object, key string) {
+	object, ok := bucket[key]
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	w.Header().Set("ETag", `"`+object.ETag+`"`)
+	w.Header().Set("Last-Modified", object.ModTime.Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.Itoa(len(object.Data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(object.Data)
+	}
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucket map[string]*Object, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "Copy Source must mention the source bucket and key")
+		return
+	}
+	sourceBucket, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	object, ok := s.buckets[sourceBucket][sourceKey]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	bucket[key] = newObject(object.Data)
+	writeXML(w, http.StatusOK, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string   `xml:"ETag"`
+		LastModified string   `xml:"LastModified"`
+	}{ETag: `"` + bucket[key].ETag + `"`, LastModified: bucket[key].ModTime.Format(time.RFC3339)})
+}
+
+type listContent struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucket map[string]*Object) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "Only ListObjectsV2 is supported")
+		return
+	}
+	prefix := query.Get("prefix")
+	maxKeys := defaultMaxKeys
+	if value := query.Get("max-keys"); value != "" {
+		maxKeys, _ = strconv.Atoi(value)
+	}
+	// the continuation token is the last key of the previous page
+	after := query.Get("continuation-token")
+	if startAfter := query.Get("start-after"); startAfter > after {
+		after = startAfter
+	}
+
+	var keys []string
+	for key := range bucket {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	truncated := len(keys) > maxKeys
+	if truncated {
+		keys = keys[:maxKeys]
+	}
+
+	contents := make([]listContent, len(keys))
+	for i, key := range keys {
+		object := bucket[key]
+		contents[i] = listContent{
+			Key:          key,
+			LastModified: object.ModTime.Format(time.RFC3339),
+			ETag:         `"` + object.ETag + `"`,
+			Size:         len(object.Data),
+		}
+	}
+
+	result := struct {
+		XMLName               xml.Name      `xml:"ListBucketResult"`
+		Prefix                string        `xml:"Prefix"`
+		KeyCount              int           `xml:"KeyCount"`
+		MaxKeys               int           `xml:"MaxKeys"`
+		IsTruncated           bool          `xml:"IsTruncated"`
+		Contents              []listContent `xml:"Contents"`
+		NextContinuationToken string        `xml:"NextContinuationToken,omitempty"`
+	}{Prefix: prefix, KeyCount: len(contents), MaxKeys: maxKeys, IsTruncated: truncated, Contents: contents}
+	if truncated {
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
+
+func writeXML(w http.ResponseWriter, status int, value interface{}) {
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	if err := xml.NewEncoder(&body).Encode(value); err != nil {
+		http.Error(w, fmt.Sprintf("unable to encode the response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
@@ -0,0 +1,97 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// ErrObjectNotFound is returned when there is no object with the requested key.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes an object of a BlobStorage.
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+	// ETag identifies the content of the object, if supported by the backend.
+	ETag string
+}
+
+// BlobStorage is a backend agnostic storage of objects, identified by slash separated keys, e.g. models/v1/weights.
+// Keys are relative to the root of the storage, a leading slash is ignored.
+type BlobStorage interface {
+	// Open returns a reader of the content of the object, to be closed by the caller.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Create returns a writer that replaces the content of the object once closed.
+	Create(ctx context.Context, key string) (io.WriteCloser, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// List returns the objects with keys starting with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Delete removes the object. Deleting an object that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+	Copy(ctx context.Context, srcKey, dstKey string) error
+	Move(ctx context.Context, srcKey, dstKey string) error
+}
+
+// NewBlobStorage returns the BlobStorage selected by the scheme of the URL of the configuration:
+//   - file:///path, or an empty URL for BasePath, the local filesystem
+//   - mem:// an in-memory storage, lost when the process exits
+//   - s3://bucket/prefix an S3-compatible storage, configured by the S3 fields
+func NewBlobStorage(logger logging.Logger, configuration BlobStorageConfiguration) (BlobStorage, error) {
+	if configuration.URL == "" {
+		return NewLocalBlobStorage(logger, configuration.BasePath), nil
+	}
+
+	u, err := url.Parse(configuration.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL %s: %w", configuration.URL, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewLocalBlobStorage(logger, u.Host+u.Path), nil
+	case "mem", "memory":
+		return NewMemoryBlobStorage(), nil
+	case "s3":
+		return NewS3BlobStorage(logger, S3BlobStorageConfiguration{
+			Endpoint:        configuration.S3Endpoint,
+			Region:          configuration.S3Region,
+			Bucket:          u.Host,
+			Prefix:          strings.TrimPrefix(u.Path, "/"),
+			AccessKeyID:     configuration.S3AccessKeyID,
+			SecretAccessKey: configuration.S3SecretAccessKey,
+			SessionToken:    configuration.S3SessionToken,
+			PathStyle:       configuration.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unsupported storage URL scheme %q", u.Scheme)
+}
+
+// cleanKey normalizes the key, so a/./b, /a/b and a//b are the same object.
+func cleanKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return cleaned, nil
+}
+
+// cleanPrefix normalizes the prefix of a listing, keeping a trailing slash.
+func cleanPrefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	cleaned := strings.TrimPrefix(path.Clean("/"+prefix), "/")
+	if strings.HasSuffix(prefix, "/") && cleaned != "" {
+		cleaned += "/"
+	}
+	return cleaned
+}
@@ -0,0 +1,195 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// LocalBlobStorage is a BlobStorage that keeps the objects as files under a base path.
+type LocalBlobStorage struct {
+	logger   logging.Logger
+	basePath string
+}
+
+// NewLocalBlobStorage returns a LocalBlobStorage storing the objects under basePath.
+func NewLocalBlobStorage(logger logging.Logger, basePath string) *LocalBlobStorage {
+	if absolutePath, err := filepath.Abs(basePath); err == nil {
+		basePath = absolutePath
+	}
+	return &LocalBlobStorage{logger: logger, basePath: filepath.Clean(basePath)}
+}
+
+// Open returns a reader of the file of the object.
+func (s *LocalBlobStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, s.wrapError(key, err)
+	}
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return file, nil
+}
+
+// Create returns a writer to a temporary file that replaces the file of the object once closed,
+// creating its parent directories.
+func (s *LocalBlobStorage) Create(_ context.Context, key string) (io.WriteCloser, error) {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return newAtomicFileWriter(fullPath, false)
+}
+
+// Stat returns the information of the file of the object.
+func (s *LocalBlobStorage) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return ObjectInfo{}, s.wrapError(key, err)
+	}
+	if info.IsDir() {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return ObjectInfo{Key: s.key(fullPath), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List walks the directories under the base path matching the prefix and returns their files, sorted by key.
+func (s *LocalBlobStorage) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	prefix = cleanPrefix(prefix)
+
+	// only walk the deepest directory containing the prefix
+	root := s.basePath
+	if dir := path.Dir(prefix + "x"); dir != "." {
+		root = filepath.Join(s.basePath, filepath.FromSlash(dir))
+	}
+
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || isTempFile(entry.Name()) {
+			return nil
+		}
+
+		key := s.key(fullPath)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("while listing prefix %s. Error: %v", prefix, err)
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the file of the object.
+func (s *LocalBlobStorage) Delete(_ context.Context, key string) error {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("attempting to delete %s", fullPath)
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.logger.Errorf("while deleting %s. Error: %v", fullPath, err)
+		return err
+	}
+	return nil
+}
+
+// Copy copies the file of the object to dstKey, replacing it if it exists.
+func (s *LocalBlobStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	src, err := s.Open(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := s.Create(ctx, dstKey)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Move renames the file of the object to dstKey, replacing it if it exists.
+func (s *LocalBlobStorage) Move(_ context.Context, srcKey, dstKey string) error {
+	srcPath, err := s.fullPath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := s.fullPath(dstKey)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("attempting to move %s to %s", srcPath, dstPath)
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		s.logger.Errorf("while moving %s to %s. Error: %v", srcPath, dstPath, err)
+		return s.wrapError(srcKey, err)
+	}
+	return nil
+}
+
+// fullPath returns the path of the file of the object, or ErrOutsideBasePath if a symlink leads outside of it.
+func (s *LocalBlobStorage) fullPath(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return resolvePath(s.basePath, filepath.FromSlash(key))
+}
+
+// key returns the key of the object stored in the file.
+func (s *LocalBlobStorage) key(fullPath string) string {
+	relativePath, _ := filepath.Rel(s.basePath, fullPath)
+	return filepath.ToSlash(relativePath)
+}
+
+func (s *LocalBlobStorage) wrapError(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s: %w", ErrObjectNotFound, key, err)
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data    []byte
+	modTime time.Time
+	etag    string
+}
+
+// MemoryBlobStorage is a BlobStorage that keeps the objects in memory, for tests and local development.
+type MemoryBlobStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// NewMemoryBlobStorage returns an empty MemoryBlobStorage.
+func NewMemoryBlobStorage() *MemoryBlobStorage {
+	return &MemoryBlobStorage{objects: map[string]memoryObject{}}
+}
+
+// Open returns a reader of the content of the object.
+func (s *MemoryBlobStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	object, _, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+// Create returns a writer that stores the object once closed.
+func (s *MemoryBlobStorage) Create(_ context.Context, key string) (io.WriteCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryWriter{storage: s, key: key}, nil
+}
+
+// Stat returns the information of the object.
+func (s *MemoryBlobStorage) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	object, key, err := s.get(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return object.info(key), nil
+}
+
+// List returns the objects with keys starting with prefix, sorted by key.
+func (s *MemoryBlobStorage) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	prefix = cleanPrefix(prefix)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	objects := []ObjectInfo{}
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, object.info(key))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the object.
+func (s *MemoryBlobStorage) Delete(_ context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// Copy copies the object to dstKey, replacing it if it exists.
+func (s *MemoryBlobStorage) Copy(_ context.Context, srcKey, dstKey string) error {
+	object, _, err := s.get(srcKey)
+	if err != nil {
+		return err
+	}
+	if dstKey, err = cleanKey(dstKey); err != nil {
+		return err
+	}
+
+	s.put(dstKey, object.data)
+	return nil
+}
+
+// Move renames the object to dstKey, replacing it if it exists.
+func (s *MemoryBlobStorage) Move(_ context.Context, srcKey, dstKey string) error {
+	srcKey, err := cleanKey(srcKey)
+	if err != nil {
+		return err
+	}
+	if dstKey, err = cleanKey(dstKey); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrObjectNotFound, srcKey)
+	}
+	delete(s.objects, srcKey)
+	s.objects[dstKey] = object
+	return nil
+}
+
+func (s *MemoryBlobStorage) get(key string) (memoryObject, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return memoryObject{}, "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return memoryObject{}, "", fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return object, key, nil
+}
+
+func (s *MemoryBlobStorage) put(key string, data []byte) {
+	sum := md5.Sum(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memoryObject{data: data, modTime: time.Now(), etag: hex.EncodeToString(sum[:])}
+}
+
+func (o memoryObject) info(key string) ObjectInfo {
+	return ObjectInfo{Key: key, Size: int64(len(o.data)), ModTime: o.modTime, ETag: o.etag}
+}
+
+type memoryWriter struct {
+	storage *MemoryBlobStorage
+	key     string
+	buffer  bytes.Buffer
+	closed  bool
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("write to closed object %s", w.key)
+	}
+	return w.buffer.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.storage.put(w.key, w.buffer.Bytes())
+	return nil
+}
@@ -0,0 +1,360 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// S3BlobStorageConfiguration holds the settings of a S3BlobStorage.
+type S3BlobStorageConfiguration struct {
+	// Endpoint of the S3-compatible server, e.g. http://localhost:9000, defaults to AWS S3 in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix of the keys of the objects in the bucket, so several storages can share it.
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// PathStyle addresses the bucket in the path, http://endpoint/bucket/key, instead of the host.
+	PathStyle bool
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// S3Error is the error returned by the S3 server, other than a missing object.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3: %s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// S3BlobStorage is a BlobStorage that keeps the objects in a bucket of an S3-compatible server, such as AWS S3
+// or MinIO. Requests are signed with AWS Signature Version 4.
+type S3BlobStorage struct {
+	logger        logging.Logger
+	configuration S3BlobStorageConfiguration
+	endpoint      *url.URL
+	client        *http.Client
+}
+
+// NewS3BlobStorage returns a S3BlobStorage storing the objects in the bucket of the configuration.
+func NewS3BlobStorage(logger logging.Logger, configuration S3BlobStorageConfiguration) (*S3BlobStorage, error) {
+	if configuration.Bucket == "" {
+		return nil, errors.New("missing S3 bucket")
+	}
+	if configuration.Region == "" {
+		configuration.Region = "us-east-1"
+	}
+	if configuration.Endpoint == "" {
+		configuration.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", configuration.Region)
+	}
+	if configuration.Prefix = cleanPrefix(configuration.Prefix); configuration.Prefix != "" &&
+		!strings.HasSuffix(configuration.Prefix, "/") {
+		configuration.Prefix += "/"
+	}
+
+	endpoint, err := url.Parse(configuration.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %s: %w", configuration.Endpoint, err)
+	}
+
+	client := configuration.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3BlobStorage{
+		logger:        logger,
+		configuration: configuration,
+		endpoint:      endpoint,
+		client:        client,
+	}, nil
+}
+
+// Open returns a reader of the body of the object, streamed from the server.
+func (s *S3BlobStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, s.objectKey(key), nil, nil, nil, 0, s3EmptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.responseError(resp, key)
+	}
+	return resp.Body, nil
+}
+
+// Create returns a writer that buffers the object in a temporary file and uploads it once closed.
+func (s *S3BlobStorage) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "s3-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &s3Writer{ctx: ctx, storage: s, key: key, file: file, hash: sha256.New()}, nil
+}
+
+// Stat returns the information of the object, from its headers.
+func (s *S3BlobStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, s.objectKey(key), nil, nil, nil, 0, s3EmptyPayloadHash)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ObjectInfo{}, s.responseError(resp, key)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return ObjectInfo{
+		Key:     key,
+		Size:    resp.ContentLength,
+		ModTime: modTime,
+		ETag:    strings.Trim(resp.Header.Get("ETag"), `"`),
+	}, nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects with keys starting with prefix, following all the pages of the listing.
+func (s *S3BlobStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	query := url.Values{
+		"list-type": {"2"},
+		"prefix":    {s.objectKey(cleanPrefix(prefix))},
+	}
+
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil, 0, s3EmptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, s.responseError(resp, prefix)
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the listing of %s: %w", prefix, err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:     strings.TrimPrefix(content.Key, s.configuration.Prefix),
+				Size:    content.Size,
+				ModTime: content.LastModified,
+				ETag:    strings.Trim(content.ETag, `"`),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// Delete removes the object.
+func (s *S3BlobStorage) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("attempting to delete %s", key)
+	resp, err := s.do(ctx, http.MethodDelete, s.objectKey(key), nil, nil, nil, 0, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode != http.StatusNotFound {
+		return s.responseError(resp, key)
+	}
+	return nil
+}
+
+// Copy copies the object to dstKey on the server, replacing it if it exists.
+func (s *S3BlobStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	srcKey, err := cleanKey(srcKey)
+	if err != nil {
+		return err
+	}
+	if dstKey, err = cleanKey(dstKey); err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", "/"+s.configuration.Bucket+"/"+s3Escape(s.objectKey(srcKey), false))
+	resp, err := s.do(ctx, http.MethodPut, s.objectKey(dstKey), nil, header, nil, 0, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp, srcKey)
+	}
+
+	// the copy may fail after the server sent the status, with an error in the body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(body, []byte("<Error>")) {
+		s3Err := &S3Error{StatusCode: resp.StatusCode}
+		_ = xml.Unmarshal(body, s3Err)
+		return s3Err
+	}
+	return nil
+}
+
+// Move copies the object to dstKey and deletes it, as S3 has no rename.
+func (s *S3BlobStorage) Move(ctx context.Context, srcKey, dstKey string) error {
+	if err := s.Copy(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	return s.Delete(ctx, srcKey)
+}
+
+// objectKey returns the key of the object in the bucket.
+func (s *S3BlobStorage) objectKey(key string) string {
+	return s.configuration.Prefix + key
+}
+
+// do sends a signed request for the object, or for the bucket when objectKey is empty.
+func (s *S3BlobStorage) do(ctx context.Context, method, objectKey string, query url.Values, header http.Header,
+	body io.Reader, size int64, payloadHash string,
+) (*http.Response, error) {
+	u := *s.endpoint
+	objectPath := "/" + objectKey
+	if s.configuration.PathStyle {
+		objectPath = "/" + s.configuration.Bucket + objectPath
+	} else {
+		u.Host = s.configuration.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + s3Escape(objectPath, false)
+	u.RawQuery = query.Encode()
+
+	if body == nil || size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	signS3Request(req, payloadHash, s.configuration, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Errorf("while requesting %s %s. Error: %v", method, objectKey, err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// responseError returns ErrObjectNotFound for a missing object, or the S3Error in the body of the response.
+func (s *S3BlobStorage) responseError(resp *http.Response, key string) error {
+	s3Err := &S3Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	_ = xml.Unmarshal(body, s3Err)
+
+	if resp.StatusCode == http.StatusNotFound && (s3Err.Code == "" || s3Err.Code == "NoSuchKey") {
+		return fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if s3Err.Code == "" {
+		s3Err.Code = http.StatusText(resp.StatusCode)
+	}
+	return s3Err
+}
+
+// s3Writer buffers the object in a temporary file, as S3 requires its size and hash before the upload.
+type s3Writer struct {
+	ctx     context.Context
+	storage *S3BlobStorage
+	key     string
+	file    *os.File
+	hash    hash.Hash
+	size    int64
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *s3Writer) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	defer func() {
+		w.file.Close()
+		os.Remove(w.file.Name())
+		w.file = nil
+	}()
+
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	payloadHash := hex.EncodeToString(w.hash.Sum(nil))
+	resp, err := w.storage.do(w.ctx, http.MethodPut, w.storage.objectKey(w.key), nil, nil,
+		io.NopCloser(w.file), w.size, payloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return w.storage.responseError(resp, w.key)
+	}
+	return nil
+}
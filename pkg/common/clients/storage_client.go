@@ -1,8 +1,12 @@
 package clients
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ydataai/go-core/pkg/common/logging"
 )
@@ -11,6 +15,7 @@ import (
 type StorageClient struct {
 	logger        logging.Logger
 	configuration StorageClientConfiguration
+	quotas        *storageQuotas
 }
 
 // StorageClientInterface defines storage client interface
@@ -19,7 +24,23 @@ type StorageClientInterface interface {
 	CreateDirectory(relativePath string) error
 	RemoveDirectory(relativePath string) error
 	CheckIfExists(relativePath string) bool
-	Rename(fromRelativePath, toAbsolutePath string) error
+	Rename(fromRelativePath, toRelativePath string) error
+	MoveOutsideBasePath(fromRelativePath, toAbsolutePath, reason string) error
+	WriteFile(relativePath string, data []byte) error
+	OpenWriter(relativePath string) (*AtomicFileWriter, error)
+	ReadFile(relativePath string) ([]byte, error)
+	ReadFileRange(relativePath string, offset, length int64) ([]byte, error)
+	OpenReader(relativePath string) (io.ReadCloser, error)
+	OpenRangeReader(relativePath string, offset, length int64) (io.ReadCloser, error)
+	Checksum(relativePath string) (string, error)
+	VerifyChecksum(relativePath string) error
+	List(relativePath string, opts ListOptions) (ListResult, error)
+	Walk(relativePath string, visit func(FileInfo) error) error
+	DiskUsage(relativePath string) (Usage, error)
+	SetQuota(prefix string, maxBytes int64)
+	CheckQuota(relativePath string, additionalBytes int64) error
+	Archive(relativePath string, format ArchiveFormat, w io.Writer) error
+	Extract(relativePath string, format ArchiveFormat, r io.Reader, opts ExtractOptions) error
 }
 
 // NewStorageClient returns an initialized struct with the required dependencies injected
@@ -27,6 +48,7 @@ func NewStorageClient(logger logging.Logger, configuration StorageClientConfigur
 	return StorageClient{
 		logger:        logger,
 		configuration: configuration,
+		quotas:        newStorageQuotas(configuration.Quotas),
 	}
 }
 
@@ -36,53 +58,118 @@ func (sc *StorageClient) BasePath() string {
 }
 
 // CreateDirectory creates a new directory in the give path
-// fails if the the path exists and is not a folder or other reason os related
+// fails if the the path exists and is not a folder, is outside of the base path or other reason os related
 func (sc *StorageClient) CreateDirectory(relativePath string) error {
 	sc.logger.Infof("attempting to create directory %s", relativePath)
 
-	fullPath := fmt.Sprintf("%s/%s", sc.configuration.BasePath, relativePath)
-	pathInfo, err := os.Stat(fullPath)
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return err
+	}
 
+	pathInfo, err := os.Stat(fullPath)
 	if os.IsNotExist(err) {
 		return os.MkdirAll(fullPath, os.ModePerm)
 	}
-
-	if os.IsExist(err) && pathInfo.IsDir() {
-		return nil
+	if err == nil && !pathInfo.IsDir() {
+		return fmt.Errorf("%s exists and is not a directory", relativePath)
 	}
 
 	return err
 }
 
 // RemoveDirectory attempts to remove the directory that holds requirements.txt
+// fails if the path is outside of the base path, or is the base path itself
 func (sc *StorageClient) RemoveDirectory(relativePath string) error {
-	fullPath := fmt.Sprintf("%s/%s", sc.configuration.BasePath, relativePath)
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return err
+	}
+	if base, _ := filepath.Abs(sc.configuration.BasePath); fullPath == base {
+		return fmt.Errorf("refusing to remove the base path %s", base)
+	}
+
 	sc.logger.Infof("attempting to remove %s", fullPath)
 	if err := os.RemoveAll(fullPath); err != nil {
-		sc.logger.Errorf("while remove path %s. Error:", relativePath, err)
+		sc.logger.Errorf("while remove path %s. Error: %v", relativePath, err)
 		return err
 	}
 	return nil
 }
 
-// CheckIfExists attempts to check if the directory exists
+// CheckIfExists attempts to check if the directory exists, paths outside of the base path never exist
 func (sc *StorageClient) CheckIfExists(relativePath string) bool {
-	fullPath := fmt.Sprintf("%s/%s", sc.configuration.BasePath, relativePath)
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return false
+	}
+
 	if _, err := os.Stat(fullPath); err != nil && os.IsNotExist(err) {
-		sc.logger.Errorf("while check path %s. Error:", relativePath, err)
+		sc.logger.Errorf("while check path %s. Error: %v", relativePath, err)
 		return false
 	}
 
 	return true
 }
 
-// Rename attempts to rename a path
-func (sc *StorageClient) Rename(fromRelativePath, toAbsolutePath string) error {
-	sc.logger.Infof("attempting to rename from %s to %s", fromRelativePath, toAbsolutePath)
-	if err := os.Rename(fromRelativePath, toAbsolutePath); err != nil {
-		sc.logger.Errorf("while rename path from %s to %s. Error:", fromRelativePath, toAbsolutePath, err)
+// Rename attempts to rename a path, both relative to the base path. For compatibility, the destination may be
+// an absolute path as long as it's within the base path, use MoveOutsideBasePath otherwise
+func (sc *StorageClient) Rename(fromRelativePath, toRelativePath string) error {
+	sc.logger.Infof("attempting to rename from %s to %s", fromRelativePath, toRelativePath)
+
+	fromPath, err := resolvePath(sc.configuration.BasePath, fromRelativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", fromRelativePath, err)
+		return err
+	}
+	toPath, err := resolvePath(sc.configuration.BasePath, toRelativePath)
+	if filepath.IsAbs(toRelativePath) {
+		toPath, err = resolveAbsolutePath(sc.configuration.BasePath, toRelativePath)
+	}
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", toRelativePath, err)
+		return err
+	}
+
+	if err := os.Rename(fromPath, toPath); err != nil {
+		sc.logger.Errorf("while rename path from %s to %s. Error: %v", fromRelativePath, toRelativePath, err)
+		return err
+	}
+	// the checksum of the file, if any, follows it
+	if err := os.Rename(fromPath+ChecksumSuffix, toPath+ChecksumSuffix); err != nil && !os.IsNotExist(err) {
+		sc.logger.Errorf("while rename the checksum of %s. Error: %v", fromRelativePath, err)
+		return err
+	}
+
+	return nil
+}
+
+// MoveOutsideBasePath moves a path under the base path to an absolute path anywhere in the filesystem.
+// It's meant for the rare cases where data has to leave the storage, e.g. to be handed to another volume,
+// so every call is logged for auditing along with its reason, which is required
+func (sc *StorageClient) MoveOutsideBasePath(fromRelativePath, toAbsolutePath, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("a reason is required to move a path outside of the base path")
+	}
+	if !filepath.IsAbs(toAbsolutePath) {
+		return fmt.Errorf("%s is not an absolute path", toAbsolutePath)
+	}
+
+	fromPath, err := resolvePath(sc.configuration.BasePath, fromRelativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", fromRelativePath, err)
+		return err
+	}
+
+	sc.logger.Warnf("[AUDIT] moving %s outside of the base path to %s. Reason: %s", fromPath, toAbsolutePath, reason)
+	if err := os.Rename(fromPath, toAbsolutePath); err != nil {
+		sc.logger.Errorf("[AUDIT] while moving %s to %s. Error: %v", fromPath, toAbsolutePath, err)
 		return err
 	}
+	sc.logger.Warnf("[AUDIT] moved %s outside of the base path to %s", fromPath, toAbsolutePath)
 
 	return nil
 }
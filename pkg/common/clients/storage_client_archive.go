@@ -0,0 +1,406 @@
+package clients
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFormat is the format of the archives created and extracted by the StorageClient.
+type ArchiveFormat string
+
+// Archive formats supported by Archive and Extract.
+const (
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	ArchiveZip   ArchiveFormat = "zip"
+)
+
+// Default limits of Extract.
+const (
+	DefaultExtractMaxBytes   int64 = 1 << 30
+	DefaultExtractMaxEntries       = 10000
+)
+
+// ErrArchiveLimitExceeded is returned when an archive exceeds the limits of the extraction.
+var ErrArchiveLimitExceeded = errors.New("archive limit exceeded")
+
+// ArchiveFormatFromName returns the format matching the extension of the file name, e.g. dataset.zip.
+func ArchiveFormatFromName(name string) (ArchiveFormat, error) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveTarGz, nil
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveZip, nil
+	}
+	return "", fmt.Errorf("unsupported archive %s", name)
+}
+
+// ContentType returns the MIME type of the format, to serve the archives over HTTP.
+func (f ArchiveFormat) ContentType() string {
+	if f == ArchiveZip {
+		return "application/zip"
+	}
+	return "application/gzip"
+}
+
+// Extension returns the file extension of the format, with the leading dot.
+func (f ArchiveFormat) Extension() string {
+	return "." + string(f)
+}
+
+// ExtractOptions limits the archives extracted by Extract.
+type ExtractOptions struct {
+	// MaxBytes limits the uncompressed size of the files, 0 uses DefaultExtractMaxBytes and a negative value
+	// disables the limit.
+	MaxBytes int64
+	// MaxEntries limits the number of files and directories, 0 uses DefaultExtractMaxEntries and a negative value
+	// disables the limit.
+	MaxEntries int
+}
+
+// Archive streams the directory as an archive to w, with the paths relative to the directory, so it can be served
+// directly from an HTTP handler:
+//
+//	c.Header("Content-Type", clients.ArchiveZip.ContentType())
+//	err := storage.Archive(path, clients.ArchiveZip, c.Writer)
+//
+// The checksums and temporary files of the StorageClient, and symlinks, are left out.
+func (sc *StorageClient) Archive(relativePath string, format ArchiveFormat, w io.Writer) error {
+	root, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return err
+	}
+
+	var archive archiveWriter
+	switch format {
+	case ArchiveTarGz:
+		archive = newTarGzArchiveWriter(w)
+	case ArchiveZip:
+		archive = &zipArchiveWriter{writer: zip.NewWriter(w)}
+	default:
+		return fmt.Errorf("unsupported archive format %s", format)
+	}
+
+	sc.logger.Infof("attempting to archive %s as %s", relativePath, format)
+	err = sc.Walk(relativePath, func(info FileInfo) error {
+		if !info.IsDir && !info.Mode.IsRegular() {
+			return nil
+		}
+		name := sc.relativeTo(root, info.Path)
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		return archive.add(fullPath, name, info)
+	})
+	if err != nil {
+		archive.Close()
+		sc.logger.Errorf("while archiving %s. Error: %v", relativePath, err)
+		return err
+	}
+	return archive.Close()
+}
+
+// Extract extracts the archive read from r into the directory, creating it if needed and replacing the existing
+// files. The entries are written as by WriteFile, with their permissions, and the other types of entries, such
+// as symlinks, are skipped. Returns ErrOutsideBasePath if an entry would be extracted outside of the directory,
+// and ErrArchiveLimitExceeded if the archive exceeds the limits, leaving the entries extracted so far.
+func (sc *StorageClient) Extract(relativePath string, format ArchiveFormat, r io.Reader, opts ExtractOptions) error {
+	root, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return err
+	}
+
+	extractor := &archiveExtractor{sc: sc, root: root, relativePath: relativePath, opts: opts.withDefaults()}
+	sc.logger.Infof("attempting to extract a %s archive into %s", format, relativePath)
+
+	switch format {
+	case ArchiveTarGz:
+		err = extractor.extractTarGz(r)
+	case ArchiveZip:
+		err = extractor.extractZip(r)
+	default:
+		return fmt.Errorf("unsupported archive format %s", format)
+	}
+	if err != nil {
+		sc.logger.Errorf("while extracting into %s. Error: %v", relativePath, err)
+	}
+	return err
+}
+
+func (o ExtractOptions) withDefaults() ExtractOptions {
+	if o.MaxBytes == 0 {
+		o.MaxBytes = DefaultExtractMaxBytes
+	}
+	if o.MaxEntries == 0 {
+		o.MaxEntries = DefaultExtractMaxEntries
+	}
+	return o
+}
+
+type archiveWriter interface {
+	add(fullPath, name string, info FileInfo) error
+	Close() error
+}
+
+type tarGzArchiveWriter struct {
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+func newTarGzArchiveWriter(w io.Writer) *tarGzArchiveWriter {
+	gz := gzip.NewWriter(w)
+	return &tarGzArchiveWriter{gzip: gz, tar: tar.NewWriter(gz)}
+}
+
+func (a *tarGzArchiveWriter) add(fullPath, name string, info FileInfo) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode.Perm()),
+		ModTime: info.ModTime,
+	}
+	if info.IsDir {
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		return a.tar.WriteHeader(header)
+	}
+
+	header.Typeflag = tar.TypeReg
+	header.Size = info.Size
+	if err := a.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	return copyFile(a.tar, fullPath, info.Size)
+}
+
+func (a *tarGzArchiveWriter) Close() error {
+	if err := a.tar.Close(); err != nil {
+		a.gzip.Close()
+		return err
+	}
+	return a.gzip.Close()
+}
+
+type zipArchiveWriter struct {
+	writer *zip.Writer
+}
+
+func (a *zipArchiveWriter) add(fullPath, name string, info FileInfo) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime}
+	header.SetMode(info.Mode)
+	if info.IsDir {
+		header.Name += "/"
+		header.Method = zip.Store
+		_, err := a.writer.CreateHeader(header)
+		return err
+	}
+
+	w, err := a.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	return copyFile(w, fullPath, info.Size)
+}
+
+func (a *zipArchiveWriter) Close() error {
+	return a.writer.Close()
+}
+
+// copyFile copies size bytes of the file, as announced in the header of the entry, even if it changed since.
+func copyFile(w io.Writer, fullPath string, size int64) error {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := io.Copy(w, io.LimitReader(file, size))
+	if err != nil {
+		return err
+	}
+	if n < size {
+		return fmt.Errorf("%s was truncated while archiving", fullPath)
+	}
+	return nil
+}
+
+type archiveExtractor struct {
+	sc           *StorageClient
+	root         string
+	relativePath string
+	opts         ExtractOptions
+	entries      int
+	bytes        int64
+}
+
+func (e *archiveExtractor) extractTarGz(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.extractDir(header.Name, header.FileInfo().Mode())
+		case tar.TypeReg:
+			err = e.extractFile(header.Name, header.FileInfo().Mode(), archive)
+		default:
+			e.sc.logger.Warnf("skipping the entry %s of type %c while extracting into %s",
+				header.Name, header.Typeflag, e.relativePath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// zipHeadersAllowance is the room for the headers of a zip archive on top of the size of its files
+const zipHeadersAllowance = 1 << 20
+
+func (e *archiveExtractor) extractZip(r io.Reader) error {
+	// zip archives are read from their end, so the upload is spooled to a temporary file, within the size limit
+	// plus some room for the headers, as tiny files may not shrink when compressed
+	spool, err := os.CreateTemp("", "extract-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	limit := e.opts.MaxBytes + zipHeadersAllowance
+	if e.opts.MaxBytes > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	size, err := io.Copy(spool, r)
+	if err != nil {
+		return err
+	}
+	if e.opts.MaxBytes > 0 && size > limit {
+		return fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, e.opts.MaxBytes)
+	}
+	archive, err := zip.NewReader(spool, size)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range archive.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			err = e.extractDir(entry.Name, mode)
+		case mode.IsRegular():
+			err = e.extractZipFile(entry)
+		default:
+			e.sc.logger.Warnf("skipping the entry %s of mode %s while extracting into %s", entry.Name, mode, e.relativePath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *archiveExtractor) extractZipFile(entry *zip.File) error {
+	content, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return e.extractFile(entry.Name, entry.Mode(), content)
+}
+
+func (e *archiveExtractor) extractDir(name string, mode fs.FileMode) error {
+	fullPath, err := e.target(name)
+	if err != nil || fullPath == e.root {
+		return err
+	}
+	// the owner must be able to write the entries of the directory
+	if err := os.MkdirAll(fullPath, mode.Perm()|0o700); err != nil {
+		return err
+	}
+	return os.Chmod(fullPath, mode.Perm()|0o700)
+}
+
+func (e *archiveExtractor) extractFile(name string, mode fs.FileMode, content io.Reader) error {
+	fullPath, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	rel, _ := filepath.Rel(e.sc.absoluteBasePath(), fullPath)
+
+	w, err := e.sc.OpenWriter(rel)
+	if err != nil {
+		return err
+	}
+
+	// the sizes in the headers can't be trusted, so the limit is enforced on the content
+	limit := int64(-1)
+	if e.opts.MaxBytes > 0 {
+		limit = e.opts.MaxBytes - e.bytes
+	}
+	reader := content
+	if limit >= 0 {
+		reader = io.LimitReader(content, limit+1)
+	}
+	n, err := io.Copy(w, reader)
+	e.bytes += n
+	if err == nil && limit >= 0 && n > limit {
+		err = fmt.Errorf("%w: more than %d bytes", ErrArchiveLimitExceeded, e.opts.MaxBytes)
+	}
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if mode.Perm() == 0 {
+		mode = 0o644
+	}
+	return os.Chmod(fullPath, mode.Perm())
+}
+
+// target returns the path of the entry, counting it, or ErrOutsideBasePath if it escapes the directory.
+func (e *archiveExtractor) target(name string) (string, error) {
+	e.entries++
+	if e.opts.MaxEntries > 0 && e.entries > e.opts.MaxEntries {
+		return "", fmt.Errorf("%w: more than %d entries", ErrArchiveLimitExceeded, e.opts.MaxEntries)
+	}
+
+	// entries are slash separated, backslashes are only found in malicious or broken archives
+	if path.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("%w: invalid entry %s", ErrOutsideBasePath, name)
+	}
+	fullPath := filepath.Join(e.root, filepath.FromSlash(name))
+	if !isWithin(e.root, fullPath) {
+		return "", fmt.Errorf("%w: invalid entry %s", ErrOutsideBasePath, name)
+	}
+	if err := checkWithinBasePath(e.root, fullPath); err != nil {
+		return "", fmt.Errorf("%w: invalid entry %s", err, name)
+	}
+	return fullPath, nil
+}
+
+func (sc *StorageClient) absoluteBasePath() string {
+	base, _ := filepath.Abs(sc.configuration.BasePath)
+	return base
+}
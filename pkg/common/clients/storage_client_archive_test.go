@@ -0,0 +1,97 @@
+package clients
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestStorageClientArchive(t *testing.T) {
+	basePath := t.TempDir()
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{BasePath: basePath})
+
+	require.NoError(t, sc.WriteFile("project/README.md", []byte("# project")))
+	require.NoError(t, sc.WriteFile("project/bin/run.sh", []byte("#!/bin/sh")))
+	require.NoError(t, os.Chmod(filepath.Join(basePath, "project/bin/run.sh"), 0o755))
+	require.NoError(t, sc.CreateDirectory("project/empty"))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(basePath, "project/passwd")))
+
+	for _, format := range []ArchiveFormat{ArchiveTarGz, ArchiveZip} {
+		t.Run(string(format), func(t *testing.T) {
+			var archive bytes.Buffer
+			require.NoError(t, sc.Archive("project", format, &archive))
+
+			target := "extracted/" + string(format)
+			require.NoError(t, sc.Extract(target, format, &archive, ExtractOptions{}))
+
+			content, err := sc.ReadFile(target + "/bin/run.sh")
+			require.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh", string(content))
+			info, err := os.Stat(filepath.Join(basePath, target, "bin/run.sh"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+			result, err := sc.List(target, ListOptions{Recursive: true})
+			require.NoError(t, err)
+			assert.Equal(t, []string{target + "/README.md", target + "/bin", target + "/bin/run.sh", target + "/empty"},
+				pathsOf(result.Entries))
+		})
+	}
+}
+
+func TestStorageClientExtractProtections(t *testing.T) {
+	basePath := t.TempDir()
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{BasePath: basePath})
+
+	zipOf := func(names ...string) *bytes.Buffer {
+		var buffer bytes.Buffer
+		w := zip.NewWriter(&buffer)
+		for _, name := range names {
+			f, err := w.Create(name)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("0123456789"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return &buffer
+	}
+
+	for _, name := range []string{"../evil", "a/../../evil", "/etc/evil", `..\evil`} {
+		err := sc.Extract("uploads", ArchiveZip, zipOf(name), ExtractOptions{})
+		assert.ErrorIs(t, err, ErrOutsideBasePath, name)
+	}
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(basePath), "evil"))
+
+	err := sc.Extract("uploads", ArchiveZip, zipOf("a", "b", "c"), ExtractOptions{MaxEntries: 2})
+	assert.ErrorIs(t, err, ErrArchiveLimitExceeded)
+	err = sc.Extract("uploads", ArchiveZip, zipOf("a", "b", "c"), ExtractOptions{MaxBytes: 25})
+	assert.ErrorIs(t, err, ErrArchiveLimitExceeded)
+	require.NoError(t, sc.Extract("uploads", ArchiveZip, zipOf("a", "b", "c"), ExtractOptions{MaxBytes: 30}))
+
+	// symlinks are skipped, so they can't be used to write outside of the directory
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/tmp"}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0o600, Size: 4}))
+	_, err = tw.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	require.NoError(t, sc.Extract("tar", ArchiveTarGz, &buffer, ExtractOptions{}))
+	assert.NoFileExists(t, filepath.Join(basePath, "tar/link"))
+	assert.FileExists(t, filepath.Join(basePath, "tar/file"))
+
+	format, err := ArchiveFormatFromName("dataset.TGZ")
+	require.NoError(t, err)
+	assert.Equal(t, ArchiveTarGz, format)
+}
@@ -1,15 +1,48 @@
 package clients
 
 import (
+	"errors"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
 // StorageClientConfiguration is a struct that holds all the environment variables required to the Storage Client
 type StorageClientConfiguration struct {
 	BasePath string `envconfig:"STORAGE_PATH" required:"true"`
+	// Quotas limit the bytes used under prefixes of the base path, e.g. workspaces/alice:1073741824,shared:10485760
+	Quotas map[string]int64 `envconfig:"STORAGE_QUOTAS"`
 }
 
 // LoadFromEnvVars parses the required configuration variables. Throws an error if the validations aren't met
 func (c *StorageClientConfiguration) LoadFromEnvVars() error {
 	return envconfig.Process("", c)
 }
+
+// BlobStorageConfiguration is a struct that holds all the environment variables required to the Blob Storage
+type BlobStorageConfiguration struct {
+	BasePath string `envconfig:"STORAGE_PATH"`
+
+	// URL selects the backend of NewBlobStorage by its scheme: file:///data, mem:// or s3://bucket/prefix.
+	// When empty, the local filesystem under BasePath is used.
+	URL string `envconfig:"STORAGE_URL"`
+
+	// S3Endpoint is the URL of the S3-compatible server, defaults to AWS S3 in S3Region.
+	S3Endpoint        string `envconfig:"STORAGE_S3_ENDPOINT"`
+	S3Region          string `envconfig:"STORAGE_S3_REGION" default:"us-east-1"`
+	S3AccessKeyID     string `envconfig:"STORAGE_S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `envconfig:"STORAGE_S3_SECRET_ACCESS_KEY"`
+	S3SessionToken    string `envconfig:"STORAGE_S3_SESSION_TOKEN"`
+	// S3PathStyle addresses the bucket in the path instead of the host, as required by MinIO.
+	S3PathStyle bool `envconfig:"STORAGE_S3_PATH_STYLE" default:"false"`
+}
+
+// LoadFromEnvVars parses the required configuration variables. Throws an error if the validations aren't met
+func (c *BlobStorageConfiguration) LoadFromEnvVars() error {
+	if err := envconfig.Process("", c); err != nil {
+		return err
+	}
+	if c.BasePath == "" && c.URL == "" {
+		return errors.New("required key STORAGE_PATH or STORAGE_URL missing value")
+	}
+	return nil
+}
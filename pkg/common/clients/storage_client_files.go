@@ -0,0 +1,336 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumSuffix is the suffix of the files holding the SHA-256 checksum of the files written by the StorageClient,
+// in the format of sha256sum.
+const ChecksumSuffix = ".sha256"
+
+const tempFileMarker = ".tmp-"
+
+// ErrChecksumMismatch is returned when the content of a file doesn't match its stored checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// WriteFile atomically replaces the content of the file with data, see OpenWriter.
+func (sc *StorageClient) WriteFile(relativePath string, data []byte) error {
+	w, err := sc.OpenWriter(relativePath)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// OpenWriter returns a writer that streams to a temporary file next to the file, creating the parent directories.
+// Closing the writer syncs the temporary file, stores its SHA-256 checksum alongside it and renames it into place,
+// so readers never observe a partially written file. Abort discards the content.
+// Returns ErrQuotaExceeded if the quota of the path is already exceeded, and so does Close if the file would
+// exceed it, discarding the content.
+func (sc *StorageClient) OpenWriter(relativePath string) (*AtomicFileWriter, error) {
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return nil, err
+	}
+
+	if err := sc.CheckQuota(relativePath, 0); err != nil {
+		return nil, err
+	}
+
+	sc.logger.Infof("attempting to write file %s", relativePath)
+	w, err := newAtomicFileWriter(fullPath, true)
+	if err != nil {
+		return nil, err
+	}
+	w.beforeCommit = func() error { return sc.checkWriteQuota(relativePath, fullPath) }
+	return w, nil
+}
+
+// ReadFile returns the content of the file, verified against its checksum when there is one.
+func (sc *StorageClient) ReadFile(relativePath string) ([]byte, error) {
+	r, err := sc.OpenReader(relativePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// ReadFileRange returns length bytes of the file starting at offset, or up to its end when length is negative.
+func (sc *StorageClient) ReadFileRange(relativePath string, offset, length int64) ([]byte, error) {
+	r, err := sc.OpenRangeReader(relativePath, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// OpenReader returns a reader of the file. When the file has a checksum, the content is verified while streaming
+// and the last read returns ErrChecksumMismatch instead of io.EOF if it doesn't match.
+func (sc *StorageClient) OpenReader(relativePath string) (io.ReadCloser, error) {
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return nil, err
+	}
+
+	expected, err := readChecksum(fullPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if expected == "" {
+		return file, nil
+	}
+	return &checksumReader{file: file, hash: sha256.New(), expected: expected, path: relativePath}, nil
+}
+
+// OpenRangeReader returns a reader of length bytes of the file starting at offset, or up to its end when length is
+// negative. Ranges are not verified against the checksum.
+func (sc *StorageClient) OpenRangeReader(relativePath string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return file, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+// Checksum returns the hex encoded SHA-256 checksum stored alongside the file.
+func (sc *StorageClient) Checksum(relativePath string) (string, error) {
+	fullPath, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		return "", err
+	}
+	return readChecksum(fullPath)
+}
+
+// VerifyChecksum reads the whole file and returns ErrChecksumMismatch if it doesn't match its stored checksum.
+func (sc *StorageClient) VerifyChecksum(relativePath string) error {
+	r, err := sc.OpenReader(relativePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if _, ok := r.(*checksumReader); !ok {
+		return fmt.Errorf("no checksum stored for %s: %w", relativePath, fs.ErrNotExist)
+	}
+	_, err = io.Copy(io.Discard, r)
+	return err
+}
+
+func readChecksum(fullPath string) (string, error) {
+	content, err := os.ReadFile(fullPath + ChecksumSuffix)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("invalid checksum file %s", fullPath+ChecksumSuffix)
+	}
+	return fields[0], nil
+}
+
+// AtomicFileWriter writes to a temporary file that replaces the target file once closed.
+type AtomicFileWriter struct {
+	path         string
+	withChecksum bool
+	file         *os.File
+	hash         hash.Hash
+	err          error
+	done         bool
+	// beforeCommit may refuse the content once synced, before it replaces the file
+	beforeCommit func() error
+}
+
+func newAtomicFileWriter(fullPath string, withChecksum bool) (*AtomicFileWriter, error) {
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(fullPath)+tempFileMarker+"*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFileWriter{path: fullPath, withChecksum: withChecksum, file: file, hash: sha256.New()}, nil
+}
+
+// Write writes to the temporary file, hashing the content.
+func (w *AtomicFileWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, os.ErrClosed
+	}
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// Close syncs the temporary file and renames it into place, along with its checksum. If a write failed, the
+// content is discarded and the error returned.
+func (w *AtomicFileWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	if w.err != nil {
+		w.Abort()
+		return w.err
+	}
+	w.done = true
+
+	if err := w.commit(); err != nil {
+		w.file.Close()
+		os.Remove(w.file.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the content written, leaving the file as it was.
+func (w *AtomicFileWriter) Abort() {
+	if w.done {
+		return
+	}
+	w.done = true
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the content written so far.
+func (w *AtomicFileWriter) Checksum() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+func (w *AtomicFileWriter) commit() error {
+	if err := w.sync(); err != nil {
+		return err
+	}
+	if w.beforeCommit != nil {
+		if err := w.beforeCommit(); err != nil {
+			return err
+		}
+	}
+	if !w.withChecksum {
+		if err := os.Rename(w.file.Name(), w.path); err != nil {
+			return err
+		}
+		syncDir(filepath.Dir(w.path))
+		return nil
+	}
+
+	// the checksum is synced before the file is replaced and the stale one is removed, so a crash or a failure
+	// leaves the file without checksum at worst, never with the checksum of another content
+	checksum, err := newAtomicFileWriter(w.path+ChecksumSuffix, false)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s  %s\n", w.Checksum(), filepath.Base(w.path))
+	if _, err := checksum.Write([]byte(line)); err != nil {
+		checksum.Abort()
+		return err
+	}
+	if err := checksum.sync(); err != nil {
+		checksum.Abort()
+		return err
+	}
+	if err := os.Remove(checksum.path); err != nil && !os.IsNotExist(err) {
+		checksum.Abort()
+		return err
+	}
+	if err := os.Rename(w.file.Name(), w.path); err != nil {
+		checksum.Abort()
+		return err
+	}
+	if err := os.Rename(checksum.file.Name(), checksum.path); err != nil {
+		checksum.Abort()
+		return err
+	}
+	checksum.done = true
+	syncDir(filepath.Dir(w.path))
+	return nil
+}
+
+// sync persists the content of the temporary file and closes it, so it can be renamed into place.
+func (w *AtomicFileWriter) sync() error {
+	if err := w.file.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+// syncDir persists the renames in the directory. Best effort, as not all platforms support syncing directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// isTempFile returns whether the name is one of a temporary file of an AtomicFileWriter.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempFileMarker)
+}
+
+type checksumReader struct {
+	file     *os.File
+	hash     hash.Hash
+	expected string
+	path     string
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hash.Sum(nil)) != r.expected {
+		return n, fmt.Errorf("%w: %s", ErrChecksumMismatch, r.path)
+	}
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	return r.file.Close()
+}
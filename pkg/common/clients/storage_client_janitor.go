@@ -0,0 +1,324 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LockFileName is the name of the advisory lock file that keeps a directory from being removed by the
+// StorageJanitor, e.g. while a job is still using its workspace.
+const LockFileName = ".lock"
+
+// Reasons for the removal of a path by the StorageJanitor.
+const (
+	RetentionMaxAge   = "max_age"
+	RetentionMaxCount = "max_count"
+	RetentionMaxBytes = "max_bytes"
+)
+
+var (
+	storageJanitorRemoved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_janitor_removed_total",
+			Help: "Number of paths removed by the storage janitor.",
+		},
+		[]string{"prefix", "reason"},
+	)
+	storageJanitorReclaimedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_janitor_reclaimed_bytes_total",
+			Help: "Bytes reclaimed by the storage janitor.",
+		},
+		[]string{"prefix"},
+	)
+	storageJanitorReclaimableBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_janitor_reclaimable_bytes",
+			Help: "Bytes the storage janitor would reclaim, as reported by the last dry run.",
+		},
+		[]string{"prefix"},
+	)
+	storageJanitorLocked = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_janitor_locked_paths",
+			Help: "Number of paths skipped by the last sweep of the storage janitor as they hold a lock file.",
+		},
+		[]string{"prefix"},
+	)
+	registerStorageJanitorMetrics sync.Once
+)
+
+// StorageJanitorConfiguration represents the configuration of a StorageJanitor.
+type StorageJanitorConfiguration struct {
+	// Interval between sweeps.
+	Interval time.Duration `envconfig:"STORAGE_JANITOR_INTERVAL" default:"1h"`
+	// DryRun only reports the paths that would be removed.
+	DryRun bool `envconfig:"STORAGE_JANITOR_DRY_RUN" default:"false"`
+}
+
+// LoadFromEnvVars for StorageJanitorConfiguration.
+func (c *StorageJanitorConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
+
+// RetentionRule limits the entries directly under a prefix, e.g. the job workspaces under jobs. The oldest entries,
+// by the latest modification of their files, are removed first. Locked entries are never removed, but they count
+// towards MaxCount and MaxBytes. A zero limit is not applied.
+type RetentionRule struct {
+	Prefix   string
+	MaxAge   time.Duration
+	MaxCount int
+	MaxBytes int64
+}
+
+// RemovedPath is a path removed, or to be removed in a dry run, by the StorageJanitor.
+type RemovedPath struct {
+	Path    string
+	Bytes   int64
+	ModTime time.Time
+	Reason  string
+}
+
+// RetentionReport describes a sweep of the StorageJanitor.
+type RetentionReport struct {
+	DryRun  bool
+	Removed []RemovedPath
+	// Locked are the paths that would have been removed if they didn't hold a lock file.
+	Locked         []string
+	ReclaimedBytes int64
+}
+
+// StorageJanitor periodically applies retention rules to the directories of a StorageClient.
+type StorageJanitor struct {
+	sc     *StorageClient
+	config StorageJanitorConfiguration
+	rules  []RetentionRule
+}
+
+// NewStorageJanitor creates a StorageJanitor applying the rules, and registers its metrics in the default
+// Prometheus registry.
+func NewStorageJanitor(sc *StorageClient, config StorageJanitorConfiguration, rules ...RetentionRule) (*StorageJanitor, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid storage janitor interval %v", config.Interval)
+	}
+	for _, rule := range rules {
+		if _, err := resolvePath(sc.configuration.BasePath, rule.Prefix); err != nil {
+			return nil, fmt.Errorf("invalid retention rule for %s: %w", rule.Prefix, err)
+		}
+		if rule.MaxAge < 0 || rule.MaxCount < 0 || rule.MaxBytes < 0 {
+			return nil, fmt.Errorf("invalid retention rule for %s: negative limit", rule.Prefix)
+		}
+	}
+
+	registerStorageJanitorMetrics.Do(func() {
+		prometheus.MustRegister(storageJanitorRemoved, storageJanitorReclaimedBytes,
+			storageJanitorReclaimableBytes, storageJanitorLocked)
+	})
+
+	return &StorageJanitor{sc: sc, config: config, rules: rules}, nil
+}
+
+// Run sweeps every interval until the context is done.
+func (j *StorageJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.Sweep(ctx); err != nil {
+			j.sc.logger.Errorf("while sweeping the storage. Error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep applies the retention rules, removing the paths exceeding them unless configured to dry run.
+func (j *StorageJanitor) Sweep(ctx context.Context) (RetentionReport, error) {
+	return j.sweep(ctx, j.config.DryRun)
+}
+
+// Plan returns the paths the next sweep would remove, without removing them.
+func (j *StorageJanitor) Plan(ctx context.Context) (RetentionReport, error) {
+	return j.sweep(ctx, true)
+}
+
+func (j *StorageJanitor) sweep(ctx context.Context, dryRun bool) (RetentionReport, error) {
+	report := RetentionReport{DryRun: dryRun}
+	var errs []error
+	for _, rule := range j.rules {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if err := j.apply(rule, dryRun, &report); err != nil {
+			j.sc.logger.Errorf("while applying the retention of %s. Error: %v", rule.Prefix, err)
+			errs = append(errs, err)
+		}
+	}
+
+	if dryRun {
+		j.sc.logger.Infof("[DRY RUN] storage sweep would remove %d paths, reclaiming %d bytes",
+			len(report.Removed), report.ReclaimedBytes)
+	} else {
+		j.sc.logger.Infof("storage sweep removed %d paths, reclaiming %d bytes", len(report.Removed), report.ReclaimedBytes)
+	}
+	return report, errors.Join(errs...)
+}
+
+// retentionCandidate is an entry directly under the prefix of a rule.
+type retentionCandidate struct {
+	FileInfo
+	bytes  int64
+	locked bool
+}
+
+func (j *StorageJanitor) apply(rule RetentionRule, dryRun bool, report *RetentionReport) error {
+	candidates, err := j.candidates(rule.Prefix)
+	if err != nil {
+		return err
+	}
+	// newest first, so the ones over the limits are at the end
+	sort.Slice(candidates, func(i, k int) bool { return candidates[i].ModTime.After(candidates[k].ModTime) })
+
+	var count int
+	var total, reclaimable int64
+	var locked int
+	now := time.Now()
+	for _, candidate := range candidates {
+		count++
+		total += candidate.bytes
+
+		var reason string
+		switch {
+		case rule.MaxAge > 0 && now.Sub(candidate.ModTime) > rule.MaxAge:
+			reason = RetentionMaxAge
+		case rule.MaxCount > 0 && count > rule.MaxCount:
+			reason = RetentionMaxCount
+		case rule.MaxBytes > 0 && total > rule.MaxBytes:
+			reason = RetentionMaxBytes
+		default:
+			continue
+		}
+
+		// the lock may have been taken after listing the candidates
+		if candidate.locked || j.locked(candidate) {
+			j.sc.logger.Infof("skipping the locked path %s exceeding the %s retention of %s", candidate.Path, reason, rule.Prefix)
+			report.Locked = append(report.Locked, candidate.Path)
+			locked++
+			continue
+		}
+
+		removed := RemovedPath{Path: candidate.Path, Bytes: candidate.bytes, ModTime: candidate.ModTime, Reason: reason}
+		if dryRun {
+			j.sc.logger.Infof("[DRY RUN] would remove %s (%d bytes) exceeding the %s retention of %s",
+				candidate.Path, candidate.bytes, reason, rule.Prefix)
+		} else {
+			if err := j.remove(candidate); err != nil {
+				return err
+			}
+			j.sc.logger.Infof("removed %s (%d bytes) exceeding the %s retention of %s",
+				candidate.Path, candidate.bytes, reason, rule.Prefix)
+			storageJanitorRemoved.WithLabelValues(rule.Prefix, reason).Inc()
+			storageJanitorReclaimedBytes.WithLabelValues(rule.Prefix).Add(float64(candidate.bytes))
+		}
+
+		// removed entries no longer count towards the limits of the ones kept
+		count--
+		total -= candidate.bytes
+		reclaimable += candidate.bytes
+		report.Removed = append(report.Removed, removed)
+		report.ReclaimedBytes += candidate.bytes
+	}
+
+	storageJanitorLocked.WithLabelValues(rule.Prefix).Set(float64(locked))
+	if dryRun {
+		storageJanitorReclaimableBytes.WithLabelValues(rule.Prefix).Set(float64(reclaimable))
+	}
+	return nil
+}
+
+// candidates returns the entries under the prefix, with the size and latest modification of their files.
+func (j *StorageJanitor) candidates(prefix string) ([]retentionCandidate, error) {
+	result, err := j.sc.List(prefix, ListOptions{})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	candidates := make([]retentionCandidate, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		if entry.Name == LockFileName {
+			continue
+		}
+		candidate := retentionCandidate{FileInfo: entry}
+		if !entry.IsDir {
+			candidate.bytes = entry.Size
+			candidates = append(candidates, candidate)
+			continue
+		}
+
+		err := j.sc.Walk(entry.Path, func(info FileInfo) error {
+			if info.ModTime.After(candidate.ModTime) {
+				candidate.ModTime = info.ModTime
+			}
+			if path.Dir(info.Path) == entry.Path && info.Name == LockFileName {
+				candidate.locked = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage, err := j.sc.DiskUsage(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		candidate.bytes = usage.Bytes
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+func (j *StorageJanitor) locked(candidate retentionCandidate) bool {
+	if !candidate.IsDir {
+		return false
+	}
+	fullPath, err := resolvePath(j.sc.configuration.BasePath, candidate.Path)
+	if err != nil {
+		return false
+	}
+	_, err = os.Lstat(filepath.Join(fullPath, LockFileName))
+	return err == nil
+}
+
+// remove deletes the entry along with its checksum.
+func (j *StorageJanitor) remove(candidate retentionCandidate) error {
+	fullPath, err := resolvePath(j.sc.configuration.BasePath, candidate.Path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(fullPath); err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath + ChecksumSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
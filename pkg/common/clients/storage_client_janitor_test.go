@@ -0,0 +1,81 @@
+package clients
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestStorageJanitor(t *testing.T) {
+	basePath := t.TempDir()
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{BasePath: basePath})
+
+	// jobs/job-N was last modified N hours ago
+	for i, name := range []string{"job-0", "job-1", "job-2", "job-3", "job-4"} {
+		require.NoError(t, sc.WriteFile("jobs/"+name+"/output.log", make([]byte, 100)))
+		age(t, filepath.Join(basePath, "jobs", name), time.Duration(i)*time.Hour)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "jobs/job-4", LockFileName), nil, 0o644))
+	age(t, filepath.Join(basePath, "jobs/job-4"), 4*time.Hour)
+	require.NoError(t, sc.WriteFile("scratch/big.bin", make([]byte, 1000)))
+
+	janitor, err := NewStorageJanitor(&sc, StorageJanitorConfiguration{Interval: time.Hour, DryRun: true},
+		RetentionRule{Prefix: "jobs", MaxAge: 150 * time.Minute, MaxCount: 2},
+		RetentionRule{Prefix: "scratch", MaxBytes: 500},
+	)
+	require.NoError(t, err)
+
+	report, err := janitor.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"jobs/job-2", "jobs/job-3", "scratch/big.bin"}, removedPaths(report))
+	assert.Equal(t, []string{RetentionMaxCount, RetentionMaxAge, RetentionMaxBytes},
+		[]string{report.Removed[0].Reason, report.Removed[1].Reason, report.Removed[2].Reason})
+	assert.Equal(t, []string{"jobs/job-4"}, report.Locked)
+	assert.DirExists(t, filepath.Join(basePath, "jobs/job-3"))
+
+	janitor.config.DryRun = false
+	report, err = janitor.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jobs/job-2", "jobs/job-3", "scratch/big.bin"}, removedPaths(report))
+	assert.Equal(t, report.Removed[0].Bytes+report.Removed[1].Bytes+report.Removed[2].Bytes, report.ReclaimedBytes)
+	assert.NoDirExists(t, filepath.Join(basePath, "jobs/job-2"))
+	assert.NoDirExists(t, filepath.Join(basePath, "jobs/job-3"))
+	assert.NoFileExists(t, filepath.Join(basePath, "scratch/big.bin"+ChecksumSuffix))
+	assert.DirExists(t, filepath.Join(basePath, "jobs/job-4"))
+
+	report, err = janitor.Plan(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Removed)
+
+	_, err = NewStorageJanitor(&sc, StorageJanitorConfiguration{Interval: time.Hour}, RetentionRule{Prefix: "../jobs"})
+	assert.ErrorIs(t, err, ErrOutsideBasePath)
+	_, err = NewStorageJanitor(&sc, StorageJanitorConfiguration{})
+	assert.Error(t, err)
+}
+
+// age sets the modification time of the files under the path to the given time ago.
+func age(t *testing.T, root string, ago time.Duration) {
+	modTime := time.Now().Add(-ago)
+	require.NoError(t, filepath.WalkDir(root, func(fullPath string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(fullPath, modTime, modTime)
+	}))
+}
+
+func removedPaths(report RetentionReport) []string {
+	paths := make([]string, 0, len(report.Removed))
+	for _, removed := range report.Removed {
+		paths = append(paths, removed.Path)
+	}
+	return paths
+}
@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideBasePath is returned when a path resolves outside of the base path of the storage,
+// either with .. elements or through a symlink.
+var ErrOutsideBasePath = errors.New("path outside of the base path")
+
+// resolvePath joins the relative path to the base path, returning ErrOutsideBasePath if the result,
+// once its symlinks are resolved, is not within the base path.
+func resolvePath(basePath, relativePath string) (string, error) {
+	base, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", err
+	}
+
+	fullPath := filepath.Join(base, relativePath)
+	if err := checkWithinBasePath(base, fullPath); err != nil {
+		return "", fmt.Errorf("%w: %s", err, relativePath)
+	}
+	return fullPath, nil
+}
+
+// resolveAbsolutePath checks that the absolute path is within the base path.
+func resolveAbsolutePath(basePath, absolutePath string) (string, error) {
+	base, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", err
+	}
+
+	fullPath := filepath.Clean(absolutePath)
+	if err := checkWithinBasePath(base, fullPath); err != nil {
+		return "", fmt.Errorf("%w: %s", err, absolutePath)
+	}
+	return fullPath, nil
+}
+
+func checkWithinBasePath(base, fullPath string) error {
+	if !isWithin(base, fullPath) {
+		return ErrOutsideBasePath
+	}
+
+	realBase, err := resolveSymlinks(base)
+	if err != nil {
+		return err
+	}
+	realPath, err := resolveSymlinks(fullPath)
+	if err != nil {
+		return err
+	}
+	if !isWithin(realBase, realPath) {
+		return ErrOutsideBasePath
+	}
+	return nil
+}
+
+func isWithin(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSymlinks resolves the symlinks of the path, including dangling ones, keeping the elements that don't exist.
+func resolveSymlinks(path string) (string, error) {
+	existing, rest := path, ""
+	for {
+		realPath, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(realPath, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		// a dangling symlink would create its target once written
+		if target, err := os.Readlink(existing); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(existing), target)
+			}
+			return resolveSymlinks(filepath.Join(target, rest))
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
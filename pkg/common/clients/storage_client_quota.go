@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed the quota of a prefix.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+type storageQuotas struct {
+	mu     sync.RWMutex
+	limits map[string]int64
+}
+
+func newStorageQuotas(limits map[string]int64) *storageQuotas {
+	quotas := &storageQuotas{limits: map[string]int64{}}
+	for prefix, limit := range limits {
+		quotas.set(prefix, limit)
+	}
+	return quotas
+}
+
+func (q *storageQuotas) set(prefix string, limit int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	prefix = cleanQuotaPrefix(prefix)
+	if limit <= 0 {
+		delete(q.limits, prefix)
+		return
+	}
+	q.limits[prefix] = limit
+}
+
+// matching returns the prefixes containing the path, with their limits.
+func (q *storageQuotas) matching(relativePath string) map[string]int64 {
+	if q == nil {
+		return nil
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	relativePath = cleanQuotaPrefix(relativePath)
+
+	matches := map[string]int64{}
+	for prefix, limit := range q.limits {
+		if prefix == "" || relativePath == prefix || strings.HasPrefix(relativePath, prefix+"/") {
+			matches[prefix] = limit
+		}
+	}
+	return matches
+}
+
+func cleanQuotaPrefix(prefix string) string {
+	return strings.TrimPrefix(path.Clean("/"+prefix), "/")
+}
+
+// SetQuota limits the bytes used by the files under the prefix, relative to the base path, replacing the limit
+// from the configuration. A limit of 0 removes the quota.
+func (sc *StorageClient) SetQuota(prefix string, maxBytes int64) {
+	if sc.quotas == nil {
+		sc.quotas = newStorageQuotas(nil)
+	}
+	sc.quotas.set(prefix, maxBytes)
+}
+
+// CheckQuota returns ErrQuotaExceeded if adding the bytes to the path exceeds the quota of any prefix containing it.
+// Checking 0 bytes tells whether the quota is already exceeded.
+func (sc *StorageClient) CheckQuota(relativePath string, additionalBytes int64) error {
+	quotas := sc.quotas.matching(relativePath)
+	prefixes := make([]string, 0, len(quotas))
+	for prefix := range quotas {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		usage, err := sc.DiskUsage(prefix)
+		if err != nil {
+			return err
+		}
+		if usage.Bytes+additionalBytes > quotas[prefix] {
+			sc.logger.Warnf("quota of %s exceeded writing to %s: %d of %d bytes used",
+				prefix, relativePath, usage.Bytes, quotas[prefix])
+			return fmt.Errorf("%w: %s uses %d of %d bytes", ErrQuotaExceeded, prefix, usage.Bytes, quotas[prefix])
+		}
+	}
+	return nil
+}
+
+// checkWriteQuota checks the quota of a file about to be replaced by the temporary file of the writer, that is
+// already accounted in the usage.
+func (sc *StorageClient) checkWriteQuota(relativePath, fullPath string) error {
+	var replacedBytes int64
+	if info, err := os.Stat(fullPath); err == nil {
+		replacedBytes = info.Size()
+	}
+	return sc.CheckQuota(relativePath, -replacedBytes)
+}
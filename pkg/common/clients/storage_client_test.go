@@ -0,0 +1,182 @@
+package clients
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestStorageClientSandbox(t *testing.T) {
+	root := t.TempDir()
+	basePath := filepath.Join(root, "storage")
+	outside := filepath.Join(root, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(outside, "data"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(basePath, os.ModePerm))
+	require.NoError(t, os.Symlink(outside, filepath.Join(basePath, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing"), filepath.Join(basePath, "dangling")))
+
+	logger := logging.NewLogger(logging.LoggerConfiguration{})
+	sc := NewStorageClient(logger, StorageClientConfiguration{BasePath: basePath})
+
+	require.NoError(t, sc.CreateDirectory("project/requirements"))
+	assert.True(t, sc.CheckIfExists("project/requirements"))
+	assert.True(t, sc.CheckIfExists("project/../project"))
+
+	for _, path := range []string{"../outside", "../../etc", "link/data", "dangling/new"} {
+		assert.ErrorIs(t, sc.CreateDirectory(path), ErrOutsideBasePath, path)
+		assert.ErrorIs(t, sc.RemoveDirectory(path), ErrOutsideBasePath, path)
+		assert.False(t, sc.CheckIfExists(path), path)
+	}
+	assert.DirExists(t, filepath.Join(outside, "data"))
+	assert.NoDirExists(t, filepath.Join(outside, "missing"))
+	assert.Error(t, sc.RemoveDirectory("."))
+
+	require.NoError(t, sc.Rename("project/requirements", "project/renamed"))
+	require.NoError(t, sc.Rename("project/renamed", filepath.Join(basePath, "project/absolute")))
+	assert.DirExists(t, filepath.Join(basePath, "project/absolute"))
+	assert.ErrorIs(t, sc.Rename("project/absolute", filepath.Join(outside, "moved")), ErrOutsideBasePath)
+	assert.ErrorIs(t, sc.Rename("../outside/data", "stolen"), ErrOutsideBasePath)
+
+	assert.Error(t, sc.MoveOutsideBasePath("project/absolute", filepath.Join(outside, "moved"), ""))
+	require.NoError(t, sc.MoveOutsideBasePath("project/absolute", filepath.Join(outside, "moved"), "handover"))
+	assert.DirExists(t, filepath.Join(outside, "moved"))
+
+	blobs := NewLocalBlobStorage(logger, basePath)
+	_, err := blobs.Create(context.Background(), "link/data/file")
+	assert.ErrorIs(t, err, ErrOutsideBasePath)
+}
+
+func TestStorageClientFiles(t *testing.T) {
+	basePath := t.TempDir()
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{BasePath: basePath})
+
+	require.NoError(t, sc.WriteFile("artifacts/model.bin", []byte("0123456789")))
+	content, err := sc.ReadFile("artifacts/model.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(content))
+
+	content, err = sc.ReadFileRange("artifacts/model.bin", 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "234", string(content))
+	content, err = sc.ReadFileRange("artifacts/model.bin", 7, -1)
+	require.NoError(t, err)
+	assert.Equal(t, "789", string(content))
+
+	checksum, err := sc.Checksum("artifacts/model.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "84d89877f0d4041efb6bf91a16f0248f2fd573e6af05c19f96bedb9f882f7882", checksum)
+	require.NoError(t, sc.VerifyChecksum("artifacts/model.bin"))
+
+	// the file is only replaced when the writer is closed
+	w, err := sc.OpenWriter("artifacts/model.bin")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("partial"))
+	require.NoError(t, err)
+	content, err = sc.ReadFile("artifacts/model.bin")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(content))
+	w.Abort()
+	entries, err := os.ReadDir(filepath.Join(basePath, "artifacts"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "artifacts/model.bin"), []byte("corrupted"), 0o644))
+	_, err = sc.ReadFile("artifacts/model.bin")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.ErrorIs(t, sc.VerifyChecksum("artifacts/model.bin"), ErrChecksumMismatch)
+
+	require.NoError(t, sc.WriteFile("artifacts/model.bin", []byte("fixed")))
+	require.NoError(t, sc.Rename("artifacts/model.bin", "artifacts/renamed.bin"))
+	require.NoError(t, sc.VerifyChecksum("artifacts/renamed.bin"))
+	_, err = sc.OpenWriter("../outside")
+	assert.ErrorIs(t, err, ErrOutsideBasePath)
+}
+
+func TestStorageClientChecksumWriteFailure(t *testing.T) {
+	basePath := t.TempDir()
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{BasePath: basePath})
+
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "model.bin"), []byte("previous"), 0o644))
+	// a checksum that can't be replaced
+	require.NoError(t, os.MkdirAll(filepath.Join(basePath, "model.bin"+ChecksumSuffix, "blocked"), os.ModePerm))
+
+	assert.Error(t, sc.WriteFile("model.bin", []byte("0123456789")))
+
+	// the file is left as it was, and so are the temporary files
+	content, err := os.ReadFile(filepath.Join(basePath, "model.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(content))
+	entries, err := os.ReadDir(basePath)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestStorageClientListingAndQuotas(t *testing.T) {
+	sc := NewStorageClient(logging.NewLogger(logging.LoggerConfiguration{}), StorageClientConfiguration{
+		BasePath: t.TempDir(),
+		Quotas:   map[string]int64{"workspaces/alice": 1000},
+	})
+
+	require.NoError(t, sc.WriteFile("workspaces/alice/a.csv", []byte("0123456789")))
+	require.NoError(t, sc.WriteFile("workspaces/alice/b.json", []byte("{}")))
+	require.NoError(t, sc.WriteFile("workspaces/alice/data/c.csv", []byte("c")))
+	require.NoError(t, sc.CreateDirectory("workspaces/alice/empty"))
+
+	result, err := sc.List("workspaces/alice", ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"workspaces/alice/a.csv", "workspaces/alice/b.json", "workspaces/alice/data",
+		"workspaces/alice/empty"}, pathsOf(result.Entries))
+
+	result, err = sc.List("workspaces/alice", ListOptions{Pattern: "*.csv", Recursive: true, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"workspaces/alice/a.csv"}, pathsOf(result.Entries))
+	result, err = sc.List("workspaces/alice", ListOptions{Pattern: "*.csv", Recursive: true, PageSize: 1,
+		PageToken: result.NextPageToken})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"workspaces/alice/data/c.csv"}, pathsOf(result.Entries))
+	assert.Empty(t, result.NextPageToken)
+
+	result, err = sc.List("workspaces", ListOptions{Pattern: "alice/data/*", Recursive: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"workspaces/alice/data/c.csv"}, pathsOf(result.Entries))
+
+	var visited []string
+	require.NoError(t, sc.Walk("workspaces", func(info FileInfo) error {
+		visited = append(visited, info.Path)
+		if info.Name == "data" {
+			return fs.SkipDir
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"workspaces/alice", "workspaces/alice/a.csv", "workspaces/alice/b.json",
+		"workspaces/alice/data", "workspaces/alice/empty"}, visited)
+
+	// the usage includes the checksums, 65 bytes plus the name of the file each
+	usage, err := sc.DiskUsage("workspaces/alice/data")
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Bytes: 1 + 65 + 6 + 1, Files: 2}, usage)
+
+	sc.SetQuota("workspaces/alice", 300)
+	assert.NoError(t, sc.CheckQuota("workspaces/alice/new", 10))
+	assert.ErrorIs(t, sc.CheckQuota("workspaces/alice/new", 100), ErrQuotaExceeded)
+	assert.NoError(t, sc.CheckQuota("workspaces/bob/new", 1000))
+	assert.ErrorIs(t, sc.WriteFile("workspaces/alice/big", make([]byte, 100)), ErrQuotaExceeded)
+	_, err = sc.Checksum("workspaces/alice/big")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	sc.SetQuota("workspaces/alice", 0)
+	require.NoError(t, sc.WriteFile("workspaces/alice/big", make([]byte, 100)))
+}
+
+func pathsOf(entries []FileInfo) []string {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+	}
+	return paths
+}
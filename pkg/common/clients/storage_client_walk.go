@@ -0,0 +1,200 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileInfo describes a file or directory of the StorageClient.
+type FileInfo struct {
+	// Path is relative to the base path, with slashes.
+	Path    string
+	Name    string
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+	IsDir   bool
+}
+
+// ListOptions filters and paginates the entries returned by List.
+type ListOptions struct {
+	// Pattern is a glob, as supported by path.Match, matched against the name of the entries, or against their path
+	// relative to the listed directory when it contains a slash.
+	Pattern string
+	// Recursive lists the entries of the subdirectories too.
+	Recursive bool
+	// PageSize limits the number of entries returned, 0 returns them all.
+	PageSize int
+	// PageToken is the NextPageToken of the previous page.
+	PageToken string
+}
+
+// ListResult is a page of entries returned by List.
+type ListResult struct {
+	Entries []FileInfo
+	// NextPageToken is empty on the last page.
+	NextPageToken string
+}
+
+// Usage is the space used by the files under a path.
+type Usage struct {
+	Bytes int64
+	Files int64
+}
+
+// List returns the entries of the directory sorted by path, hiding the checksums and temporary files written by
+// the StorageClient.
+func (sc *StorageClient) List(relativePath string, opts ListOptions) (ListResult, error) {
+	if _, err := path.Match(opts.Pattern, ""); err != nil {
+		return ListResult{}, fmt.Errorf("invalid pattern %s: %w", opts.Pattern, err)
+	}
+
+	root, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return ListResult{}, err
+	}
+
+	var entries []FileInfo
+	err = sc.Walk(relativePath, func(info FileInfo) error {
+		rel := sc.relativeTo(root, info.Path)
+		if opts.Pattern == "" || matchPattern(opts.Pattern, rel, info.Name) {
+			if opts.PageToken == "" || info.Path > opts.PageToken {
+				entries = append(entries, info)
+			}
+		}
+		if info.IsDir && !opts.Recursive {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	result := ListResult{Entries: entries}
+	if opts.PageSize > 0 && len(entries) > opts.PageSize {
+		result.Entries = entries[:opts.PageSize]
+		result.NextPageToken = result.Entries[opts.PageSize-1].Path
+	}
+	return result, nil
+}
+
+// Walk calls visit for each file and directory under the path, in lexical order, hiding the checksums and
+// temporary files written by the StorageClient. Symlinks are not followed. The visitor may return fs.SkipDir
+// to skip a directory, or fs.SkipAll to stop walking.
+func (sc *StorageClient) Walk(relativePath string, visit func(FileInfo) error) error {
+	root, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return err
+	}
+	base, err := filepath.Abs(sc.configuration.BasePath)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if fullPath == root || isInternalFile(fullPath) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(base, fullPath)
+		return visit(FileInfo{
+			Path:    filepath.ToSlash(rel),
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+			IsDir:   entry.IsDir(),
+		})
+	})
+	if err != nil {
+		sc.logger.Errorf("while walking path %s. Error: %v", relativePath, err)
+	}
+	return err
+}
+
+// DiskUsage returns the total size and number of the regular files under the path, including the checksums and
+// temporary files.
+func (sc *StorageClient) DiskUsage(relativePath string) (Usage, error) {
+	root, err := resolvePath(sc.configuration.BasePath, relativePath)
+	if err != nil {
+		sc.logger.Errorf("while resolving path %s. Error: %v", relativePath, err)
+		return Usage{}, err
+	}
+
+	var usage Usage
+	err = filepath.WalkDir(root, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// files may be removed while walking
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		usage.Bytes += info.Size()
+		usage.Files++
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Usage{}, err
+	}
+	return usage, nil
+}
+
+// relativeTo returns the path of the entry relative to the listed directory.
+func (sc *StorageClient) relativeTo(root, entryPath string) string {
+	base, _ := filepath.Abs(sc.configuration.BasePath)
+	rel, _ := filepath.Rel(root, filepath.Join(base, filepath.FromSlash(entryPath)))
+	return filepath.ToSlash(rel)
+}
+
+func matchPattern(pattern, rel, name string) bool {
+	subject := name
+	if strings.Contains(pattern, "/") {
+		subject = rel
+	}
+	matched, _ := path.Match(pattern, subject)
+	return matched
+}
+
+// isInternalFile returns whether the file is a temporary file or the checksum of a file written by the StorageClient.
+func isInternalFile(fullPath string) bool {
+	if isTempFile(filepath.Base(fullPath)) {
+		return true
+	}
+	if target, ok := strings.CutSuffix(fullPath, ChecksumSuffix); ok {
+		_, err := os.Lstat(target)
+		return err == nil
+	}
+	return false
+}
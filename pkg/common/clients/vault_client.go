@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/ydataai/go-core/pkg/common/config"
@@ -17,6 +18,8 @@ type VaultClient struct {
 	logger        logging.Logger
 	client        *api.Client
 	secret        *api.Secret
+	tokenManager  *TokenManager
+	stop          context.CancelFunc
 }
 
 // NewVaultClient returns an initialized struct with the required dependencies injected
@@ -45,20 +48,41 @@ func NewVaultClient(logger logging.Logger, configuration VaultClientConfiguratio
 		return nil, err
 	}
 
+	// keeps the token valid until the client is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	vc.stop = cancel
+	vc.tokenManager = NewTokenManager(vc, authenticator)
+	go vc.tokenManager.Run(ctx)
+
 	return vc, nil
 }
 
+// TokenManager returns the manager keeping the token of the client valid, to watch its events and health.
+func (vc *VaultClient) TokenManager() *TokenManager {
+	return vc.tokenManager
+}
+
+// Close stops renewing the token of the client.
+func (vc *VaultClient) Close() {
+	vc.stop()
+}
+
 // StoreCredentials receives the path and the respective map of credentials and attempts to store them
 // on the Vault server.
 func (vc *VaultClient) StoreCredentials(path string, credentials config.Credentials) error {
+	return vc.StoreCredentialsWithContext(context.Background(), path, credentials)
+}
+
+// StoreCredentialsWithContext is the same as StoreCredentials, but with a context.
+func (vc *VaultClient) StoreCredentialsWithContext(ctx context.Context, path string, credentials config.Credentials) error {
 	vc.logger.Info("Sending credentials to Vault ☄️")
 
-	_, err := vc.client.Logical().Write(path, map[string]interface{}{
+	_, err := vc.client.Logical().WriteWithContext(ctx, path, map[string]interface{}{
 		"data": credentials,
 	})
 	if err != nil {
 		vc.logger.Errorf("Unable to store credentials in Vault 😱. Err: %v ", err)
-		return err
+		return wrapVaultError(err)
 	}
 
 	vc.logger.Info("Credentials safely secured in Vault 🔑")
@@ -66,48 +90,68 @@ func (vc *VaultClient) StoreCredentials(path string, credentials config.Credenti
 }
 
 // GetCredentials receives the path and attemps to retrieve the map of credentials present
-// on the Vault server.
+// on the Vault server. Returns nil if there are no credentials in the path.
 func (vc *VaultClient) GetCredentials(path string) (*config.Credentials, error) {
+	credentials, err := vc.GetCredentialsWithContext(context.Background(), path)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, nil
+	}
+	return credentials, err
+}
+
+// GetCredentialsWithContext is the same as GetCredentials, but with a context.
+// Returns ErrSecretNotFound if there are no credentials in the path.
+func (vc *VaultClient) GetCredentialsWithContext(ctx context.Context, path string) (*config.Credentials, error) {
 	vc.logger.Info("Fetching credentials from Vault ☄️")
 
-	secret, err := vc.client.Logical().Read(path)
+	secret, err := vc.client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
 		vc.logger.Errorf("Unable to fetch credentials from Vault 😱. Err: %v", err)
-		return nil, err
+		return nil, wrapVaultError(err)
 	}
 	if secret == nil {
-		return nil, nil
+		return nil, ErrSecretNotFound
 	}
 
 	vc.logger.Info("Credentials safely retrieved from Vault 🔑")
 
-	secretsMap, ok := secret.Data["data"].(config.Credentials)
-	if !ok {
+	credentials, err := decodeCredentials(secret.Data["data"])
+	if err != nil {
 		vc.logger.Errorf("Unable to decipher received credentials from Vault 😱. Err: %v", err)
 		return nil, err
 	}
 
-	return &secretsMap, nil
-
-	// vc.logger.Info("Processing credentials map 🔎")
-	// credentials := config.Credentials{}
-
-	// for key, value := range secretsMap {
-	// 	credentials[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", value)
-	// }
+	return &credentials, nil
+}
 
-	// return &credentials, nil
+// decodeCredentials converts the data of a KV v2 secret, which Vault returns as a generic map, into credentials.
+func decodeCredentials(data interface{}) (config.Credentials, error) {
+	switch values := data.(type) {
+	case config.Credentials:
+		return values, nil
+	case map[string]interface{}:
+		return config.Credentials(values), nil
+	case nil:
+		return nil, errors.New("missing credentials data")
+	default:
+		return nil, fmt.Errorf("unexpected credentials data of type %T", data)
+	}
 }
 
 // DeleteCredentials receives the path and attempts to delete the existing credentials on Vault.
 // Is performs a soft delete, per docs > https://www.vaultproject.io/docs/commands/kv/delete
 func (vc *VaultClient) DeleteCredentials(path string) error {
+	return vc.DeleteCredentialsWithContext(context.Background(), path)
+}
+
+// DeleteCredentialsWithContext is the same as DeleteCredentials, but with a context.
+func (vc *VaultClient) DeleteCredentialsWithContext(ctx context.Context, path string) error {
 	vc.logger.Info("Deleting credentials from Vault ☄️")
 
-	_, err := vc.client.Logical().Delete(path)
+	_, err := vc.client.Logical().DeleteWithContext(ctx, path)
 	if err != nil {
 		vc.logger.Errorf("Unable to delete credentials from Vault 😱. Err: %v", err)
-		return err
+		return wrapVaultError(err)
 	}
 
 	vc.logger.Info("Credentials deleted from Vault ☠️")
@@ -115,13 +159,18 @@ func (vc *VaultClient) DeleteCredentials(path string) error {
 }
 
 // CheckIfEngineExists attempts to call the /tune API endpoint on the Secrets Engine. Should it fail, it might be an
-// indication that the Secrets Engine is not created, which it's useful to know whether or not to call CreateEngine
+// indication that the Secrets Engine is not created, which it's useful to know whether or not to call EnableEngine
 func (vc *VaultClient) CheckIfEngineExists(path string) bool {
+	return vc.CheckIfEngineExistsWithContext(context.Background(), path)
+}
+
+// CheckIfEngineExistsWithContext is the same as CheckIfEngineExists, but with a context.
+func (vc *VaultClient) CheckIfEngineExistsWithContext(ctx context.Context, path string) bool {
 	vc.logger.Info("Checking if vault engine exists☄️")
 
 	epath := fmt.Sprintf("sys/mounts/%s/tune", path)
 
-	if _, err := vc.client.Logical().Read(epath); err != nil {
+	if _, err := vc.client.Logical().ReadWithContext(ctx, epath); err != nil {
 		switch err.(type) {
 		case *api.ResponseError:
 			vc.logger.Infof("%v Secrets engine seems to be non existing 🤔. Err: %v", epath, err)
@@ -134,51 +183,79 @@ func (vc *VaultClient) CheckIfEngineExists(path string) bool {
 	return true
 }
 
-// List ...
+// List returns the data of listing the path, or nil if the path is empty.
 func (vc *VaultClient) List(path string) (interface{}, error) {
+	data, err := vc.ListWithContext(context.Background(), path)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, nil
+	}
+	// a nil map in the interface wouldn't be nil for the callers
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListWithContext is the same as List, but with a context. Returns ErrSecretNotFound if the path is empty.
+func (vc *VaultClient) ListWithContext(ctx context.Context, path string) (map[string]interface{}, error) {
 	vc.logger.Infof("[Vault] Listing the path: '%s' ☄️", path)
 
-	data, err := vc.client.Logical().List(path)
+	data, err := vc.client.Logical().ListWithContext(ctx, path)
 	if err != nil {
 		vc.logger.Errorf("[Vault] Unable to list the path: '%s' 😱. Err: %v", path, err)
-		return nil, err
+		return nil, wrapVaultError(err)
 	}
 
 	if data == nil {
 		vc.logger.Infof("[Vault] ❌ No data found in path: '%s'", path)
-		return nil, nil
+		return nil, ErrSecretNotFound
 	}
 
 	vc.logger.Infof("[Vault] Listed the path: '%s' ☄️", path)
 	return data.Data, nil
 }
 
-// Get ...
+// Get returns the data of the secret in the path, or nil if there is none.
 func (vc *VaultClient) Get(path string) (map[string]interface{}, error) {
+	data, err := vc.GetWithContext(context.Background(), path)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// GetWithContext is the same as Get, but with a context. Returns ErrSecretNotFound if there is no secret in the path.
+func (vc *VaultClient) GetWithContext(ctx context.Context, path string) (map[string]interface{}, error) {
 	vc.logger.Infof("[Vault] Getting the '%s' ☄️", path)
 
-	secret, err := vc.client.Logical().Read(path)
+	secret, err := vc.client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
 		vc.logger.Errorf("[Vault] Unable to get '%s' 😱. Err: %v", path, err)
-		return nil, err
+		return nil, wrapVaultError(err)
 	}
 
 	if secret == nil {
 		vc.logger.Infof("[Vault] ❌ No data found: %s", path)
-		return nil, nil
+		return nil, ErrSecretNotFound
 	}
 
 	vc.logger.Infof("[Vault] Got the '%s' ☄️", path)
 	return secret.Data, nil
 }
 
-// Delete ...
+// Delete deletes the secret in the path, if there is any.
 func (vc *VaultClient) Delete(path string) error {
+	return vc.DeleteWithContext(context.Background(), path)
+}
+
+// DeleteWithContext is the same as Delete, but with a context.
+func (vc *VaultClient) DeleteWithContext(ctx context.Context, path string) error {
 	vc.logger.Infof("[Vault] Deleting the path: '%s'", path)
 
-	secret, err := vc.client.Logical().Read(path)
+	secret, err := vc.client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
-		return fmt.Errorf("[Vault] Unable to delete the path: '%s' 😱. Err: %v", path, err)
+		vc.logger.Errorf("[Vault] Unable to delete the path: '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
 	}
 
 	if secret == nil {
@@ -186,54 +263,65 @@ func (vc *VaultClient) Delete(path string) error {
 		return nil
 	}
 
-	_, err = vc.client.Logical().Delete(path)
-	if err != nil {
-		return fmt.Errorf("[Vault] Unable to delete the path: '%s' 😱. Err: %v", path, err)
+	if _, err = vc.client.Logical().DeleteWithContext(ctx, path); err != nil {
+		vc.logger.Errorf("[Vault] Unable to delete the path: '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
 	}
 
 	vc.logger.Infof("[Vault] Deleted the path: '%s' ☄️", path)
 	return nil
 }
 
-// Put ...
+// Put writes the data in the path, replacing any existing data.
 func (vc *VaultClient) Put(path string, data map[string]interface{}) error {
+	return vc.PutWithContext(context.Background(), path, data)
+}
+
+// PutWithContext is the same as Put, but with a context.
+func (vc *VaultClient) PutWithContext(ctx context.Context, path string, data map[string]interface{}) error {
 	vc.logger.Infof("[Vault] Creating the '%s' ☄️", path)
 
-	_, err := vc.client.Logical().Write(path, data)
-	if err != nil {
-		return fmt.Errorf("[Vault] Unable to create '%s' 😱. Err: %v", path, err)
+	if _, err := vc.client.Logical().WriteWithContext(ctx, path, data); err != nil {
+		vc.logger.Errorf("[Vault] Unable to create '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
 	}
 
 	vc.logger.Infof("[Vault] Created the '%s' ☄️", path)
 	return nil
 }
 
-// Patch ...
+// Patch merges the data into the existing data of the path, creating it if it doesn't exist.
 func (vc *VaultClient) Patch(path string, data map[string]interface{}) error {
+	return vc.PatchWithContext(context.Background(), path, data)
+}
+
+// PatchWithContext is the same as Patch, but with a context.
+func (vc *VaultClient) PatchWithContext(ctx context.Context, path string, data map[string]interface{}) error {
 	vc.logger.Infof("[Vault] Patch the '%s' ☄️", path)
 	// try to patch the path
-	_, err := vc.client.Logical().JSONMergePatch(context.Background(), path, data)
+	_, err := vc.client.Logical().JSONMergePatch(ctx, path, data)
 	if err == nil {
 		return nil
 	}
 	// If it's a 405, that probably means the server is running a pre-1.9
 	// Vault version that doesn't support the HTTP PATCH method.
-	if re, ok := err.(*api.ResponseError); ok && re.StatusCode != 405 {
-		return fmt.Errorf("[Vault] Unable to add the path: '%s' 😱. Err: %v", path, err)
+	var re *api.ResponseError
+	if !errors.As(err, &re) || re.StatusCode != http.StatusMethodNotAllowed {
+		vc.logger.Errorf("[Vault] Unable to patch the path: '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
 	}
 	// get data to update it in memory
-	existingData, err := vc.Get(path)
+	existingData, err := vc.GetWithContext(ctx, path)
+	if errors.Is(err, ErrSecretNotFound) {
+		// it doesn't exists, create
+		return vc.PutWithContext(ctx, path, data)
+	}
 	if err != nil {
-		return fmt.Errorf("[Vault] Unable to get the path: '%s' 😱. Err: %v", path, err)
+		return err
 	}
-	// if exists data, then update
-	if existingData != nil {
-		// if it exists, then update
-		for key, value := range data {
-			existingData[key] = value
-		}
-		return vc.Put(path, existingData)
+	// if it exists, then update
+	for key, value := range data {
+		existingData[key] = value
 	}
-	// it doesn't exists, create
-	return vc.Put(path, data)
+	return vc.PutWithContext(ctx, path, existingData)
 }
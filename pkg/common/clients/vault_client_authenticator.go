@@ -1,6 +1,28 @@
 package clients
 
+import "fmt"
+
 // Authenticator is an interface to identify which way to authenticate
 type Authenticator interface {
 	Authenticate(vc *VaultClient) error
 }
+
+// NewAuthenticator returns the Authenticator of the auth method selected in the configuration.
+func NewAuthenticator(configuration VaultClientConfiguration) (Authenticator, error) {
+	switch configuration.AuthMethod {
+	case VaultAuthToken:
+		return NewLocalAuthenticator(configuration.Token), nil
+	case VaultAuthTokenFile:
+		return NewTokenFileAuthenticator(configuration.TokenPath, configuration.TokenFileWatchInterval), nil
+	case VaultAuthKubernetes, "":
+		return NewK8sAuthenticatorWithPaths(configuration.AuthMount, configuration.K8sTokenPath), nil
+	case VaultAuthAppRole:
+		return NewAppRoleAuthenticator(configuration.AuthMount,
+			configuration.AppRoleRoleID, configuration.AppRoleRoleIDPath,
+			configuration.AppRoleSecretID, configuration.AppRoleSecretIDPath), nil
+	case VaultAuthJWT:
+		return NewJWTAuthenticator(configuration.AuthMount, configuration.JWT, configuration.JWTPath), nil
+	default:
+		return nil, fmt.Errorf("unknown vault auth method %s", configuration.AuthMethod)
+	}
+}
@@ -0,0 +1,50 @@
+package clients
+
+const defaultAppRoleAuthMount = "approle"
+
+// AppRoleAuthenticator defines a struct for authenticating with AppRole.
+type AppRoleAuthenticator struct {
+	mount        string
+	roleID       string
+	roleIDPath   string
+	secretID     string
+	secretIDPath string
+}
+
+// NewAppRoleAuthenticator defines a new AppRoleAuthenticator struct. The role and secret IDs are read from
+// the given files when not given directly, on every login so they can be rotated.
+func NewAppRoleAuthenticator(mount, roleID, roleIDPath, secretID, secretIDPath string) Authenticator {
+	if mount == "" {
+		mount = defaultAppRoleAuthMount
+	}
+	return &AppRoleAuthenticator{
+		mount:        mount,
+		roleID:       roleID,
+		roleIDPath:   roleIDPath,
+		secretID:     secretID,
+		secretIDPath: secretIDPath,
+	}
+}
+
+// Authenticate is used to authenticate using AppRole.
+func (a *AppRoleAuthenticator) Authenticate(vc *VaultClient) error {
+	return a.login(vc)
+}
+
+func (a *AppRoleAuthenticator) login(vc *VaultClient) error {
+	vc.logger.Info("performing vault approle login.")
+	roleID, err := readValue("role_id", a.roleID, a.roleIDPath)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{"role_id": roleID}
+	// the secret_id is optional, depending on the bind_secret_id of the role
+	if a.secretID != "" || a.secretIDPath != "" {
+		secretID, err := readValue("secret_id", a.secretID, a.secretIDPath)
+		if err != nil {
+			return err
+		}
+		params["secret_id"] = secretID
+	}
+	return login(vc, a.mount, params)
+}
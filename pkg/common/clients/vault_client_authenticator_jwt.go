@@ -0,0 +1,37 @@
+package clients
+
+const defaultJWTAuthMount = "jwt"
+
+// JWTAuthenticator defines a struct for authenticating with a JWT, using the JWT/OIDC auth method.
+type JWTAuthenticator struct {
+	mount   string
+	jwt     string
+	jwtPath string
+}
+
+// NewJWTAuthenticator defines a new JWTAuthenticator struct. The JWT is read from jwtPath when not given
+// directly, on every login so it can be rotated. The role is the one given to NewVaultClient.
+func NewJWTAuthenticator(mount, jwt, jwtPath string) Authenticator {
+	if mount == "" {
+		mount = defaultJWTAuthMount
+	}
+	return &JWTAuthenticator{mount: mount, jwt: jwt, jwtPath: jwtPath}
+}
+
+// Authenticate is used to authenticate using a JWT.
+func (a *JWTAuthenticator) Authenticate(vc *VaultClient) error {
+	return a.login(vc)
+}
+
+func (a *JWTAuthenticator) login(vc *VaultClient) error {
+	vc.logger.Info("performing vault jwt login.")
+	jwt, err := readValue("jwt", a.jwt, a.jwtPath)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"jwt":  jwt,
+		"role": vc.role,
+	}
+	return login(vc, a.mount, params)
+}
@@ -1,80 +1,48 @@
 package clients
 
-import (
-	"errors"
-	"fmt"
-	"os"
-	"strings"
-	"time"
+const (
+	defaultK8sAuthMount = "kubernetes"
+	defaultK8sTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 )
 
 // K8sAuthenticator defines a struct for authenticating with Kubernetes.
-type K8sAuthenticator struct{}
+type K8sAuthenticator struct {
+	mount     string
+	tokenPath string
+}
 
-// NewK8sAuthenticator defines a new K8sAuthenticator struct.
+// NewK8sAuthenticator defines a new K8sAuthenticator struct, using the default auth mount and service account token.
 func NewK8sAuthenticator() Authenticator {
-	return &K8sAuthenticator{}
+	return NewK8sAuthenticatorWithPaths(defaultK8sAuthMount, defaultK8sTokenPath)
 }
 
-// Authenticate is used to authenticate using Kubernetes.
-func (a *K8sAuthenticator) Authenticate(vc *VaultClient) error {
-	if err := a.login(vc); err != nil {
-		return err
+// NewK8sAuthenticatorWithPaths defines a new K8sAuthenticator struct, logging in on the auth method mounted at
+// mount with the service account token read from tokenPath. Empty values fall back to the defaults.
+func NewK8sAuthenticatorWithPaths(mount, tokenPath string) Authenticator {
+	if mount == "" {
+		mount = defaultK8sAuthMount
 	}
+	if tokenPath == "" {
+		tokenPath = defaultK8sTokenPath
+	}
+	return &K8sAuthenticator{mount: mount, tokenPath: tokenPath}
+}
 
-	// do the token renewal cycle
-	go a.renew(vc)
-
-	return nil
+// Authenticate is used to authenticate using Kubernetes.
+func (a *K8sAuthenticator) Authenticate(vc *VaultClient) error {
+	return a.login(vc)
 }
 
 func (a *K8sAuthenticator) login(vc *VaultClient) error {
 	vc.logger.Info("performing vault k8s login.")
-	// reads jwt from service account
-	jwt, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token")
+	// reads jwt from service account, read again on every login as it's rotated by kubelet
+	jwt, err := readValue("service account token", "", a.tokenPath)
 	if err != nil {
-		return fmt.Errorf("unable to read file containing service account token: %v 😱", err)
+		return err
 	}
 	params := map[string]interface{}{
-		"jwt":  string(jwt),
+		"jwt":  jwt,
 		"role": vc.role, // the name of the role in Vault that was created with this app's Kubernetes service account bound to it
 	}
-	// perform login
-	secret, err := vc.client.Logical().Write("auth/kubernetes/login", params)
-	if err != nil {
-		return fmt.Errorf("unable to log in with Kubernetes auth: %v 😱", err)
-	}
-	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
-		return errors.New("login response did not return client token 😱")
-	}
-	// client update with the access token
-	vc.logger.Info("login: client logged in successfully 🔑")
-	token := strings.TrimSuffix(secret.Auth.ClientToken, "\n")
-	vc.client.SetToken(token)
-	// stores login response secret
-	vc.secret = secret
-
-	return nil
-}
-
-// renew the token according to secret.Auth.LeaseDuration automatically
-func (a *K8sAuthenticator) renew(vc *VaultClient) {
-	vc.logger.Info("stating vault token auto renew ...")
-	// schedule the token renew operation
-	for range time.Tick(time.Second * time.Duration(vc.secret.Auth.LeaseDuration-(vc.secret.Auth.LeaseDuration/10))) {
-		// perform renew
-		resp, err := vc.client.Auth().Token().Renew(vc.secret.Auth.ClientToken, vc.secret.Auth.LeaseDuration)
-		if err != nil {
-			vc.logger.Errorf("unable to renew the access token %v 😱", err)
-		}
-		// client update with the renewed token
-		if resp != nil && resp.Auth != nil && resp.Auth.ClientToken != "" {
-			token := strings.TrimSuffix(resp.Auth.ClientToken, "\n")
-			vc.logger.Info("renew: client token renewed successfully 🔑")
-			vc.client.SetToken(token)
-		} else {
-			// new login to deal with system token expiration
-			a.login(vc)
-		}
-	}
+	return login(vc, a.mount, params)
 }
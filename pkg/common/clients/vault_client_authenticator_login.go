@@ -0,0 +1,43 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// login performs the login on the auth method mounted at mount, and sets the client token.
+func login(vc *VaultClient, mount string, params map[string]interface{}) error {
+	path := fmt.Sprintf("auth/%s/login", strings.Trim(mount, "/"))
+	secret, err := vc.client.Logical().Write(path, params)
+	if err != nil {
+		return fmt.Errorf("unable to log in with %s: %w 😱", path, wrapVaultError(err))
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return errors.New("login response did not return client token 😱")
+	}
+	// client update with the access token
+	vc.logger.Info("login: client logged in successfully 🔑")
+	token := strings.TrimSuffix(secret.Auth.ClientToken, "\n")
+	vc.client.SetToken(token)
+	// stores login response secret
+	vc.secret = secret
+
+	return nil
+}
+
+// readValue returns the value, or the trimmed content of the file in path when the value is empty.
+func readValue(name, value, path string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if path == "" {
+		return "", fmt.Errorf("missing %s 😱", name)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file containing %s: %v 😱", name, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,181 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func newAuthTestClient(t *testing.T, server *vaulttest.Server, role string, authenticator Authenticator) *VaultClient {
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, role, authenticator)
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return vc
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration VaultClientConfiguration
+		expected      Authenticator
+	}{
+		{
+			name:          "token",
+			configuration: VaultClientConfiguration{AuthMethod: VaultAuthToken, Token: "s.token"},
+			expected:      &LocalAuthenticator{token: "s.token"},
+		},
+		{
+			name: "token file",
+			configuration: VaultClientConfiguration{
+				AuthMethod: VaultAuthTokenFile, TokenPath: "/vault/token", TokenFileWatchInterval: time.Second,
+			},
+			expected: &TokenFileAuthenticator{path: "/vault/token", interval: time.Second},
+		},
+		{
+			name:          "kubernetes by default",
+			configuration: VaultClientConfiguration{},
+			expected:      &K8sAuthenticator{mount: defaultK8sAuthMount, tokenPath: defaultK8sTokenPath},
+		},
+		{
+			name: "kubernetes with mount and token path",
+			configuration: VaultClientConfiguration{
+				AuthMethod: VaultAuthKubernetes, AuthMount: "k8s-cluster", K8sTokenPath: "/token",
+			},
+			expected: &K8sAuthenticator{mount: "k8s-cluster", tokenPath: "/token"},
+		},
+		{
+			name: "approle",
+			configuration: VaultClientConfiguration{
+				AuthMethod: VaultAuthAppRole, AppRoleRoleID: "role-id", AppRoleSecretIDPath: "/secret-id",
+			},
+			expected: &AppRoleAuthenticator{mount: defaultAppRoleAuthMount, roleID: "role-id", secretIDPath: "/secret-id"},
+		},
+		{
+			name:          "jwt",
+			configuration: VaultClientConfiguration{AuthMethod: VaultAuthJWT, AuthMount: "oidc", JWTPath: "/jwt"},
+			expected:      &JWTAuthenticator{mount: "oidc", jwtPath: "/jwt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewAuthenticator(tt.configuration)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, authenticator)
+		})
+	}
+
+	_, err := NewAuthenticator(VaultClientConfiguration{AuthMethod: "ldap"})
+	assert.Error(t, err)
+}
+
+func TestAppRoleAuthenticator(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.EnableAuth("services", vaulttest.AuthAppRole)
+
+	secretIDPath := writeFile(t, "secret-id", "secret-1\n")
+	authenticator := NewAppRoleAuthenticator("services", "role-id", "", "", secretIDPath)
+	vc := newAuthTestClient(t, server, "", authenticator)
+
+	calls := server.CallsTo(http.MethodPut, "auth/services/login")
+	require.Len(t, calls, 1)
+	assert.Equal(t, map[string]interface{}{"role_id": "role-id", "secret_id": "secret-1"}, calls[0].Body)
+	assert.Equal(t, "s.vaulttest1", vc.client.Token())
+
+	// the secret id is read again on every login, so it can be rotated
+	require.NoError(t, os.WriteFile(secretIDPath, []byte("secret-2"), 0o600))
+	require.NoError(t, authenticator.Authenticate(vc))
+	calls = server.CallsTo(http.MethodPut, "auth/services/login")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "secret-2", calls[1].Body["secret_id"])
+	assert.Equal(t, "s.vaulttest2", vc.client.Token())
+
+	// the secret id is optional
+	roleIDPath := writeFile(t, "role-id", "role-from-file")
+	require.NoError(t, NewAppRoleAuthenticator("services", "", roleIDPath, "", "").Authenticate(vc))
+	calls = server.CallsTo(http.MethodPut, "auth/services/login")
+	assert.Equal(t, map[string]interface{}{"role_id": "role-from-file"}, calls[2].Body)
+
+	assert.Error(t, NewAppRoleAuthenticator("services", "", "", "", "").Authenticate(vc))
+	assert.Error(t, NewAppRoleAuthenticator("services", "role-id", "", "", "/missing").Authenticate(vc))
+	// not enabled in the default mount
+	assert.Error(t, NewAppRoleAuthenticator("", "role-id", "", "", "").Authenticate(vc))
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.EnableAuth("jwt", vaulttest.AuthJWT)
+
+	jwtPath := writeFile(t, "jwt", "header.payload.signature")
+	vc := newAuthTestClient(t, server, "ci", NewJWTAuthenticator("", "", jwtPath))
+
+	calls := server.CallsTo(http.MethodPut, "auth/jwt/login")
+	require.Len(t, calls, 1)
+	assert.Equal(t, map[string]interface{}{"jwt": "header.payload.signature", "role": "ci"}, calls[0].Body)
+	assert.Equal(t, "s.vaulttest1", vc.client.Token())
+
+	require.NoError(t, NewJWTAuthenticator("jwt", "given.jwt.value", jwtPath).Authenticate(vc))
+	calls = server.CallsTo(http.MethodPut, "auth/jwt/login")
+	assert.Equal(t, "given.jwt.value", calls[1].Body["jwt"])
+
+	// the server rejects logins without a role
+	vc.role = ""
+	assert.Error(t, NewJWTAuthenticator("jwt", "given.jwt.value", "").Authenticate(vc))
+	assert.Error(t, NewJWTAuthenticator("jwt", "", "").Authenticate(vc))
+}
+
+func TestK8sAuthenticator(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.EnableAuth("k8s-cluster", vaulttest.AuthKubernetes)
+
+	tokenPath := writeFile(t, "token", "service-account-token\n")
+	vc := newAuthTestClient(t, server, "app", NewK8sAuthenticatorWithPaths("/k8s-cluster/", tokenPath))
+
+	calls := server.CallsTo(http.MethodPut, "auth/k8s-cluster/login")
+	require.Len(t, calls, 1)
+	assert.Equal(t, map[string]interface{}{"jwt": "service-account-token", "role": "app"}, calls[0].Body)
+	assert.Equal(t, "s.vaulttest1", vc.client.Token())
+
+	assert.Equal(t, &K8sAuthenticator{mount: defaultK8sAuthMount, tokenPath: defaultK8sTokenPath}, NewK8sAuthenticator())
+	assert.Error(t, NewK8sAuthenticatorWithPaths("k8s-cluster", "/missing").Authenticate(vc))
+	// not enabled in the default mount
+	assert.Error(t, NewK8sAuthenticatorWithPaths("", tokenPath).Authenticate(vc))
+}
+
+func TestTokenFileAuthenticator(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("secret", vaulttest.KVv2)
+
+	tokenPath := writeFile(t, "token", vaulttest.RootToken+"\n")
+	vc := newAuthTestClient(t, server, "", NewTokenFileAuthenticator(tokenPath, 0))
+	assert.Equal(t, vaulttest.RootToken, vc.client.Token())
+
+	_, err := vc.KVPut(context.Background(), "secret", "app", map[string]interface{}{"a": "1"})
+	require.NoError(t, err)
+	calls := server.CallsTo(http.MethodPut, "secret/data/app")
+	require.Len(t, calls, 1)
+	assert.Equal(t, vaulttest.RootToken, calls[0].Token)
+
+	_, err = NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewTokenFileAuthenticator("/missing", 0))
+	assert.Error(t, err)
+}
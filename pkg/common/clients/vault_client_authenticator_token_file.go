@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"context"
+	"time"
+)
+
+// TokenFileAuthenticator defines a struct for authenticating with a token read from a file, such as the
+// sink of a Vault Agent. The file is watched for changes by the TokenManager and the client token updated accordingly.
+type TokenFileAuthenticator struct {
+	path     string
+	interval time.Duration
+	token    string
+}
+
+// NewTokenFileAuthenticator defines a new TokenFileAuthenticator struct, checking the file for a new token every interval.
+func NewTokenFileAuthenticator(path string, interval time.Duration) Authenticator {
+	return &TokenFileAuthenticator{path: path, interval: interval}
+}
+
+// Authenticate is used to authenticate with the token in the file.
+func (a *TokenFileAuthenticator) Authenticate(vc *VaultClient) error {
+	token, err := readValue("token", "", a.path)
+	if err != nil {
+		return err
+	}
+	a.token = token
+	vc.client.SetToken(token)
+
+	return nil
+}
+
+// watch checks the file for a new token until the context is done, calling onChange after every token update,
+// failure to read the file and read of the file after a failure.
+func (a *TokenFileAuthenticator) watch(ctx context.Context, vc *VaultClient, onChange func(err error)) {
+	if a.interval <= 0 {
+		return
+	}
+
+	vc.logger.Infof("watching vault token file %s ...", a.path)
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		token, err := readValue("token", "", a.path)
+		if err != nil {
+			vc.logger.Errorf("unable to read the token file %v 😱", err)
+			failing = true
+			onChange(err)
+			continue
+		}
+		if token == "" {
+			// the file is being written
+			continue
+		}
+		if token != a.token {
+			a.token = token
+			vc.client.SetToken(token)
+			vc.logger.Info("token file changed, client token updated 🔑")
+		} else if !failing {
+			continue
+		}
+		failing = false
+		onChange(nil)
+	}
+}
@@ -1,10 +1,43 @@
 package clients
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Vault auth methods supported by NewAuthenticator.
+const (
+	VaultAuthToken      = "token"
+	VaultAuthTokenFile  = "token_file"
+	VaultAuthKubernetes = "kubernetes"
+	VaultAuthAppRole    = "approle"
+	VaultAuthJWT        = "jwt"
+)
 
 // VaultClientConfiguration is a struct that holds all the environment variables required to the Vault client
 type VaultClientConfiguration struct {
 	VaultURL string `envconfig:"VAULT_SERVER_URL" required:"true"`
+
+	// AuthMethod selects the Authenticator built by NewAuthenticator.
+	AuthMethod string `envconfig:"VAULT_AUTH_METHOD" default:"kubernetes"`
+	// AuthMount is the path where the auth method is mounted, defaults to the name of the method.
+	AuthMount string `envconfig:"VAULT_AUTH_MOUNT"`
+
+	Token                  string        `envconfig:"VAULT_TOKEN"`
+	TokenPath              string        `envconfig:"VAULT_TOKEN_PATH"`
+	TokenFileWatchInterval time.Duration `envconfig:"VAULT_TOKEN_FILE_WATCH_INTERVAL" default:"10s"`
+
+	// K8sTokenPath defaults to the token of the pod service account.
+	K8sTokenPath string `envconfig:"VAULT_K8S_TOKEN_PATH"`
+
+	AppRoleRoleID       string `envconfig:"VAULT_APPROLE_ROLE_ID"`
+	AppRoleRoleIDPath   string `envconfig:"VAULT_APPROLE_ROLE_ID_PATH"`
+	AppRoleSecretID     string `envconfig:"VAULT_APPROLE_SECRET_ID"`
+	AppRoleSecretIDPath string `envconfig:"VAULT_APPROLE_SECRET_ID_PATH"`
+
+	JWT     string `envconfig:"VAULT_JWT"`
+	JWTPath string `envconfig:"VAULT_JWT_PATH"`
 }
 
 // LoadFromEnvVars parses the required configuration variables. Throws an error if the validations aren't met
@@ -0,0 +1,203 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const vaultTag = "vault"
+
+// GetInto reads the secret in the path and decodes its data, as returned by Vault, into the struct pointed
+// by target, see DecodeSecret. Secrets of the KV v2 secrets engine must be read with KVGetInto, since their
+// data is wrapped with their metadata.
+func (vc *VaultClient) GetInto(ctx context.Context, path string, target interface{}) error {
+	data, err := vc.GetWithContext(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if err := DecodeSecret(data, target); err != nil {
+		return fmt.Errorf("unable to decode '%s': %w", path, err)
+	}
+	return nil
+}
+
+// KVGetInto reads the latest version of a secret from the KV v2 secrets engine mounted at mount and decodes it
+// into the struct pointed by target, see DecodeSecret.
+func (vc *VaultClient) KVGetInto(ctx context.Context, mount, path string, target interface{}) error {
+	secret, err := vc.KVGet(ctx, mount, path)
+	if err != nil {
+		return err
+	}
+
+	if err := DecodeSecret(secret.Data, target); err != nil {
+		return fmt.Errorf("unable to decode '%s' of '%s': %w", path, mount, err)
+	}
+	return nil
+}
+
+// DecodeSecret decodes the data of a secret into the struct pointed by target. The fields are matched by their
+// `vault:"field"` tag, and the ones tagged with `vault:"field,required"` must be present. Fields without a tag
+// or missing from the data keep their value.
+//
+// Values are converted to the type of the field, so numbers and booleans can be stored as strings.
+// Durations are parsed with time.ParseDuration, or taken as seconds when numeric, and string slices may be
+// comma separated strings.
+//
+//	type Database struct {
+//		Username string        `vault:"username,required"`
+//		Password string        `vault:"password,required"`
+//		Port     int           `vault:"port"`
+//		Timeout  time.Duration `vault:"timeout"`
+//	}
+func DecodeSecret(data map[string]interface{}, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", target)
+	}
+	value = value.Elem()
+
+	var missing []string
+	var errs []error
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		tag := structField.Tag.Get(vaultTag)
+		if tag == "" || tag == "-" || !structField.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		raw, ok := data[name]
+		if !ok || raw == nil {
+			if options == "required" {
+				missing = append(missing, name)
+			}
+			continue
+		}
+
+		if err := setField(value.Field(i), raw); err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", name, err))
+		}
+	}
+
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("missing required fields %s", strings.Join(missing, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(field reflect.Value, raw interface{}) error {
+	if field.Type() == durationType {
+		duration, err := toDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(fmt.Sprintf("%v", raw))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(fmt.Sprintf("%v", raw))
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(numberString(raw), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(numberString(raw), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(numberString(raw), field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes([]byte(fmt.Sprintf("%v", raw)))
+			return nil
+		}
+		return setSlice(field, raw)
+	case reflect.Map:
+		values, ok := raw.(map[string]interface{})
+		if !ok || field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unable to convert %T to %s", raw, field.Type())
+		}
+		m := reflect.MakeMapWithSize(field.Type(), len(values))
+		for key, value := range values {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), elem)
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func setSlice(field reflect.Value, raw interface{}) error {
+	var values []interface{}
+	switch v := raw.(type) {
+	case []interface{}:
+		values = v
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	default:
+		return fmt.Errorf("unable to convert %T to %s", raw, field.Type())
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setField(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+func toDuration(raw interface{}) (time.Duration, error) {
+	s := numberString(raw)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// numberString formats numbers decoded from JSON without exponent, so they can be parsed as integers.
+func numberString(raw interface{}) string {
+	switch v := raw.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", raw)
+	}
+}
@@ -0,0 +1,110 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/config"
+)
+
+func TestDecodeSecret(t *testing.T) {
+	type database struct {
+		Username string            `vault:"username,required"`
+		Password string            `vault:"password,required"`
+		Port     int               `vault:"port"`
+		TLS      bool              `vault:"tls"`
+		Timeout  time.Duration     `vault:"timeout"`
+		Retry    time.Duration     `vault:"retry"`
+		Hosts    []string          `vault:"hosts"`
+		Options  map[string]string `vault:"options"`
+		Name     string
+	}
+
+	target := database{Name: "default", Port: 5432}
+	err := DecodeSecret(map[string]interface{}{
+		"username": "admin",
+		"password": "secret",
+		"tls":      "true",
+		"timeout":  "1m30s",
+		"retry":    json.Number("5"),
+		"hosts":    "a, b",
+		"options":  map[string]interface{}{"sslmode": "require"},
+	}, &target)
+
+	require.NoError(t, err)
+	assert.Equal(t, database{
+		Username: "admin",
+		Password: "secret",
+		Port:     5432,
+		TLS:      true,
+		Timeout:  90 * time.Second,
+		Retry:    5 * time.Second,
+		Hosts:    []string{"a", "b"},
+		Options:  map[string]string{"sslmode": "require"},
+		Name:     "default",
+	}, target)
+
+	err = DecodeSecret(map[string]interface{}{"username": "admin", "port": "abc"}, &target)
+	assert.ErrorContains(t, err, "field port")
+	assert.ErrorContains(t, err, "missing required fields password")
+}
+
+func TestGetInto(t *testing.T) {
+	server, vc := newKVTestClient(t)
+	server.Mount("kv", vaulttest.KVv1)
+	ctx := context.Background()
+
+	type credentials struct {
+		Data     string `vault:"data"`
+		Metadata string `vault:"metadata"`
+		Password string `vault:"password"`
+	}
+
+	// a KV v1 secret with data and metadata fields isn't taken for a KV v2 one
+	require.NoError(t, vc.Put("kv/app", map[string]interface{}{
+		"data":     map[string]interface{}{"password": "nested"},
+		"metadata": "owner=team-a",
+		"password": "first",
+	}))
+	var target credentials
+	require.NoError(t, vc.GetInto(ctx, "kv/app", &target))
+	assert.Equal(t, credentials{Data: "map[password:nested]", Metadata: "owner=team-a", Password: "first"}, target)
+
+	_, err := vc.KVPut(ctx, "secret", "app", map[string]interface{}{"password": "second"})
+	require.NoError(t, err)
+	target = credentials{}
+	require.NoError(t, vc.KVGetInto(ctx, "secret", "app", &target))
+	assert.Equal(t, credentials{Password: "second"}, target)
+
+	assert.ErrorIs(t, vc.GetInto(ctx, "kv/missing", &target), ErrSecretNotFound)
+}
+
+func TestVaultConfigurationVariables(t *testing.T) {
+	server, vc := newKVTestClient(t)
+	server.Mount("kv", vaulttest.KVv1)
+	ctx := context.Background()
+
+	require.NoError(t, vc.Put("kv/database", map[string]interface{}{"username": "admin"}))
+	_, err := vc.KVPut(ctx, "secret", "database", map[string]interface{}{"password": "secret"})
+	require.NoError(t, err)
+
+	type database struct {
+		Host     string
+		Username string `vault:"username"`
+		Password string `vault:"password"`
+	}
+	target := &database{Host: "localhost"}
+	require.NoError(t, config.InitConfigurationVariables([]config.ConfigurationVariables{
+		NewVaultConfigurationVariables(vc, "kv/database", target),
+		NewVaultKVConfigurationVariables(vc, "secret", "database", target),
+	}))
+	assert.Equal(t, &database{Host: "localhost", Username: "admin", Password: "secret"}, target)
+
+	// the client must be created in a first phase
+	assert.Error(t, NewVaultKVConfigurationVariables(nil, "secret", "database", target).LoadFromEnvVars())
+}
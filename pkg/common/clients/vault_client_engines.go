@@ -0,0 +1,113 @@
+package clients
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Secrets engine types commonly enabled with EnableEngine.
+const (
+	EngineKV       = "kv"
+	EngineTransit  = "transit"
+	EnginePKI      = "pki"
+	EngineDatabase = "database"
+)
+
+// EngineOptions are the options of a secrets engine enabled with EnableEngine.
+type EngineOptions struct {
+	// Type of the engine, e.g. EngineKV.
+	Type        string
+	Description string
+	// KVVersion is the version of the KV engine, 1 or 2. Ignored for other engines.
+	KVVersion int
+	// DefaultLeaseTTL and MaxLeaseTTL default to the ones of the system when 0.
+	DefaultLeaseTTL time.Duration
+	MaxLeaseTTL     time.Duration
+	// Options are passed as is to the engine.
+	Options map[string]string
+}
+
+// TuneOptions are the settings of a mount changed by TuneEngine. Nil fields are left unchanged.
+type TuneOptions struct {
+	Description     *string
+	DefaultLeaseTTL *time.Duration
+	MaxLeaseTTL     *time.Duration
+	Options         map[string]string
+}
+
+// EnableEngine enables a secrets engine at the path.
+func (vc *VaultClient) EnableEngine(ctx context.Context, path string, options EngineOptions) error {
+	vc.logger.Infof("[Vault] Enabling the %s secrets engine at '%s' ☄️", options.Type, path)
+
+	engineOptions := map[string]string{}
+	for key, value := range options.Options {
+		engineOptions[key] = value
+	}
+	if options.Type == EngineKV && options.KVVersion > 0 {
+		engineOptions["version"] = strconv.Itoa(options.KVVersion)
+	}
+
+	input := &api.MountInput{
+		Type:        options.Type,
+		Description: options.Description,
+		Config: api.MountConfigInput{
+			DefaultLeaseTTL: formatTTL(options.DefaultLeaseTTL),
+			MaxLeaseTTL:     formatTTL(options.MaxLeaseTTL),
+		},
+		Options: engineOptions,
+	}
+	if err := vc.client.Sys().MountWithContext(ctx, path, input); err != nil {
+		vc.logger.Errorf("[Vault] Unable to enable the secrets engine at '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// DisableEngine disables the secrets engine at the path, deleting all its data and revoking its leases.
+func (vc *VaultClient) DisableEngine(ctx context.Context, path string) error {
+	vc.logger.Infof("[Vault] Disabling the secrets engine at '%s' ☠️", path)
+
+	if err := vc.client.Sys().UnmountWithContext(ctx, path); err != nil {
+		vc.logger.Errorf("[Vault] Unable to disable the secrets engine at '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// TuneEngine changes the settings of the secrets engine at the path.
+func (vc *VaultClient) TuneEngine(ctx context.Context, path string, options TuneOptions) error {
+	vc.logger.Infof("[Vault] Tuning the secrets engine at '%s' ☄️", path)
+
+	input := api.TuneMountConfigInput{Description: options.Description}
+	if options.DefaultLeaseTTL != nil {
+		ttl := formatTTL(*options.DefaultLeaseTTL)
+		input.DefaultLeaseTTL = &ttl
+	}
+	if options.MaxLeaseTTL != nil {
+		ttl := formatTTL(*options.MaxLeaseTTL)
+		input.MaxLeaseTTL = &ttl
+	}
+	if options.Options != nil {
+		input.Options = &options.Options
+	}
+
+	if err := vc.client.Sys().TuneMountAllowNilWithContext(ctx, path, input); err != nil {
+		vc.logger.Errorf("[Vault] Unable to tune the secrets engine at '%s' 😱. Err: %v", path, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// formatTTL formats the duration as seconds, or as an empty string for 0 so Vault uses its default.
+func formatTTL(ttl time.Duration) string {
+	if ttl <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(ttl.Seconds()), 10) + "s"
+}
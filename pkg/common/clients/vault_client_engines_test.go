@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestTenantProvisioning(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.EnableAuth("kubernetes", vaulttest.AuthKubernetes)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	defer vc.Close()
+	ctx := context.Background()
+
+	require.NoError(t, vc.EnableEngine(ctx, "tenants", EngineOptions{Type: EngineKV, KVVersion: 2, MaxLeaseTTL: time.Hour}))
+	engine, ok := server.Mounted("tenants")
+	require.True(t, ok)
+	assert.Equal(t, vaulttest.KVv2, engine)
+	assert.Error(t, vc.EnableEngine(ctx, "tenants", EngineOptions{Type: EngineKV}))
+
+	defaultTTL := 10 * time.Minute
+	require.NoError(t, vc.TuneEngine(ctx, "tenants", TuneOptions{DefaultLeaseTTL: &defaultTTL}))
+	tune, err := vc.client.Sys().MountConfigWithContext(ctx, "tenants")
+	require.NoError(t, err)
+	assert.Equal(t, 600, tune.DefaultLeaseTTL)
+	assert.Equal(t, 3600, tune.MaxLeaseTTL)
+
+	data := map[string]string{"Mount": "tenants", "Path": "acme"}
+	require.NoError(t, vc.PutPolicyFromTemplate(ctx, "acme", KVReadWritePolicyTemplate, data))
+	rules, err := vc.GetPolicy(ctx, "acme")
+	require.NoError(t, err)
+	assert.Contains(t, rules, `path "tenants/data/acme/*"`)
+	assert.Error(t, vc.PutPolicyFromTemplate(ctx, "acme", KVReadPolicyTemplate, map[string]string{"Mount": "tenants"}))
+
+	role := K8sAuthRole{
+		Name:                     "acme",
+		ServiceAccountNames:      []string{"app"},
+		ServiceAccountNamespaces: []string{"acme"},
+		Policies:                 []string{"acme"},
+		TTL:                      time.Hour,
+	}
+	require.NoError(t, vc.PutK8sAuthRole(ctx, "", role))
+	written, ok := server.AuthRole("kubernetes", "acme")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"acme"}, written["token_policies"])
+	assert.Equal(t, "3600s", written["token_ttl"])
+
+	require.NoError(t, vc.DeleteK8sAuthRole(ctx, "", "acme"))
+	require.NoError(t, vc.DeletePolicy(ctx, "acme"))
+	_, err = vc.GetPolicy(ctx, "acme")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	require.NoError(t, vc.DisableEngine(ctx, "tenants"))
+	assert.False(t, vc.CheckIfEngineExists("tenants"))
+}
+
+func TestPutPolicyFromTemplateInjection(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	defer vc.Close()
+	ctx := context.Background()
+
+	injections := []string{
+		"acme/*\" {\n  capabilities = [\"read\"]\n}\n\npath \"sys/*\" {\n  capabilities = [\"sudo\"]\n}\n\npath \"x",
+		"*",
+		"acme+",
+		"acme\"",
+		"acme}",
+		"",
+	}
+	for _, path := range injections {
+		err := vc.PutPolicyFromTemplate(ctx, "acme", KVReadPolicyTemplate, map[string]string{"Mount": "tenants", "Path": path})
+		assert.Error(t, err, path)
+	}
+	_, ok := server.Policy("acme")
+	assert.False(t, ok)
+
+	// values rendered through pipelines, nested blocks and other templates are validated too
+	text := `{{ define "path" }}path "{{ . }}/*" { capabilities = ["read"] }{{ end }}` +
+		`{{ range .Paths }}{{ template "path" . }}{{ end }}{{ with .Extra }}{{ printf "%s" . }}{{ end }}`
+	assert.Error(t, vc.PutPolicyFromTemplate(ctx, "acme", text,
+		map[string]interface{}{"Paths": []string{"tenants/data/acme", "sys/*"}, "Extra": nil}))
+	assert.Error(t, vc.PutPolicyFromTemplate(ctx, "acme", text,
+		map[string]interface{}{"Paths": []string{"tenants/data/acme"}, "Extra": `" {}`}))
+
+	require.NoError(t, vc.PutPolicyFromTemplate(ctx, "acme", text,
+		map[string]interface{}{"Paths": []string{"tenants/data/acme", "tenants/data/acme-2"}, "Extra": nil}))
+	rules, ok := server.Policy("acme")
+	require.True(t, ok)
+	assert.Equal(t, `path "tenants/data/acme/*" { capabilities = ["read"] }`+
+		`path "tenants/data/acme-2/*" { capabilities = ["read"] }`, rules)
+}
@@ -0,0 +1,56 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+var (
+	// ErrSecretNotFound is returned when there is no secret at the requested path.
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrPermissionDenied is returned when the token is not allowed to perform the operation, or is no longer valid.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrSealed is returned when the Vault server is sealed and can't serve requests.
+	ErrSealed = errors.New("vault is sealed")
+)
+
+// wrapVaultError wraps the errors returned by the Vault API with the matching typed error, if any.
+// The original error, usually an *api.ResponseError, is kept in the chain so errors.As still works.
+func wrapVaultError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrSecretNotFound) || errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrSealed) {
+		return err
+	}
+	if errors.Is(err, api.ErrSecretNotFound) {
+		return fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	}
+
+	var re *api.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	switch {
+	case re.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	case re.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrPermissionDenied, err)
+	case re.StatusCode == http.StatusServiceUnavailable && isSealedResponse(re):
+		return fmt.Errorf("%w: %w", ErrSealed, err)
+	}
+	return err
+}
+
+func isSealedResponse(re *api.ResponseError) bool {
+	for _, e := range re.Errors {
+		if strings.Contains(strings.ToLower(e), "sealed") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestWrapVaultError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"not found", &api.ResponseError{StatusCode: http.StatusNotFound}, ErrSecretNotFound},
+		{"kv not found", fmt.Errorf("%w: at secret/data/app", api.ErrSecretNotFound), ErrSecretNotFound},
+		{"forbidden", &api.ResponseError{StatusCode: http.StatusForbidden, Errors: []string{"permission denied"}}, ErrPermissionDenied},
+		{"sealed", &api.ResponseError{StatusCode: http.StatusServiceUnavailable, Errors: []string{"Vault is sealed"}}, ErrSealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapVaultError(tt.err)
+
+			assert.ErrorIs(t, err, tt.expected)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	unavailable := &api.ResponseError{StatusCode: http.StatusServiceUnavailable, Errors: []string{"standby"}}
+	assert.Equal(t, unavailable, wrapVaultError(unavailable))
+	assert.Nil(t, wrapVaultError(nil))
+
+	var re *api.ResponseError
+	assert.True(t, errors.As(wrapVaultError(&api.ResponseError{StatusCode: http.StatusNotFound}), &re))
+}
+
+func TestListErrors(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("kv", vaulttest.KVv1)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	defer vc.Close()
+	require.NoError(t, vc.Put("kv/app", map[string]interface{}{"a": "1"}))
+
+	listed, err := vc.List("kv/missing/")
+	require.NoError(t, err)
+	assert.Nil(t, listed)
+
+	server.FailOnce("LIST", "kv", http.StatusForbidden, "permission denied")
+	listed, err = vc.List("kv/")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.True(t, listed == nil, "List must return an untyped nil on error")
+}
@@ -0,0 +1,253 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// ErrCheckAndSetMismatch is returned by KVPutCAS when the secret was updated since the given version was read.
+var ErrCheckAndSetMismatch = errors.New("check-and-set version mismatch")
+
+// KVGet reads the latest version of a secret from the KV v2 secrets engine mounted at mount.
+// Returns ErrSecretNotFound if the secret doesn't exist. If the latest version was deleted,
+// the Data of the returned secret is nil.
+func (vc *VaultClient) KVGet(ctx context.Context, mount, path string) (*api.KVSecret, error) {
+	vc.logger.Infof("[Vault] Getting the KV secret '%s' from '%s' ☄️", path, mount)
+
+	secret, err := vc.client.KVv2(mount).Get(ctx, path)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to get the KV secret '%s' from '%s' 😱. Err: %v", path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return secret, nil
+}
+
+// KVGetVersion reads a specific version of a secret from the KV v2 secrets engine mounted at mount.
+func (vc *VaultClient) KVGetVersion(ctx context.Context, mount, path string, version int) (*api.KVSecret, error) {
+	vc.logger.Infof("[Vault] Getting the version %d of the KV secret '%s' from '%s' ☄️", version, path, mount)
+
+	secret, err := vc.client.KVv2(mount).GetVersion(ctx, path, version)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to get the version %d of the KV secret '%s' from '%s' 😱. Err: %v",
+			version, path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return secret, nil
+}
+
+// KVPut writes a new version of a secret in the KV v2 secrets engine mounted at mount.
+func (vc *VaultClient) KVPut(ctx context.Context, mount, path string, data map[string]interface{}) (*api.KVSecret, error) {
+	vc.logger.Infof("[Vault] Writing the KV secret '%s' in '%s' ☄️", path, mount)
+
+	secret, err := vc.client.KVv2(mount).Put(ctx, path, data)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to write the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return secret, nil
+}
+
+// KVPutCAS writes a new version of a secret only if its current version is the given one, so concurrent
+// updates are not lost. A version of 0 only writes the secret if it doesn't exist yet.
+// Returns ErrCheckAndSetMismatch if the current version is a different one.
+func (vc *VaultClient) KVPutCAS(ctx context.Context, mount, path string, data map[string]interface{}, version int) (*api.KVSecret, error) {
+	vc.logger.Infof("[Vault] Writing the KV secret '%s' in '%s' with check-and-set version %d ☄️", path, mount, version)
+
+	secret, err := vc.client.KVv2(mount).Put(ctx, path, data, api.WithCheckAndSet(version))
+	if err != nil {
+		if isCheckAndSetMismatch(err) {
+			vc.logger.Warnf("[Vault] The KV secret '%s' in '%s' is no longer at version %d 🤔", path, mount, version)
+			return nil, fmt.Errorf("%w: %w", ErrCheckAndSetMismatch, err)
+		}
+		vc.logger.Errorf("[Vault] Unable to write the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return secret, nil
+}
+
+// KVGetMetadata reads the metadata and the versions of a secret from the KV v2 secrets engine mounted at mount.
+func (vc *VaultClient) KVGetMetadata(ctx context.Context, mount, path string) (*api.KVMetadata, error) {
+	vc.logger.Infof("[Vault] Getting the metadata of the KV secret '%s' from '%s' ☄️", path, mount)
+
+	metadata, err := vc.client.KVv2(mount).GetMetadata(ctx, path)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to get the metadata of the KV secret '%s' from '%s' 😱. Err: %v", path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return metadata, nil
+}
+
+// KVPutMetadata replaces the metadata of a secret in the KV v2 secrets engine mounted at mount.
+// Every field of the input is written, fields left unset are reset to their zero value.
+func (vc *VaultClient) KVPutMetadata(ctx context.Context, mount, path string, metadata api.KVMetadataPutInput) error {
+	vc.logger.Infof("[Vault] Writing the metadata of the KV secret '%s' in '%s' ☄️", path, mount)
+
+	if err := vc.client.KVv2(mount).PutMetadata(ctx, path, metadata); err != nil {
+		vc.logger.Errorf("[Vault] Unable to write the metadata of the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVPatchMetadata updates only the given fields of the metadata of a secret in the KV v2 secrets engine
+// mounted at mount.
+func (vc *VaultClient) KVPatchMetadata(ctx context.Context, mount, path string, metadata api.KVMetadataPatchInput) error {
+	vc.logger.Infof("[Vault] Patching the metadata of the KV secret '%s' in '%s' ☄️", path, mount)
+
+	if err := vc.client.KVv2(mount).PatchMetadata(ctx, path, metadata); err != nil {
+		vc.logger.Errorf("[Vault] Unable to patch the metadata of the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVDelete soft deletes the given versions of a secret in the KV v2 secrets engine mounted at mount,
+// or the latest version when none is given. Deleted versions can be recovered with KVUndelete.
+func (vc *VaultClient) KVDelete(ctx context.Context, mount, path string, versions ...int) error {
+	vc.logger.Infof("[Vault] Deleting the versions %v of the KV secret '%s' in '%s' ☄️", versions, path, mount)
+
+	var err error
+	if len(versions) == 0 {
+		err = vc.client.KVv2(mount).Delete(ctx, path)
+	} else {
+		err = vc.client.KVv2(mount).DeleteVersions(ctx, path, versions)
+	}
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to delete the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVUndelete restores the given soft deleted versions of a secret in the KV v2 secrets engine mounted at mount.
+func (vc *VaultClient) KVUndelete(ctx context.Context, mount, path string, versions ...int) error {
+	vc.logger.Infof("[Vault] Undeleting the versions %v of the KV secret '%s' in '%s' ☄️", versions, path, mount)
+
+	if err := vc.client.KVv2(mount).Undelete(ctx, path, versions); err != nil {
+		vc.logger.Errorf("[Vault] Unable to undelete the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVDestroy permanently removes the data of the given versions of a secret in the KV v2 secrets engine
+// mounted at mount. The versions can't be recovered.
+func (vc *VaultClient) KVDestroy(ctx context.Context, mount, path string, versions ...int) error {
+	vc.logger.Infof("[Vault] Destroying the versions %v of the KV secret '%s' in '%s' ☠️", versions, path, mount)
+
+	if err := vc.client.KVv2(mount).Destroy(ctx, path, versions); err != nil {
+		vc.logger.Errorf("[Vault] Unable to destroy the KV secret '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVDeleteMetadata permanently removes all the versions and the metadata of a secret in the KV v2 secrets
+// engine mounted at mount.
+func (vc *VaultClient) KVDeleteMetadata(ctx context.Context, mount, path string) error {
+	vc.logger.Infof("[Vault] Deleting all the versions of the KV secret '%s' in '%s' ☠️", path, mount)
+
+	if err := vc.client.KVv2(mount).DeleteMetadata(ctx, path); err != nil {
+		vc.logger.Errorf("[Vault] Unable to delete all the versions of the KV secret '%s' in '%s' 😱. Err: %v",
+			path, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// KVList returns the keys under a path of the KV v2 secrets engine mounted at mount.
+// Keys ending with a slash are folders.
+func (vc *VaultClient) KVList(ctx context.Context, mount, path string) ([]string, error) {
+	vc.logger.Infof("[Vault] Listing the KV path '%s' in '%s' ☄️", path, mount)
+
+	keys, err := vc.kvList(ctx, mount, path)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to list the KV path '%s' in '%s' 😱. Err: %v", path, mount, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return keys, nil
+}
+
+// KVListRecursive returns the paths of all the secrets under a path of the KV v2 secrets engine
+// mounted at mount, walking through every folder.
+func (vc *VaultClient) KVListRecursive(ctx context.Context, mount, path string) ([]string, error) {
+	vc.logger.Infof("[Vault] Listing recursively the KV path '%s' in '%s' ☄️", path, mount)
+
+	var secrets []string
+	folders := []string{strings.Trim(path, "/")}
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+
+		keys, err := vc.kvList(ctx, mount, folder)
+		if err != nil {
+			vc.logger.Errorf("[Vault] Unable to list the KV path '%s' in '%s' 😱. Err: %v", folder, mount, err)
+			return nil, wrapVaultError(err)
+		}
+
+		for _, key := range keys {
+			fullPath := key
+			if folder != "" {
+				fullPath = folder + "/" + key
+			}
+			if strings.HasSuffix(key, "/") {
+				folders = append(folders, strings.TrimSuffix(fullPath, "/"))
+			} else {
+				secrets = append(secrets, fullPath)
+			}
+		}
+	}
+
+	return secrets, nil
+}
+
+func (vc *VaultClient) kvList(ctx context.Context, mount, path string) ([]string, error) {
+	secret, err := vc.client.Logical().ListWithContext(ctx, fmt.Sprintf("%s/metadata/%s", strings.Trim(mount, "/"), path))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	rawKeys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(rawKeys))
+	for _, key := range rawKeys {
+		keys = append(keys, fmt.Sprintf("%v", key))
+	}
+
+	return keys, nil
+}
+
+func isCheckAndSetMismatch(err error) bool {
+	var re *api.ResponseError
+	if !errors.As(err, &re) || re.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, e := range re.Errors {
+		if strings.Contains(e, "check-and-set parameter did not match the current version") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newKVTestClient(t *testing.T) (*vaulttest.Server, *VaultClient) {
+	server := vaulttest.NewServer()
+	t.Cleanup(server.Close)
+	server.Mount("secret", vaulttest.KVv2)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return server, vc
+}
+
+func TestKVVersions(t *testing.T) {
+	_, vc := newKVTestClient(t)
+	ctx := context.Background()
+
+	for i, password := range []string{"first", "second", "third"} {
+		secret, err := vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": password})
+		require.NoError(t, err)
+		assert.Equal(t, i+1, secret.VersionMetadata.Version)
+	}
+
+	secret, err := vc.KVGet(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "third"}, secret.Data)
+	assert.Equal(t, 3, secret.VersionMetadata.Version)
+
+	secret, err = vc.KVGetVersion(ctx, "secret", "apps/web", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "second", secret.Data["password"])
+	_, err = vc.KVGetVersion(ctx, "secret", "apps/web", 4)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	// soft deleted versions keep their metadata and can be restored
+	require.NoError(t, vc.KVDelete(ctx, "secret", "apps/web", 1, 2))
+	secret, err = vc.KVGetVersion(ctx, "secret", "apps/web", 1)
+	require.NoError(t, err)
+	assert.Nil(t, secret.Data)
+	assert.False(t, secret.VersionMetadata.DeletionTime.IsZero())
+
+	require.NoError(t, vc.KVUndelete(ctx, "secret", "apps/web", 1))
+	secret, err = vc.KVGetVersion(ctx, "secret", "apps/web", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "first", secret.Data["password"])
+
+	// destroyed versions can't be restored
+	require.NoError(t, vc.KVDestroy(ctx, "secret", "apps/web", 2))
+	require.NoError(t, vc.KVUndelete(ctx, "secret", "apps/web", 2))
+	secret, err = vc.KVGetVersion(ctx, "secret", "apps/web", 2)
+	require.NoError(t, err)
+	assert.Nil(t, secret.Data)
+	assert.True(t, secret.VersionMetadata.Destroyed)
+
+	metadata, err := vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 3, metadata.CurrentVersion)
+	assert.Equal(t, 1, metadata.OldestVersion)
+	require.Len(t, metadata.Versions, 3)
+	assert.True(t, metadata.Versions["2"].Destroyed)
+	assert.True(t, metadata.Versions["1"].DeletionTime.IsZero())
+
+	require.NoError(t, vc.KVDeleteMetadata(ctx, "secret", "apps/web"))
+	_, err = vc.KVGet(ctx, "secret", "apps/web")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	_, err = vc.KVGetMetadata(ctx, "secret", "apps/web")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestKVCheckAndSet(t *testing.T) {
+	_, vc := newKVTestClient(t)
+	ctx := context.Background()
+
+	// version 0 only writes secrets that don't exist yet
+	_, err := vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "first"}, 0)
+	require.NoError(t, err)
+	_, err = vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "again"}, 0)
+	assert.ErrorIs(t, err, ErrCheckAndSetMismatch)
+
+	secret, err := vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "second"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, secret.VersionMetadata.Version)
+	_, err = vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "stale"}, 1)
+	assert.ErrorIs(t, err, ErrCheckAndSetMismatch)
+
+	// with cas_required, writes without check-and-set are rejected, but not as a mismatch
+	require.NoError(t, vc.KVPatchMetadata(ctx, "secret", "apps/web", api.KVMetadataPatchInput{CASRequired: boolPtr(true)}))
+	_, err = vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": "unchecked"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCheckAndSetMismatch)
+	_, err = vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "third"}, 2)
+	require.NoError(t, err)
+}
+
+func TestKVMetadata(t *testing.T) {
+	_, vc := newKVTestClient(t)
+	ctx := context.Background()
+
+	require.NoError(t, vc.KVPutMetadata(ctx, "secret", "apps/web", api.KVMetadataPutInput{
+		MaxVersions:        2,
+		DeleteVersionAfter: time.Hour,
+		CustomMetadata:     map[string]interface{}{"owner": "team-a"},
+	}))
+	metadata, err := vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.MaxVersions)
+	assert.Equal(t, time.Hour, metadata.DeleteVersionAfter)
+	assert.Equal(t, map[string]interface{}{"owner": "team-a"}, metadata.CustomMetadata)
+
+	// patching keeps the fields that are not given
+	require.NoError(t, vc.KVPatchMetadata(ctx, "secret", "apps/web", api.KVMetadataPatchInput{
+		CustomMetadata: map[string]interface{}{"owner": "team-b"},
+	}))
+	metadata, err = vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.MaxVersions)
+	assert.Equal(t, map[string]interface{}{"owner": "team-b"}, metadata.CustomMetadata)
+
+	// max versions destroys the oldest versions
+	for _, password := range []string{"first", "second", "third"} {
+		_, err = vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": password})
+		require.NoError(t, err)
+	}
+	metadata, err = vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 3, metadata.CurrentVersion)
+	assert.Equal(t, 2, metadata.OldestVersion)
+
+	// putting replaces every field
+	require.NoError(t, vc.KVPutMetadata(ctx, "secret", "apps/web", api.KVMetadataPutInput{MaxVersions: 5}))
+	metadata, err = vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 5, metadata.MaxVersions)
+	assert.Empty(t, metadata.CustomMetadata)
+}
+
+func TestKVList(t *testing.T) {
+	server, vc := newKVTestClient(t)
+	ctx := context.Background()
+
+	for _, path := range []string{"apps/web", "apps/api/db", "apps/api/cache", "infra/dns"} {
+		_, err := vc.KVPut(ctx, "secret", path, map[string]interface{}{"value": path})
+		require.NoError(t, err)
+	}
+
+	keys, err := vc.KVList(ctx, "secret", "apps")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/", "web"}, keys)
+
+	keys, err = vc.KVList(ctx, "secret", "missing")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	paths, err := vc.KVListRecursive(ctx, "secret", "/apps/")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"apps/web", "apps/api/db", "apps/api/cache"}, paths)
+
+	paths, err = vc.KVListRecursive(ctx, "secret", "")
+	require.NoError(t, err)
+	assert.Len(t, paths, 4)
+
+	server.FailOnce("LIST", "secret/metadata/apps/api", http.StatusForbidden, "permission denied")
+	_, err = vc.KVListRecursive(ctx, "secret", "apps")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestKVErrors(t *testing.T) {
+	server, vc := newKVTestClient(t)
+	ctx := context.Background()
+
+	server.FailOnce(http.MethodGet, "secret/data/apps/*", http.StatusForbidden, "permission denied")
+	_, err := vc.KVGet(ctx, "secret", "apps/web")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	server.FailOnce(http.MethodPut, "secret/data/apps/*", http.StatusForbidden, "permission denied")
+	_, err = vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": "first"})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	server.FailOnce(http.MethodPut, "secret/metadata/apps/*", http.StatusForbidden, "permission denied")
+	err = vc.KVPutMetadata(ctx, "secret", "apps/web", api.KVMetadataPutInput{})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	server.FailOnce(http.MethodPut, "secret/destroy/apps/*", http.StatusForbidden, "permission denied")
+	assert.ErrorIs(t, vc.KVDestroy(ctx, "secret", "apps/web", 1), ErrPermissionDenied)
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,202 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// RenewLease extends the lease of a dynamic secret by increment, or by the default of the engine when 0.
+func (vc *VaultClient) RenewLease(ctx context.Context, leaseID string, increment time.Duration) (*api.Secret, error) {
+	vc.logger.Infof("[Vault] Renewing the lease '%s' ☄️", leaseID)
+
+	secret, err := vc.client.Sys().RenewWithContext(ctx, leaseID, int(increment.Seconds()))
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to renew the lease '%s' 😱. Err: %v", leaseID, err)
+		return nil, wrapVaultError(err)
+	}
+
+	return secret, nil
+}
+
+// RevokeLease revokes the lease of a dynamic secret, so its credentials are no longer valid.
+func (vc *VaultClient) RevokeLease(ctx context.Context, leaseID string) error {
+	vc.logger.Infof("[Vault] Revoking the lease '%s' ☠️", leaseID)
+
+	if err := vc.client.Sys().RevokeWithContext(ctx, leaseID); err != nil {
+		vc.logger.Errorf("[Vault] Unable to revoke the lease '%s' 😱. Err: %v", leaseID, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// DynamicSecret is a secret with a lease, such as the credentials of the database secrets engine,
+// that is renewed by the LeaseManager until it reaches its max TTL or is revoked.
+type DynamicSecret struct {
+	path    string
+	watcher *api.LifetimeWatcher
+	done    chan struct{}
+
+	mu        sync.RWMutex
+	secret    *api.Secret
+	expiresAt time.Time
+	err       error
+}
+
+// Path returns the path the secret was read from.
+func (s *DynamicSecret) Path() string {
+	return s.path
+}
+
+// LeaseID returns the ID of the lease of the secret.
+func (s *DynamicSecret) LeaseID() string {
+	return s.secret.LeaseID
+}
+
+// Data returns the data of the secret, e.g. the username and password of the database credentials.
+func (s *DynamicSecret) Data() map[string]interface{} {
+	return s.secret.Data
+}
+
+// ExpiresAt returns when the lease expires, considering the last renewal.
+func (s *DynamicSecret) ExpiresAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.expiresAt
+}
+
+// Done is closed when the lease can no longer be renewed, either because it reached its max TTL,
+// the renewal failed or it was revoked. New credentials should be requested before ExpiresAt.
+func (s *DynamicSecret) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the lease could no longer be renewed, nil if it reached its max TTL or was revoked.
+func (s *DynamicSecret) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
+
+func (s *DynamicSecret) renewed(secret *api.Secret) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresAt = time.Now().Add(time.Duration(secret.LeaseDuration) * time.Second)
+}
+
+// LeaseManager requests dynamic secrets and keeps their leases renewed, revoking all of them on shutdown.
+type LeaseManager struct {
+	vc *VaultClient
+
+	mu      sync.Mutex
+	secrets map[string]*DynamicSecret
+}
+
+// NewLeaseManager creates a LeaseManager using the Vault client.
+func NewLeaseManager(vc *VaultClient) *LeaseManager {
+	return &LeaseManager{vc: vc, secrets: map[string]*DynamicSecret{}}
+}
+
+// Request reads a dynamic secret, such as database/creds/<role>, and keeps renewing its lease until
+// it reaches its max TTL or is revoked.
+func (m *LeaseManager) Request(ctx context.Context, path string) (*DynamicSecret, error) {
+	m.vc.logger.Infof("[Vault] Requesting the dynamic secret '%s' ☄️", path)
+
+	secret, err := m.vc.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		m.vc.logger.Errorf("[Vault] Unable to request the dynamic secret '%s' 😱. Err: %v", path, err)
+		return nil, wrapVaultError(err)
+	}
+	if secret == nil {
+		return nil, ErrSecretNotFound
+	}
+	if secret.LeaseID == "" {
+		return nil, errors.New("the secret doesn't have a lease")
+	}
+
+	// renewal errors are reported through Err, instead of waiting for the lease to expire. The leases that
+	// aren't renewable are watched until they are about to expire.
+	behavior := api.RenewBehaviorErrorOnErrors
+	if !secret.Renewable {
+		behavior = api.RenewBehaviorIgnoreErrors
+	}
+	watcher, err := m.vc.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret, RenewBehavior: behavior})
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &DynamicSecret{
+		path:    path,
+		watcher: watcher,
+		done:    make(chan struct{}),
+		secret:  secret,
+	}
+	ds.renewed(secret)
+
+	m.mu.Lock()
+	m.secrets[secret.LeaseID] = ds
+	m.mu.Unlock()
+
+	go watcher.Start()
+	go m.watch(ds)
+
+	return ds, nil
+}
+
+// Revoke stops renewing the lease of the secret and revokes it.
+func (m *LeaseManager) Revoke(ctx context.Context, secret *DynamicSecret) error {
+	m.mu.Lock()
+	delete(m.secrets, secret.LeaseID())
+	m.mu.Unlock()
+
+	secret.watcher.Stop()
+	return m.vc.RevokeLease(ctx, secret.LeaseID())
+}
+
+// RevokeAll revokes the leases of all the secrets still managed, to be called on shutdown.
+func (m *LeaseManager) RevokeAll(ctx context.Context) error {
+	m.mu.Lock()
+	secrets := make([]*DynamicSecret, 0, len(m.secrets))
+	for _, secret := range m.secrets {
+		secrets = append(secrets, secret)
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, secret := range secrets {
+		if err := m.Revoke(ctx, secret); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *LeaseManager) watch(ds *DynamicSecret) {
+	defer close(ds.done)
+
+	for {
+		select {
+		case err := <-ds.watcher.DoneCh():
+			if err != nil {
+				m.vc.logger.Errorf("[Vault] Unable to renew the lease of '%s' 😱. Err: %v", ds.path, err)
+			} else {
+				m.vc.logger.Infof("[Vault] The lease of '%s' can no longer be renewed ⌛", ds.path)
+			}
+			ds.mu.Lock()
+			ds.err = err
+			ds.mu.Unlock()
+
+			m.mu.Lock()
+			delete(m.secrets, ds.LeaseID())
+			m.mu.Unlock()
+			return
+		case renewal := <-ds.watcher.RenewCh():
+			m.vc.logger.Infof("[Vault] Renewed the lease of '%s' ☄️", ds.path)
+			ds.renewed(renewal.Secret)
+		}
+	}
+}
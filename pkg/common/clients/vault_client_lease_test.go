@@ -0,0 +1,135 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newLeaseTestClient(t *testing.T, leaseTTL time.Duration, renewable bool) (*vaulttest.Server, *VaultClient) {
+	server := vaulttest.NewServer()
+	t.Cleanup(server.Close)
+	server.Mount("database", vaulttest.Database)
+	server.Mount("kv", vaulttest.KVv1)
+	server.SetLeaseTTL(leaseTTL)
+	server.SetLeaseRenewable(renewable)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return server, vc
+}
+
+// leaseCalls returns the number of calls to the sys/leases endpoint of the operation for the lease.
+func leaseCalls(server *vaulttest.Server, operation, leaseID string) int {
+	count := 0
+	for _, call := range server.CallsTo(http.MethodPut, "sys/leases/"+operation) {
+		if call.Body["lease_id"] == leaseID {
+			count++
+		}
+	}
+	return count
+}
+
+func waitDone(t *testing.T, secret *DynamicSecret) {
+	select {
+	case <-secret.Done():
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "the lease is still renewed", secret.Path())
+	}
+}
+
+func TestLeaseManagerRenewsLeases(t *testing.T) {
+	server, vc := newLeaseTestClient(t, time.Minute, true)
+	manager := NewLeaseManager(vc)
+	ctx := context.Background()
+	require.NoError(t, vc.Put("kv/static", map[string]interface{}{"value": "static"}))
+
+	secret, err := manager.Request(ctx, "database/creds/app")
+	require.NoError(t, err)
+	assert.Equal(t, "database/creds/app", secret.Path())
+	assert.Equal(t, "database/creds/app/1", secret.LeaseID())
+	assert.Equal(t, map[string]interface{}{"username": "v-app-1", "password": "password-1"}, secret.Data())
+	assert.WithinDuration(t, time.Now().Add(time.Minute), secret.ExpiresAt(), 5*time.Second)
+
+	// the lease is renewed right away, then before it expires
+	assert.Eventually(t, func() bool {
+		return leaseCalls(server, "renew", secret.LeaseID()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case <-secret.Done():
+		assert.Fail(t, "the lease must still be renewed")
+	default:
+	}
+
+	_, err = manager.Request(ctx, "kv/static")
+	assert.Error(t, err)
+	_, err = manager.Request(ctx, "database/missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestLeaseManagerExpiry(t *testing.T) {
+	_, vc := newLeaseTestClient(t, time.Second, false)
+	manager := NewLeaseManager(vc)
+
+	// a lease that can't be renewed is done before it expires, without error
+	secret, err := manager.Request(context.Background(), "database/creds/app")
+	require.NoError(t, err)
+	waitDone(t, secret)
+	assert.NoError(t, secret.Err())
+	assert.True(t, time.Now().Before(secret.ExpiresAt()))
+
+	// it's no longer managed
+	require.NoError(t, manager.RevokeAll(context.Background()))
+}
+
+func TestLeaseManagerRenewalFailure(t *testing.T) {
+	server, vc := newLeaseTestClient(t, 2*time.Second, true)
+	server.FailOn(http.MethodPut, "sys/leases/renew", http.StatusBadRequest, "lease not found")
+	manager := NewLeaseManager(vc)
+
+	secret, err := manager.Request(context.Background(), "database/creds/app")
+	require.NoError(t, err)
+	waitDone(t, secret)
+	assert.Error(t, secret.Err())
+
+	require.NoError(t, manager.RevokeAll(context.Background()))
+	assert.Empty(t, server.CallsTo(http.MethodPut, "sys/leases/revoke"))
+}
+
+func TestLeaseManagerRevokeAll(t *testing.T) {
+	server, vc := newLeaseTestClient(t, time.Minute, true)
+	manager := NewLeaseManager(vc)
+	ctx := context.Background()
+
+	first, err := manager.Request(ctx, "database/creds/app")
+	require.NoError(t, err)
+	second, err := manager.Request(ctx, "database/creds/reporting")
+	require.NoError(t, err)
+	third, err := manager.Request(ctx, "database/creds/app")
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Revoke(ctx, first))
+	waitDone(t, first)
+	assert.NoError(t, first.Err())
+	assert.ElementsMatch(t, []string{second.LeaseID(), third.LeaseID()}, server.Leases())
+
+	// a failed revocation doesn't keep the others from being revoked
+	server.FailOnce(http.MethodPut, "sys/leases/revoke", http.StatusBadRequest, "invalid lease")
+	assert.Error(t, manager.RevokeAll(ctx))
+	waitDone(t, second)
+	waitDone(t, third)
+	assert.Len(t, server.Leases(), 1)
+	assert.Len(t, server.CallsTo(http.MethodPut, "sys/leases/revoke"), 3)
+
+	// the secrets are revoked only once
+	require.NoError(t, manager.RevokeAll(ctx))
+	assert.Len(t, server.CallsTo(http.MethodPut, "sys/leases/revoke"), 3)
+}
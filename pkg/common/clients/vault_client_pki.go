@@ -0,0 +1,191 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	certificateRetryMinBackoff = time.Second
+	certificateRetryMaxBackoff = time.Minute
+)
+
+// CertificateRequest holds the parameters of a certificate issued by a PKI role.
+type CertificateRequest struct {
+	CommonName string
+	AltNames   []string
+	IPSANs     []string
+	// TTL of the certificate, defaults to the TTL of the role when 0.
+	TTL time.Duration
+}
+
+// IssueCertificate issues a new certificate and private key from the role of the PKI engine mounted at mount.
+// The returned certificate includes the CA chain and its Leaf is set.
+func (vc *VaultClient) IssueCertificate(ctx context.Context, mount, role string, request CertificateRequest) (*tls.Certificate, error) {
+	vc.logger.Infof("[Vault] Issuing a certificate for '%s' from the role '%s' of '%s' ☄️", request.CommonName, role, mount)
+
+	data := map[string]interface{}{"common_name": request.CommonName}
+	if len(request.AltNames) > 0 {
+		data["alt_names"] = strings.Join(request.AltNames, ",")
+	}
+	if len(request.IPSANs) > 0 {
+		data["ip_sans"] = strings.Join(request.IPSANs, ",")
+	}
+	if request.TTL > 0 {
+		data["ttl"] = request.TTL.String()
+	}
+
+	path := fmt.Sprintf("%s/issue/%s", strings.Trim(mount, "/"), role)
+	secret, err := vc.client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to issue a certificate from '%s' 😱. Err: %v", path, err)
+		return nil, wrapVaultError(err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("empty response issuing a certificate from %s", path)
+	}
+
+	certificate, err := parseIssuedCertificate(secret.Data)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to parse the certificate issued from '%s' 😱. Err: %v", path, err)
+		return nil, err
+	}
+
+	vc.logger.Infof("[Vault] Issued a certificate for '%s' valid until %v 🔑", request.CommonName, certificate.Leaf.NotAfter)
+	return certificate, nil
+}
+
+func parseIssuedCertificate(data map[string]interface{}) (*tls.Certificate, error) {
+	certificatePEM, _ := data["certificate"].(string)
+	privateKeyPEM, _ := data["private_key"].(string)
+	if certificatePEM == "" || privateKeyPEM == "" {
+		return nil, errors.New("missing certificate or private key")
+	}
+
+	chain := []string{certificatePEM}
+	if caChain, ok := data["ca_chain"].([]interface{}); ok {
+		for _, ca := range caChain {
+			if pem, ok := ca.(string); ok {
+				chain = append(chain, pem)
+			}
+		}
+	} else if issuingCA, ok := data["issuing_ca"].(string); ok && issuingCA != "" {
+		chain = append(chain, issuingCA)
+	}
+
+	certificate, err := tls.X509KeyPair([]byte(strings.Join(chain, "\n")), []byte(privateKeyPEM))
+	if err != nil {
+		return nil, err
+	}
+	if certificate.Leaf == nil {
+		if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &certificate, nil
+}
+
+// CertificateRotator keeps a certificate issued by a PKI role valid, issuing a new one once two thirds
+// of its validity elapsed. Its TLS config can be given to server.NewServerWithTLSConfig or
+// http.NewPipelineWithTLSConfig, so the new certificates are used without a restart.
+type CertificateRotator struct {
+	vc      *VaultClient
+	mount   string
+	role    string
+	request CertificateRequest
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+}
+
+// NewCertificateRotator issues the first certificate from the role of the PKI engine mounted at mount.
+func NewCertificateRotator(ctx context.Context, vc *VaultClient, mount, role string,
+	request CertificateRequest) (*CertificateRotator, error) {
+
+	certificate, err := vc.IssueCertificate(ctx, mount, role, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateRotator{
+		vc:          vc,
+		mount:       mount,
+		role:        role,
+		request:     request,
+		certificate: certificate,
+	}, nil
+}
+
+// Certificate returns the current certificate.
+func (r *CertificateRotator) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.certificate
+}
+
+// GetCertificate returns the current certificate, to be used as tls.Config.GetCertificate of servers.
+func (r *CertificateRotator) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate returns the current certificate, to be used as tls.Config.GetClientCertificate of clients.
+func (r *CertificateRotator) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// TLSConfig returns a TLS config that always presents the current certificate, both as server and client.
+func (r *CertificateRotator) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetCertificate:       r.GetCertificate,
+		GetClientCertificate: r.GetClientCertificate,
+	}
+}
+
+// Run rotates the certificate until the context is done, retrying with backoff when it can't be issued.
+func (r *CertificateRotator) Run(ctx context.Context) {
+	backoff := certificateRetryMinBackoff
+	for {
+		timer := time.NewTimer(r.nextRotation())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		certificate, err := r.vc.IssueCertificate(ctx, r.mount, r.role, r.request)
+		if err != nil {
+			r.vc.logger.Errorf("[Vault] Unable to rotate the certificate of '%s', retrying in %v 😱. Err: %v",
+				r.request.CommonName, backoff, err)
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			backoff = min(backoff*2, certificateRetryMaxBackoff)
+			continue
+		}
+
+		backoff = certificateRetryMinBackoff
+		r.mu.Lock()
+		r.certificate = certificate
+		r.mu.Unlock()
+	}
+}
+
+// nextRotation returns how long until two thirds of the validity of the current certificate elapsed.
+func (r *CertificateRotator) nextRotation() time.Duration {
+	leaf := r.Certificate().Leaf
+	validity := leaf.NotAfter.Sub(leaf.NotBefore)
+	return max(time.Until(leaf.NotBefore.Add(validity*2/3)), 0)
+}
@@ -0,0 +1,73 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newPKITestClient(t *testing.T) (*vaulttest.Server, *VaultClient) {
+	server := vaulttest.NewServer()
+	t.Cleanup(server.Close)
+	server.Mount("pki", vaulttest.PKI)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return server, vc
+}
+
+func TestCertificateRotator(t *testing.T) {
+	_, vc := newPKITestClient(t)
+
+	rotator, err := NewCertificateRotator(context.Background(), vc, "pki", "web",
+		CertificateRequest{CommonName: "app.local", IPSANs: []string{"127.0.0.1"}, TTL: time.Hour})
+	require.NoError(t, err)
+
+	certificate, err := rotator.TLSConfig().GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "app.local", certificate.Leaf.Subject.CommonName)
+	assert.Equal(t, "127.0.0.1", certificate.Leaf.IPAddresses[0].String())
+	// the CA chain is included
+	assert.Len(t, certificate.Certificate, 2)
+	// two thirds of the hour of validity
+	assert.InDelta(t, (40 * time.Minute).Seconds(), rotator.nextRotation().Seconds(), 5)
+}
+
+func TestCertificateRotatorRun(t *testing.T) {
+	server, vc := newPKITestClient(t)
+
+	rotator, err := NewCertificateRotator(context.Background(), vc, "pki", "web",
+		CertificateRequest{CommonName: "app.local", TTL: 3 * time.Second})
+	require.NoError(t, err)
+	first := rotator.Certificate()
+	assert.Equal(t, int64(1), first.Leaf.SerialNumber.Int64())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rotator.Run(ctx)
+	}()
+
+	// a new certificate is issued once two thirds of the 3 seconds of validity elapsed
+	assert.Eventually(t, func() bool {
+		return rotator.Certificate().Leaf.SerialNumber.Int64() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, time.Now().Before(first.Leaf.NotBefore.Add(2*time.Second)))
+
+	certificate, err := rotator.TLSConfig().GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, rotator.Certificate(), certificate)
+
+	cancel()
+	<-done
+	assert.LessOrEqual(t, len(server.CallsTo(http.MethodPut, "pki/issue/web")), 3)
+}
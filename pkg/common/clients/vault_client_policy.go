@@ -0,0 +1,214 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const policyValueFunc = "policyValue"
+
+// policyValuePattern matches the values that can be rendered in a policy template, so they can't
+// close the quoted path or add wildcards or rules to the policy.
+var policyValuePattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// Policy templates for PutPolicyFromTemplate, that grant access to the secrets of a KV v2 engine.
+// They expect a data with the Mount of the engine and the Path of the secrets, e.g. tenants/acme.
+const (
+	KVReadPolicyTemplate = `path "{{ .Mount }}/data/{{ .Path }}/*" {
+  capabilities = ["read"]
+}
+
+path "{{ .Mount }}/metadata/{{ .Path }}/*" {
+  capabilities = ["read", "list"]
+}
+`
+	KVReadWritePolicyTemplate = `path "{{ .Mount }}/data/{{ .Path }}/*" {
+  capabilities = ["create", "read", "update", "patch", "delete"]
+}
+
+path "{{ .Mount }}/metadata/{{ .Path }}/*" {
+  capabilities = ["read", "list", "delete"]
+}
+
+path "{{ .Mount }}/delete/{{ .Path }}/*" {
+  capabilities = ["update"]
+}
+
+path "{{ .Mount }}/undelete/{{ .Path }}/*" {
+  capabilities = ["update"]
+}
+`
+)
+
+// PutPolicy creates or replaces the ACL policy with the rules, written in the HCL policy syntax.
+func (vc *VaultClient) PutPolicy(ctx context.Context, name, rules string) error {
+	vc.logger.Infof("[Vault] Writing the policy '%s' ☄️", name)
+
+	if err := vc.client.Sys().PutPolicyWithContext(ctx, name, rules); err != nil {
+		vc.logger.Errorf("[Vault] Unable to write the policy '%s' 😱. Err: %v", name, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// PutPolicyFromTemplate creates or replaces the ACL policy with the rules rendered from the text/template
+// with the data. Missing keys of the data are an error, and so are the rendered values with characters
+// other than letters, digits, '_', '.', '/' and '-', so the data can't inject rules in the policy.
+func (vc *VaultClient) PutPolicyFromTemplate(ctx context.Context, name, text string, data interface{}) error {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{policyValueFunc: policyValue}).
+		Parse(text)
+	if err != nil {
+		return fmt.Errorf("unable to parse the template of the policy '%s': %w", name, err)
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			validatePolicyValues(t.Tree.Root)
+		}
+	}
+
+	var rules strings.Builder
+	if err := tmpl.Execute(&rules, data); err != nil {
+		return fmt.Errorf("unable to render the template of the policy '%s': %w", name, err)
+	}
+
+	return vc.PutPolicy(ctx, name, rules.String())
+}
+
+// validatePolicyValues pipes every value rendered by the template into policyValue.
+func validatePolicyValues(node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			validatePolicyValues(child)
+		}
+	case *parse.ActionNode:
+		// declarations, e.g. {{ $path := .Path }}, don't render anything
+		if len(node.Pipe.Decl) > 0 {
+			return
+		}
+		node.Pipe.Cmds = append(node.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      node.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(policyValueFunc).SetPos(node.Pos)},
+		})
+	case *parse.IfNode:
+		validatePolicyValues(node.List)
+		validatePolicyValues(node.ElseList)
+	case *parse.RangeNode:
+		validatePolicyValues(node.List)
+		validatePolicyValues(node.ElseList)
+	case *parse.WithNode:
+		validatePolicyValues(node.List)
+		validatePolicyValues(node.ElseList)
+	}
+}
+
+func policyValue(value interface{}) (string, error) {
+	s := fmt.Sprint(value)
+	if !policyValuePattern.MatchString(s) {
+		return "", fmt.Errorf("invalid value %q in a policy", s)
+	}
+	return s, nil
+}
+
+// GetPolicy returns the rules of the ACL policy, or ErrSecretNotFound if it doesn't exist.
+func (vc *VaultClient) GetPolicy(ctx context.Context, name string) (string, error) {
+	vc.logger.Infof("[Vault] Reading the policy '%s' ☄️", name)
+
+	rules, err := vc.client.Sys().GetPolicyWithContext(ctx, name)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to read the policy '%s' 😱. Err: %v", name, err)
+		return "", wrapVaultError(err)
+	}
+	if rules == "" {
+		return "", fmt.Errorf("%w: policy '%s'", ErrSecretNotFound, name)
+	}
+
+	return rules, nil
+}
+
+// DeletePolicy deletes the ACL policy. Deleting a policy that doesn't exist is not an error.
+func (vc *VaultClient) DeletePolicy(ctx context.Context, name string) error {
+	vc.logger.Infof("[Vault] Deleting the policy '%s' ☠️", name)
+
+	if err := vc.client.Sys().DeletePolicyWithContext(ctx, name); err != nil {
+		vc.logger.Errorf("[Vault] Unable to delete the policy '%s' 😱. Err: %v", name, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// K8sAuthRole is a role of the kubernetes auth method, that grants the policies to the service accounts bound to it.
+type K8sAuthRole struct {
+	Name string
+	// ServiceAccountNames and ServiceAccountNamespaces bound to the role, "*" allows all of them.
+	ServiceAccountNames      []string
+	ServiceAccountNamespaces []string
+	Policies                 []string
+	// Audience, if set, must be in the audience claim of the service account token.
+	Audience string
+	// TTL and MaxTTL of the issued tokens, default to the ones of the auth method when 0.
+	TTL    time.Duration
+	MaxTTL time.Duration
+}
+
+// PutK8sAuthRole creates or replaces a role of the kubernetes auth method mounted at mount,
+// defaulting to kubernetes when empty.
+func (vc *VaultClient) PutK8sAuthRole(ctx context.Context, mount string, role K8sAuthRole) error {
+	if mount == "" {
+		mount = defaultK8sAuthMount
+	}
+	vc.logger.Infof("[Vault] Writing the role '%s' of 'auth/%s' ☄️", role.Name, mount)
+
+	data := map[string]interface{}{
+		"bound_service_account_names":      role.ServiceAccountNames,
+		"bound_service_account_namespaces": role.ServiceAccountNamespaces,
+		"token_policies":                   role.Policies,
+	}
+	if role.Audience != "" {
+		data["audience"] = role.Audience
+	}
+	if ttl := formatTTL(role.TTL); ttl != "" {
+		data["token_ttl"] = ttl
+	}
+	if ttl := formatTTL(role.MaxTTL); ttl != "" {
+		data["token_max_ttl"] = ttl
+	}
+
+	path := fmt.Sprintf("auth/%s/role/%s", mount, role.Name)
+	if _, err := vc.client.Logical().WriteWithContext(ctx, path, data); err != nil {
+		vc.logger.Errorf("[Vault] Unable to write the role '%s' of 'auth/%s' 😱. Err: %v", role.Name, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
+
+// DeleteK8sAuthRole deletes a role of the kubernetes auth method mounted at mount,
+// defaulting to kubernetes when empty.
+func (vc *VaultClient) DeleteK8sAuthRole(ctx context.Context, mount, name string) error {
+	if mount == "" {
+		mount = defaultK8sAuthMount
+	}
+	vc.logger.Infof("[Vault] Deleting the role '%s' of 'auth/%s' ☠️", name, mount)
+
+	path := fmt.Sprintf("auth/%s/role/%s", mount, name)
+	if _, err := vc.client.Logical().DeleteWithContext(ctx, path); err != nil {
+		vc.logger.Errorf("[Vault] Unable to delete the role '%s' of 'auth/%s' 😱. Err: %v", name, mount, err)
+		return wrapVaultError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/kelseyhightower/envconfig"
+)
+
+const secretChangesBuffer = 64
+
+// SecretWatcherConfiguration represents the configuration of a SecretWatcher.
+type SecretWatcherConfiguration struct {
+	// Interval between polls of the watched secrets.
+	Interval time.Duration `envconfig:"VAULT_SECRET_WATCHER_INTERVAL" default:"30s"`
+	// CacheTTL is how long a cached secret is served before it is read again from Vault.
+	CacheTTL time.Duration `envconfig:"VAULT_SECRET_WATCHER_CACHE_TTL" default:"5m"`
+	// MaxStaleness is how long an expired secret keeps being served while Vault is unavailable.
+	MaxStaleness time.Duration `envconfig:"VAULT_SECRET_WATCHER_MAX_STALENESS" default:"1h"`
+}
+
+// LoadFromEnvVars for SecretWatcherConfiguration.
+func (c *SecretWatcherConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
+
+// SecretChange describes a change of a watched secret. Versions are only set for KV v2 secrets.
+type SecretChange struct {
+	Path       string
+	OldVersion int
+	NewVersion int
+	OldData    map[string]interface{}
+	NewData    map[string]interface{}
+	// Deleted is set when the secret, or its latest version, was deleted.
+	Deleted bool
+}
+
+type cachedSecret struct {
+	// mount is only set for KV v2 secrets.
+	mount     string
+	path      string
+	watched   bool
+	data      map[string]interface{}
+	version   int
+	fetchedAt time.Time
+}
+
+// SecretWatcher caches secrets read from Vault and polls the watched ones, notifying their changes.
+// When Vault is unavailable, the cached secrets keep being served up to MaxStaleness.
+type SecretWatcher struct {
+	vc     *VaultClient
+	config SecretWatcherConfiguration
+	events chan SecretChange
+
+	mu        sync.RWMutex
+	secrets   map[string]*cachedSecret
+	callbacks []func(SecretChange)
+}
+
+// NewSecretWatcher creates a SecretWatcher using the Vault client.
+func NewSecretWatcher(vc *VaultClient, config SecretWatcherConfiguration) *SecretWatcher {
+	return &SecretWatcher{
+		vc:      vc,
+		config:  config,
+		events:  make(chan SecretChange, secretChangesBuffer),
+		secrets: map[string]*cachedSecret{},
+	}
+}
+
+// Watch polls the secret in the path, as read by VaultClient.Get.
+func (w *SecretWatcher) Watch(path string) {
+	w.watch("", path)
+}
+
+// WatchKV polls the metadata of a secret of the KV v2 secrets engine mounted at mount,
+// reading the secret only when a new version is written.
+func (w *SecretWatcher) WatchKV(mount, path string) {
+	w.watch(mount, path)
+}
+
+func (w *SecretWatcher) watch(mount, path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := secretKey(mount, path)
+	if secret, ok := w.secrets[key]; ok {
+		secret.watched = true
+		return
+	}
+	w.secrets[key] = &cachedSecret{mount: mount, path: path, watched: true}
+}
+
+// OnChange registers a callback called on every change of a watched secret.
+func (w *SecretWatcher) OnChange(callback func(SecretChange)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, callback)
+}
+
+// Events returns the channel where the changes of the watched secrets are sent.
+// Changes are dropped when the channel is full.
+func (w *SecretWatcher) Events() <-chan SecretChange {
+	return w.events
+}
+
+// Get returns the data of the secret in the path, from the cache while it's fresh.
+func (w *SecretWatcher) Get(ctx context.Context, path string) (map[string]interface{}, error) {
+	return w.get(ctx, "", path)
+}
+
+// GetKV returns the data of the latest version of a secret of the KV v2 secrets engine mounted at mount,
+// from the cache while it's fresh.
+func (w *SecretWatcher) GetKV(ctx context.Context, mount, path string) (map[string]interface{}, error) {
+	return w.get(ctx, mount, path)
+}
+
+func (w *SecretWatcher) get(ctx context.Context, mount, path string) (map[string]interface{}, error) {
+	key := secretKey(mount, path)
+
+	w.mu.RLock()
+	cached, ok := w.secrets[key]
+	var snapshot cachedSecret
+	if ok {
+		snapshot = *cached
+	}
+	w.mu.RUnlock()
+
+	if ok && !snapshot.fetchedAt.IsZero() && time.Since(snapshot.fetchedAt) < w.config.CacheTTL {
+		return copySecretData(snapshot.data), nil
+	}
+
+	data, version, err := w.fetch(ctx, mount, path)
+	if err != nil {
+		if ok && !snapshot.fetchedAt.IsZero() && !errors.Is(err, ErrSecretNotFound) &&
+			time.Since(snapshot.fetchedAt) < w.config.CacheTTL+w.config.MaxStaleness {
+			w.vc.logger.Warnf("[Vault] Serving the cached '%s' fetched at %v. Err: %v", key, snapshot.fetchedAt, err)
+			return copySecretData(snapshot.data), nil
+		}
+		return nil, err
+	}
+
+	w.store(key, mount, path, data, version)
+	return copySecretData(data), nil
+}
+
+// Run polls the watched secrets every interval until the context is done.
+func (w *SecretWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *SecretWatcher) poll(ctx context.Context) {
+	w.mu.RLock()
+	var watched []cachedSecret
+	for _, secret := range w.secrets {
+		if secret.watched {
+			watched = append(watched, *secret)
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, secret := range watched {
+		if ctx.Err() != nil {
+			return
+		}
+		w.refresh(ctx, secret)
+	}
+}
+
+// refresh reads the secret again and notifies its change, if any.
+func (w *SecretWatcher) refresh(ctx context.Context, old cachedSecret) {
+	key := secretKey(old.mount, old.path)
+
+	if old.mount != "" && !old.fetchedAt.IsZero() {
+		// only the metadata is read while the version of a KV v2 secret doesn't change
+		metadata, err := w.vc.KVGetMetadata(ctx, old.mount, old.path)
+		if err == nil && metadata.CurrentVersion == old.version {
+			deleted := isCurrentVersionDeleted(metadata.Versions, metadata.CurrentVersion)
+			switch {
+			case deleted && old.data != nil:
+				w.store(key, old.mount, old.path, nil, old.version)
+				w.notify(SecretChange{
+					Path:       key,
+					OldVersion: old.version,
+					NewVersion: old.version,
+					OldData:    old.data,
+					Deleted:    true,
+				})
+				return
+			case deleted && old.data == nil, !deleted && old.data != nil:
+				w.touch(key)
+				return
+			}
+		}
+	}
+
+	data, version, err := w.fetch(ctx, old.mount, old.path)
+	deleted := errors.Is(err, ErrSecretNotFound)
+	if err != nil && !deleted {
+		w.vc.logger.Warnf("[Vault] Unable to refresh the watched '%s', serving the cached one. Err: %v", key, err)
+		return
+	}
+	if deleted {
+		data, version = nil, 0
+	}
+
+	w.store(key, old.mount, old.path, data, version)
+
+	// the first read isn't a change
+	if old.fetchedAt.IsZero() || (version == old.version && reflect.DeepEqual(data, old.data)) {
+		return
+	}
+	w.notify(SecretChange{
+		Path:       key,
+		OldVersion: old.version,
+		NewVersion: version,
+		OldData:    old.data,
+		NewData:    data,
+		Deleted:    data == nil,
+	})
+}
+
+// fetch reads the secret from Vault, returning its version for KV v2 secrets.
+func (w *SecretWatcher) fetch(ctx context.Context, mount, path string) (map[string]interface{}, int, error) {
+	if mount == "" {
+		data, err := w.vc.GetWithContext(ctx, path)
+		return data, 0, err
+	}
+
+	secret, err := w.vc.KVGet(ctx, mount, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	version := 0
+	if secret.VersionMetadata != nil {
+		version = secret.VersionMetadata.Version
+	}
+	return secret.Data, version, nil
+}
+
+func (w *SecretWatcher) store(key, mount, path string, data map[string]interface{}, version int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	secret, ok := w.secrets[key]
+	if !ok {
+		secret = &cachedSecret{mount: mount, path: path}
+		w.secrets[key] = secret
+	}
+	secret.data = data
+	secret.version = version
+	secret.fetchedAt = time.Now()
+}
+
+func (w *SecretWatcher) touch(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if secret, ok := w.secrets[key]; ok {
+		secret.fetchedAt = time.Now()
+	}
+}
+
+// notify delivers a copy of the change to every callback and to the events channel, so they can't modify the cache.
+func (w *SecretWatcher) notify(change SecretChange) {
+	w.vc.logger.Infof("[Vault] The watched '%s' changed from version %d to %d ☄️",
+		change.Path, change.OldVersion, change.NewVersion)
+
+	w.mu.RLock()
+	callbacks := w.callbacks
+	w.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		callback(change.copy())
+	}
+
+	select {
+	case w.events <- change.copy():
+	default:
+	}
+}
+
+func (c SecretChange) copy() SecretChange {
+	c.OldData = copySecretData(c.OldData)
+	c.NewData = copySecretData(c.NewData)
+	return c
+}
+
+// isCurrentVersionDeleted tells whether the current version of a KV v2 secret was soft deleted or destroyed.
+// A deletion time in the future is only scheduled by delete_version_after.
+func isCurrentVersionDeleted(versions map[string]api.KVVersionMetadata, current int) bool {
+	version, ok := versions[strconv.Itoa(current)]
+	if !ok {
+		return false
+	}
+	return version.Destroyed || (!version.DeletionTime.IsZero() && !version.DeletionTime.After(time.Now()))
+}
+
+// copySecretData deep copies the maps and slices of the data of a secret, as decoded from JSON.
+func copySecretData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		copied[key] = copySecretValue(value)
+	}
+	return copied
+}
+
+func copySecretValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return copySecretData(value)
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copySecretValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func secretKey(mount, path string) string {
+	if mount == "" {
+		return path
+	}
+	return fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(path, "/"))
+}
@@ -0,0 +1,104 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestSecretWatcher(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("secret", vaulttest.KVv1)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	defer vc.Close()
+	// don't retry the failed requests
+	vc.client.SetMaxRetries(0)
+	require.NoError(t, vc.Put("secret/app", map[string]interface{}{"password": "first"}))
+
+	watcher := NewSecretWatcher(vc, SecretWatcherConfiguration{Interval: time.Hour, MaxStaleness: time.Minute})
+	var changes []SecretChange
+	watcher.OnChange(func(change SecretChange) { changes = append(changes, change) })
+	watcher.Watch("secret/app")
+
+	ctx := context.Background()
+	watcher.poll(ctx)
+	assert.Empty(t, changes)
+
+	require.NoError(t, vc.Put("secret/app", map[string]interface{}{"password": "second"}))
+	watcher.poll(ctx)
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, "secret/app", changes[0].Path)
+	assert.Equal(t, map[string]interface{}{"password": "first"}, changes[0].OldData)
+	assert.Equal(t, map[string]interface{}{"password": "second"}, changes[0].NewData)
+	assert.Equal(t, changes[0], <-watcher.Events())
+
+	server.FailOn(http.MethodGet, "secret/*", http.StatusInternalServerError)
+
+	data, err := watcher.Get(ctx, "secret/app")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "second"}, data)
+
+	_, err = watcher.Get(ctx, "secret/other")
+	assert.Error(t, err)
+}
+
+func TestSecretWatcherKVDeletion(t *testing.T) {
+	_, vc := newKVTestClient(t)
+	ctx := context.Background()
+	_, err := vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": "first"})
+	require.NoError(t, err)
+
+	watcher := NewSecretWatcher(vc, SecretWatcherConfiguration{Interval: time.Hour, CacheTTL: time.Hour})
+	var changes []SecretChange
+	watcher.OnChange(func(change SecretChange) { changes = append(changes, change) })
+	watcher.WatchKV("secret", "apps/web")
+	watcher.poll(ctx)
+	assert.Empty(t, changes)
+
+	// soft deleting the current version keeps it as the current version in the metadata
+	require.NoError(t, vc.KVDelete(ctx, "secret", "apps/web", 1))
+	watcher.poll(ctx)
+	require.Len(t, changes, 1)
+	assert.Equal(t, SecretChange{
+		Path:       "secret/data/apps/web",
+		OldVersion: 1,
+		NewVersion: 1,
+		OldData:    map[string]interface{}{"password": "first"},
+		Deleted:    true,
+	}, changes[0])
+	watcher.poll(ctx)
+	assert.Len(t, changes, 1)
+
+	require.NoError(t, vc.KVUndelete(ctx, "secret", "apps/web", 1))
+	watcher.poll(ctx)
+	require.Len(t, changes, 2)
+	assert.False(t, changes[1].Deleted)
+	assert.Equal(t, map[string]interface{}{"password": "first"}, changes[1].NewData)
+
+	// the delivered data is a copy of the cached one
+	changes[1].NewData["password"] = "changed"
+	data, err := watcher.GetKV(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "first"}, data)
+	data["password"] = "changed"
+	data, err = watcher.GetKV(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "first"}, data)
+
+	require.NoError(t, vc.KVDestroy(ctx, "secret", "apps/web", 1))
+	watcher.poll(ctx)
+	require.Len(t, changes, 3)
+	assert.True(t, changes[2].Deleted)
+	assert.Equal(t, map[string]interface{}{"password": "first"}, changes[2].OldData)
+}
@@ -0,0 +1,264 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	tokenLoginMinBackoff = time.Second
+	tokenLoginMaxBackoff = time.Minute
+	tokenEventsBuffer    = 16
+)
+
+var (
+	vaultTokenRenewals = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_token_renewals_total",
+			Help: "Number of Vault token renewals, by result.",
+		},
+		[]string{"result"},
+	)
+	vaultTokenLogins = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vault_token_logins_total",
+			Help: "Number of Vault logins, by result.",
+		},
+		[]string{"result"},
+	)
+	vaultTokenExpiry = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vault_token_expiry_timestamp_seconds",
+			Help: "Unix time when the current Vault token expires, 0 if it doesn't expire.",
+		},
+	)
+	registerTokenMetrics sync.Once
+)
+
+// TokenEventType identifies what happened to the Vault token.
+type TokenEventType string
+
+// Vault token events emitted by the TokenManager.
+const (
+	TokenRenewed     TokenEventType = "renewed"
+	TokenRenewFailed TokenEventType = "renew_failed"
+	TokenExpiring    TokenEventType = "expiring"
+	TokenLoggedIn    TokenEventType = "logged_in"
+	TokenLoginFailed TokenEventType = "login_failed"
+)
+
+const (
+	tokenResultOK     = "success"
+	tokenResultFailed = "failure"
+)
+
+// TokenEvent is emitted by the TokenManager on every token renewal and login.
+type TokenEvent struct {
+	Type TokenEventType
+	Time time.Time
+	// TTL is the remaining time to live of the token, 0 if it doesn't expire or on failures.
+	TTL time.Duration
+	Err error
+}
+
+// tokenWatcher is implemented by the authenticators which token is updated from outside of Vault.
+type tokenWatcher interface {
+	watch(ctx context.Context, vc *VaultClient, onChange func(err error))
+}
+
+// TokenManager keeps the token of a VaultClient valid. Renewable tokens are renewed before they expire,
+// and the client logs in again with the authenticator when they can no longer be renewed,
+// backing off while the login fails.
+type TokenManager struct {
+	vc            *VaultClient
+	authenticator Authenticator
+	events        chan TokenEvent
+
+	mu        sync.RWMutex
+	expiresAt time.Time
+	lastErr   error
+}
+
+// NewTokenManager creates a TokenManager for a client already authenticated with the authenticator,
+// and registers its metrics in the default Prometheus registry.
+func NewTokenManager(vc *VaultClient, authenticator Authenticator) *TokenManager {
+	registerTokenMetrics.Do(func() {
+		prometheus.MustRegister(vaultTokenRenewals, vaultTokenLogins, vaultTokenExpiry)
+	})
+
+	m := &TokenManager{
+		vc:            vc,
+		authenticator: authenticator,
+		events:        make(chan TokenEvent, tokenEventsBuffer),
+	}
+	m.setTTL(secretTTL(vc.secret), nil)
+	return m
+}
+
+// Events returns the channel where the token events are sent. Events are dropped when the channel is full.
+func (m *TokenManager) Events() <-chan TokenEvent {
+	return m.events
+}
+
+// Health returns an error if the token expired and the client was unable to log in again, or if the token
+// file can't be read when the token is read from a file.
+func (m *TokenManager) Health() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	switch {
+	case m.lastErr == nil:
+		return nil
+	case m.expiresAt.IsZero():
+		return fmt.Errorf("vault token unavailable: %w", m.lastErr)
+	case time.Now().After(m.expiresAt):
+		return fmt.Errorf("vault token expired at %v: %w", m.expiresAt, m.lastErr)
+	}
+	return nil
+}
+
+// Ready reports whether the token is healthy, to be used as readiness probe (see server.Server.AddReadyz).
+func (m *TokenManager) Ready() bool {
+	return m.Health() == nil
+}
+
+// Run manages the token until the context is done.
+func (m *TokenManager) Run(ctx context.Context) {
+	if w, ok := m.authenticator.(tokenWatcher); ok {
+		w.watch(ctx, m.vc, func(err error) {
+			m.setError(err)
+			if err != nil {
+				m.emit(TokenLoginFailed, 0, err)
+				return
+			}
+			m.emit(TokenLoggedIn, 0, nil)
+		})
+		return
+	}
+
+	for {
+		secret := m.vc.secret
+		if secretTTL(secret) == 0 {
+			m.vc.logger.Info("vault token doesn't expire, no need to renew it.")
+			return
+		}
+
+		m.watch(ctx, secret)
+		if ctx.Err() != nil {
+			return
+		}
+		if !m.login(ctx) {
+			return
+		}
+	}
+}
+
+// watch renews the token until it can no longer be renewed or the context is done.
+func (m *TokenManager) watch(ctx context.Context, secret *api.Secret) {
+	watcher, err := m.vc.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		m.vc.logger.Errorf("unable to watch the vault token %v 😱", err)
+		return
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				vaultTokenRenewals.WithLabelValues(tokenResultFailed).Inc()
+				m.vc.logger.Errorf("unable to renew the access token %v 😱", err)
+				m.emit(TokenRenewFailed, 0, err)
+				return
+			}
+			// the token reached its max TTL, or isn't renewable
+			m.vc.logger.Info("vault token is about to expire, logging in again.")
+			m.emit(TokenExpiring, time.Until(m.expiry()), nil)
+			return
+		case renewal := <-watcher.RenewCh():
+			ttl := secretTTL(renewal.Secret)
+			vaultTokenRenewals.WithLabelValues(tokenResultOK).Inc()
+			m.vc.logger.Info("renew: client token renewed successfully 🔑")
+			m.setTTL(ttl, nil)
+			m.emit(TokenRenewed, ttl, nil)
+		}
+	}
+}
+
+// login retries to log in until it succeeds or the context is done.
+func (m *TokenManager) login(ctx context.Context) bool {
+	backoff := tokenLoginMinBackoff
+	for {
+		err := m.authenticator.Authenticate(m.vc)
+		if err == nil {
+			ttl := secretTTL(m.vc.secret)
+			vaultTokenLogins.WithLabelValues(tokenResultOK).Inc()
+			m.setTTL(ttl, nil)
+			m.emit(TokenLoggedIn, ttl, nil)
+			return true
+		}
+
+		vaultTokenLogins.WithLabelValues(tokenResultFailed).Inc()
+		m.vc.logger.Errorf("unable to log in again, retrying in %v %v 😱", backoff, err)
+		m.setError(err)
+		m.emit(TokenLoginFailed, 0, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, tokenLoginMaxBackoff)
+	}
+}
+
+func (m *TokenManager) setTTL(ttl time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastErr = err
+	if ttl == 0 {
+		m.expiresAt = time.Time{}
+		vaultTokenExpiry.Set(0)
+		return
+	}
+	m.expiresAt = time.Now().Add(ttl)
+	vaultTokenExpiry.Set(float64(m.expiresAt.Unix()))
+}
+
+func (m *TokenManager) setError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastErr = err
+}
+
+func (m *TokenManager) expiry() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.expiresAt
+}
+
+func (m *TokenManager) emit(eventType TokenEventType, ttl time.Duration, err error) {
+	select {
+	case m.events <- TokenEvent{Type: eventType, Time: time.Now(), TTL: ttl, Err: err}:
+	default:
+	}
+}
+
+// secretTTL returns the time to live of the token of a login secret, 0 if it doesn't expire.
+func secretTTL(secret *api.Secret) time.Duration {
+	if secret == nil || secret.Auth == nil {
+		return 0
+	}
+	return time.Duration(secret.Auth.LeaseDuration) * time.Second
+}
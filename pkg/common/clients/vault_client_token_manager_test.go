@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func TestTokenManagerLogsInAgainWhenTokenExpires(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.EnableAuth("jwt", vaulttest.AuthJWT)
+	server.SetTokenTTL(time.Second)
+	server.SetTokenRenewable(false)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "role", NewJWTAuthenticator("", "jwt", ""))
+	require.NoError(t, err)
+	defer vc.Close()
+
+	events := vc.TokenManager().Events()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received []TokenEventType
+	for len(received) < 2 {
+		select {
+		case event := <-events:
+			received = append(received, event.Type)
+		case <-ctx.Done():
+			t.Fatalf("missing token events, received %v", received)
+		}
+	}
+
+	assert.Equal(t, []TokenEventType{TokenExpiring, TokenLoggedIn}, received)
+	assert.GreaterOrEqual(t, len(server.CallsTo("*", "auth/jwt/login")), 2)
+	assert.True(t, vc.TokenManager().Ready())
+}
+
+func TestTokenManagerWatchesTokenFile(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("secret", vaulttest.KVv1)
+	first, second := server.IssueToken(), server.IssueToken()
+	tokenPath := writeFile(t, "token", first)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewTokenFileAuthenticator(tokenPath, 10*time.Millisecond))
+	require.NoError(t, err)
+	defer vc.Close()
+	manager := vc.TokenManager()
+	assert.True(t, manager.Ready())
+
+	// the file is read again every interval, so failures may be repeated
+	waitEvent := func(expected TokenEventType) TokenEvent {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case event := <-manager.Events():
+				if event.Type == expected {
+					return event
+				}
+			case <-timeout:
+				require.FailNow(t, "missing token event", expected)
+				return TokenEvent{}
+			}
+		}
+	}
+
+	// a failure to read the file makes the manager unhealthy, until the file can be read again
+	require.NoError(t, os.Remove(tokenPath))
+	event := waitEvent(TokenLoginFailed)
+	assert.Error(t, event.Err)
+	assert.Error(t, manager.Health())
+	assert.False(t, manager.Ready())
+
+	require.NoError(t, os.WriteFile(tokenPath, []byte(first), 0o600))
+	waitEvent(TokenLoggedIn)
+	assert.NoError(t, manager.Health())
+
+	require.NoError(t, os.WriteFile(tokenPath, []byte(second), 0o600))
+	waitEvent(TokenLoggedIn)
+	assert.True(t, manager.Ready())
+	require.NoError(t, vc.Put("secret/app", map[string]interface{}{"a": "1"}))
+	calls := server.CallsTo(http.MethodPut, "secret/app")
+	require.Len(t, calls, 1)
+	assert.Equal(t, second, calls[0].Token)
+}
@@ -0,0 +1,267 @@
+package clients
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// TransitItem is an item of a Transit batch operation. Context is only required for keys with derivation enabled.
+type TransitItem struct {
+	Plaintext  []byte
+	Ciphertext string
+	Context    []byte
+	// Err is set when the operation failed for this item only.
+	Err error
+}
+
+// TransitEncrypt encrypts the plaintext with the key of the Transit engine mounted at mount, and returns
+// the ciphertext. The derivation context is only required for keys with derivation enabled.
+func (vc *VaultClient) TransitEncrypt(ctx context.Context, mount, key string, plaintext, derivation []byte) (string, error) {
+	items, err := vc.TransitEncryptBatch(ctx, mount, key, []TransitItem{{Plaintext: plaintext, Context: derivation}})
+	if err != nil {
+		return "", err
+	}
+	return items[0].Ciphertext, items[0].Err
+}
+
+// TransitDecrypt decrypts the ciphertext with the key of the Transit engine mounted at mount, and returns
+// the plaintext. The derivation context must be the one used to encrypt.
+func (vc *VaultClient) TransitDecrypt(ctx context.Context, mount, key, ciphertext string, derivation []byte) ([]byte, error) {
+	items, err := vc.TransitDecryptBatch(ctx, mount, key, []TransitItem{{Ciphertext: ciphertext, Context: derivation}})
+	if err != nil {
+		return nil, err
+	}
+	return items[0].Plaintext, items[0].Err
+}
+
+// TransitRewrap encrypts again the ciphertext with the latest version of the key, without exposing the plaintext.
+// Should be used after the key is rotated.
+func (vc *VaultClient) TransitRewrap(ctx context.Context, mount, key, ciphertext string, derivation []byte) (string, error) {
+	items, err := vc.TransitRewrapBatch(ctx, mount, key, []TransitItem{{Ciphertext: ciphertext, Context: derivation}})
+	if err != nil {
+		return "", err
+	}
+	return items[0].Ciphertext, items[0].Err
+}
+
+// TransitEncryptBatch encrypts the plaintext of every item in a single request, setting their ciphertext.
+func (vc *VaultClient) TransitEncryptBatch(ctx context.Context, mount, key string, items []TransitItem) ([]TransitItem, error) {
+	vc.logger.Infof("[Vault] Encrypting %d items with the transit key '%s' of '%s' ☄️", len(items), key, mount)
+
+	input := make([]interface{}, len(items))
+	for i, item := range items {
+		input[i] = transitBatchInput(item, map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(item.Plaintext),
+		})
+	}
+
+	results, err := vc.transitBatch(ctx, transitPath(mount, "encrypt", key, ""), input)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to encrypt with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return nil, err
+	}
+
+	encrypted := make([]TransitItem, len(items))
+	for i, item := range items {
+		encrypted[i] = item
+		encrypted[i].Ciphertext, encrypted[i].Err = transitResult(results[i], "ciphertext")
+	}
+	return encrypted, nil
+}
+
+// TransitDecryptBatch decrypts the ciphertext of every item in a single request, setting their plaintext.
+func (vc *VaultClient) TransitDecryptBatch(ctx context.Context, mount, key string, items []TransitItem) ([]TransitItem, error) {
+	vc.logger.Infof("[Vault] Decrypting %d items with the transit key '%s' of '%s' ☄️", len(items), key, mount)
+
+	input := make([]interface{}, len(items))
+	for i, item := range items {
+		input[i] = transitBatchInput(item, map[string]interface{}{"ciphertext": item.Ciphertext})
+	}
+
+	results, err := vc.transitBatch(ctx, transitPath(mount, "decrypt", key, ""), input)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to decrypt with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return nil, err
+	}
+
+	decrypted := make([]TransitItem, len(items))
+	for i, item := range items {
+		decrypted[i] = item
+		plaintext, err := transitResult(results[i], "plaintext")
+		if err == nil {
+			decrypted[i].Plaintext, err = base64.StdEncoding.DecodeString(plaintext)
+		}
+		decrypted[i].Err = err
+	}
+	return decrypted, nil
+}
+
+// TransitRewrapBatch rewraps the ciphertext of every item in a single request, replacing their ciphertext.
+func (vc *VaultClient) TransitRewrapBatch(ctx context.Context, mount, key string, items []TransitItem) ([]TransitItem, error) {
+	vc.logger.Infof("[Vault] Rewrapping %d items with the transit key '%s' of '%s' ☄️", len(items), key, mount)
+
+	input := make([]interface{}, len(items))
+	for i, item := range items {
+		input[i] = transitBatchInput(item, map[string]interface{}{"ciphertext": item.Ciphertext})
+	}
+
+	results, err := vc.transitBatch(ctx, transitPath(mount, "rewrap", key, ""), input)
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to rewrap with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return nil, err
+	}
+
+	rewrapped := make([]TransitItem, len(items))
+	for i, item := range items {
+		rewrapped[i] = item
+		ciphertext, err := transitResult(results[i], "ciphertext")
+		if err == nil {
+			rewrapped[i].Ciphertext = ciphertext
+		}
+		rewrapped[i].Err = err
+	}
+	return rewrapped, nil
+}
+
+// TransitSign signs the input with the key of the Transit engine mounted at mount, and returns the signature.
+// The hash algorithm is optional, the default of the engine is used when empty.
+func (vc *VaultClient) TransitSign(ctx context.Context, mount, key string, input []byte, hashAlgorithm string) (string, error) {
+	vc.logger.Infof("[Vault] Signing with the transit key '%s' of '%s' ☄️", key, mount)
+
+	data, err := vc.transitWrite(ctx, transitPath(mount, "sign", key, hashAlgorithm), map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	})
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to sign with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return "", err
+	}
+	return transitResult(data, "signature")
+}
+
+// TransitVerify returns whether the signature of the input is valid for the key of the Transit engine mounted at mount.
+func (vc *VaultClient) TransitVerify(ctx context.Context, mount, key string, input []byte, signature, hashAlgorithm string) (bool, error) {
+	vc.logger.Infof("[Vault] Verifying a signature with the transit key '%s' of '%s' ☄️", key, mount)
+
+	data, err := vc.transitWrite(ctx, transitPath(mount, "verify", key, hashAlgorithm), map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(input),
+		"signature": signature,
+	})
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to verify with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return false, err
+	}
+	valid, _ := data["valid"].(bool)
+	return valid, nil
+}
+
+// TransitHMAC returns the HMAC of the input with the key of the Transit engine mounted at mount.
+// The algorithm is optional, the default of the engine is used when empty.
+func (vc *VaultClient) TransitHMAC(ctx context.Context, mount, key string, input []byte, algorithm string) (string, error) {
+	vc.logger.Infof("[Vault] Generating a HMAC with the transit key '%s' of '%s' ☄️", key, mount)
+
+	data, err := vc.transitWrite(ctx, transitPath(mount, "hmac", key, algorithm), map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	})
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to generate a HMAC with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return "", err
+	}
+	return transitResult(data, "hmac")
+}
+
+// TransitVerifyHMAC returns whether the HMAC of the input is valid for the key of the Transit engine mounted at mount.
+func (vc *VaultClient) TransitVerifyHMAC(ctx context.Context, mount, key string, input []byte, hmac, algorithm string) (bool, error) {
+	vc.logger.Infof("[Vault] Verifying a HMAC with the transit key '%s' of '%s' ☄️", key, mount)
+
+	data, err := vc.transitWrite(ctx, transitPath(mount, "verify", key, algorithm), map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+		"hmac":  hmac,
+	})
+	if err != nil {
+		vc.logger.Errorf("[Vault] Unable to verify a HMAC with the transit key '%s' of '%s' 😱. Err: %v", key, mount, err)
+		return false, err
+	}
+	valid, _ := data["valid"].(bool)
+	return valid, nil
+}
+
+func (vc *VaultClient) transitWrite(ctx context.Context, path string, data map[string]interface{}) (map[string]interface{}, error) {
+	secret, err := vc.client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return nil, wrapVaultError(err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("empty transit response")
+	}
+	return secret.Data, nil
+}
+
+// transitBatch sends the batch input and returns a result per item. When some items fail, Vault still responds
+// with the results of all of them, but when every item fails, it responds with 400 along with the results.
+func (vc *VaultClient) transitBatch(ctx context.Context, path string, input []interface{}) ([]map[string]interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"batch_input":                   input,
+		"partial_failure_response_code": http.StatusOK,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := vc.client.Logical().WriteRawWithContext(ctx, path, body)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusBadRequest) {
+		return nil, wrapVaultError(err)
+	}
+
+	var rawResults []interface{}
+	if secret, parseErr := api.ParseSecret(resp.Body); parseErr == nil && secret != nil {
+		rawResults, _ = secret.Data["batch_results"].([]interface{})
+	}
+	if len(rawResults) != len(input) {
+		if err != nil {
+			return nil, wrapVaultError(err)
+		}
+		return nil, fmt.Errorf("expected %d transit batch results, got %d", len(input), len(rawResults))
+	}
+	results := make([]map[string]interface{}, len(rawResults))
+	for i, rawResult := range rawResults {
+		results[i], _ = rawResult.(map[string]interface{})
+	}
+	return results, nil
+}
+
+func transitBatchInput(item TransitItem, input map[string]interface{}) map[string]interface{} {
+	if len(item.Context) > 0 {
+		input["context"] = base64.StdEncoding.EncodeToString(item.Context)
+	}
+	return input
+}
+
+// transitResult returns the field of a transit result, or the error reported for it.
+func transitResult(result map[string]interface{}, field string) (string, error) {
+	if message, _ := result["error"].(string); message != "" {
+		return "", errors.New(message)
+	}
+	value, ok := result[field].(string)
+	if !ok {
+		return "", fmt.Errorf("missing %s in transit result", field)
+	}
+	return value, nil
+}
+
+func transitPath(mount, operation, key, algorithm string) string {
+	path := fmt.Sprintf("%s/%s/%s", strings.Trim(mount, "/"), operation, key)
+	if algorithm != "" {
+		path = fmt.Sprintf("%s/%s", path, algorithm)
+	}
+	return path
+}
@@ -0,0 +1,195 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	transitTag       = "transit"
+	transitEncrypt   = "encrypt"
+	transitEncrypted = "encrypted"
+)
+
+// TransitFieldEncryptor encrypts the fields of a struct tagged with `transit:"encrypt"` using a key of
+// the Transit engine, e.g. before the struct is persisted in Redis, and decrypts them back after it is read.
+// Only string and []byte fields are supported, nested structs and pointers to structs are walked.
+//
+// Whether the fields are encrypted is recorded in a bool field of the struct tagged with `transit:"encrypted"`,
+// that must be persisted along with them. The values themselves are never inspected, so a plaintext looking
+// like a ciphertext is still encrypted, and a plaintext is never sent to be decrypted.
+//
+//	type Connector struct {
+//		Name      string
+//		Password  string `transit:"encrypt"`
+//		Encrypted bool   `transit:"encrypted"`
+//	}
+type TransitFieldEncryptor struct {
+	vc    *VaultClient
+	mount string
+	key   string
+}
+
+// NewTransitFieldEncryptor creates a TransitFieldEncryptor using the key of the Transit engine mounted at mount.
+func NewTransitFieldEncryptor(vc *VaultClient, mount, key string) *TransitFieldEncryptor {
+	return &TransitFieldEncryptor{vc: vc, mount: mount, key: key}
+}
+
+// Encrypt replaces the plaintext of the tagged fields of the struct pointed by v with their ciphertext,
+// in a single request, and marks the struct as encrypted. Empty fields are left untouched, and so is the struct
+// when already marked as encrypted. On failure, no field is changed.
+func (e *TransitFieldEncryptor) Encrypt(ctx context.Context, v interface{}) error {
+	fields, encrypted, err := transitFields(v)
+	if err != nil || encrypted.Bool() {
+		return err
+	}
+
+	var pending []reflect.Value
+	var items []TransitItem
+	for _, field := range fields {
+		value := fieldString(field)
+		if value == "" {
+			continue
+		}
+		pending = append(pending, field)
+		items = append(items, TransitItem{Plaintext: []byte(value)})
+	}
+
+	if len(items) > 0 {
+		results, err := e.vc.TransitEncryptBatch(ctx, e.mount, e.key, items)
+		if err != nil {
+			return err
+		}
+		if err := transitItemsError(results); err != nil {
+			return err
+		}
+		for i, item := range results {
+			setFieldString(pending[i], item.Ciphertext)
+		}
+	}
+	encrypted.SetBool(true)
+	return nil
+}
+
+// Decrypt replaces the ciphertext of the tagged fields of the struct pointed by v with their plaintext,
+// in a single request, and marks the struct as not encrypted. The struct is left untouched when not marked as
+// encrypted. On failure, no field is changed.
+func (e *TransitFieldEncryptor) Decrypt(ctx context.Context, v interface{}) error {
+	fields, encrypted, err := transitFields(v)
+	if err != nil || !encrypted.Bool() {
+		return err
+	}
+
+	var pending []reflect.Value
+	var items []TransitItem
+	for _, field := range fields {
+		value := fieldString(field)
+		if value == "" {
+			continue
+		}
+		pending = append(pending, field)
+		items = append(items, TransitItem{Ciphertext: value})
+	}
+
+	if len(items) > 0 {
+		results, err := e.vc.TransitDecryptBatch(ctx, e.mount, e.key, items)
+		if err != nil {
+			return err
+		}
+		if err := transitItemsError(results); err != nil {
+			return err
+		}
+		for i, item := range results {
+			setFieldString(pending[i], string(item.Plaintext))
+		}
+	}
+	encrypted.SetBool(false)
+	return nil
+}
+
+func transitItemsError(items []TransitItem) error {
+	var errs []error
+	for _, item := range items {
+		if item.Err != nil {
+			errs = append(errs, item.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transitFields returns the tagged fields of the struct pointed by v, and its field recording whether they
+// are encrypted.
+func transitFields(v interface{}) ([]reflect.Value, reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, reflect.Value{}, fmt.Errorf("expected a pointer to a struct, got %T", v)
+	}
+
+	var encrypted reflect.Value
+	for i := 0; i < value.Elem().NumField(); i++ {
+		structField := value.Elem().Type().Field(i)
+		if structField.IsExported() && structField.Tag.Get(transitTag) == transitEncrypted {
+			if structField.Type.Kind() != reflect.Bool {
+				return nil, reflect.Value{}, fmt.Errorf("unsupported type %s of the field %s recording the encryption",
+					structField.Type, structField.Name)
+			}
+			encrypted = value.Elem().Field(i)
+		}
+	}
+	if !encrypted.IsValid() {
+		return nil, reflect.Value{}, fmt.Errorf(`missing a bool field tagged with transit:"encrypted" in %T`, v)
+	}
+
+	fields, err := collectTransitFields(value.Elem(), nil)
+	return fields, encrypted, err
+}
+
+func collectTransitFields(value reflect.Value, fields []reflect.Value) ([]reflect.Value, error) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		if structField.Tag.Get(transitTag) == transitEncrypt {
+			if field.Kind() != reflect.String && !isByteSlice(field) {
+				return nil, fmt.Errorf("unsupported type %s of the encrypted field %s", field.Type(), structField.Name)
+			}
+			fields = append(fields, field)
+			continue
+		}
+
+		if field.Kind() == reflect.Pointer && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Struct {
+			var err error
+			if fields, err = collectTransitFields(field, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fields, nil
+}
+
+func isByteSlice(field reflect.Value) bool {
+	return field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8
+}
+
+func fieldString(field reflect.Value) string {
+	if isByteSlice(field) {
+		return string(field.Bytes())
+	}
+	return field.String()
+}
+
+func setFieldString(field reflect.Value, value string) {
+	if isByteSlice(field) {
+		field.SetBytes([]byte(value))
+		return
+	}
+	field.SetString(value)
+}
@@ -0,0 +1,75 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitFieldEncryptor(t *testing.T) {
+	server, vc := newTransitTestClient(t)
+	ctx := context.Background()
+	encryptCalls := func() int { return len(server.CallsTo(http.MethodPut, "transit/encrypt/connectors")) }
+	decryptCalls := func() int { return len(server.CallsTo(http.MethodPut, "transit/decrypt/connectors")) }
+
+	type credentials struct {
+		Password string `transit:"encrypt"`
+		Key      []byte `transit:"encrypt"`
+	}
+	type connector struct {
+		Name        string
+		Token       string `transit:"encrypt"`
+		Empty       string `transit:"encrypt"`
+		Credentials *credentials
+		Encrypted   bool `transit:"encrypted"`
+	}
+
+	// a plaintext looking like a ciphertext is encrypted too
+	original := connector{Name: "s3", Token: "vault:v1:token", Credentials: &credentials{Password: "secret", Key: []byte("key")}}
+	value := original
+	value.Credentials = &credentials{Password: "secret", Key: []byte("key")}
+
+	encryptor := NewTransitFieldEncryptor(vc, "transit", "connectors")
+	require.NoError(t, encryptor.Encrypt(ctx, &value))
+
+	assert.True(t, value.Encrypted)
+	assert.Equal(t, "s3", value.Name)
+	assert.True(t, strings.HasPrefix(value.Token, "vault:v1:"))
+	assert.NotEqual(t, original.Token, value.Token)
+	assert.Empty(t, value.Empty)
+	assert.True(t, strings.HasPrefix(value.Credentials.Password, "vault:v1:"))
+	assert.True(t, strings.HasPrefix(string(value.Credentials.Key), "vault:v1:"))
+	assert.Equal(t, 1, encryptCalls())
+
+	// already encrypted
+	encrypted := value
+	require.NoError(t, encryptor.Encrypt(ctx, &value))
+	assert.Equal(t, encrypted, value)
+	assert.Equal(t, 1, encryptCalls())
+
+	require.NoError(t, encryptor.Decrypt(ctx, &value))
+	assert.Equal(t, original, value)
+	assert.Equal(t, 1, decryptCalls())
+
+	// plaintext is never sent to be decrypted
+	require.NoError(t, encryptor.Decrypt(ctx, &value))
+	assert.Equal(t, original, value)
+	assert.Equal(t, 1, decryptCalls())
+
+	assert.Error(t, encryptor.Encrypt(ctx, value))
+	var unmarked struct {
+		Token string `transit:"encrypt"`
+	}
+	assert.Error(t, encryptor.Encrypt(ctx, &unmarked))
+	assert.Zero(t, unmarked)
+
+	// on failure, no field is changed
+	corrupted := connector{Token: encrypted.Token, Empty: "corrupted", Encrypted: true}
+	expected := corrupted
+	assert.Error(t, encryptor.Decrypt(ctx, &corrupted))
+	assert.Equal(t, expected, corrupted)
+}
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newTransitTestClient(t *testing.T) (*vaulttest.Server, *VaultClient) {
+	server := vaulttest.NewServer()
+	t.Cleanup(server.Close)
+	server.Mount("transit", vaulttest.Transit)
+
+	vc, err := NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		VaultClientConfiguration{VaultURL: server.URL}, "", NewLocalAuthenticator(vaulttest.RootToken))
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return server, vc
+}
+
+func TestTransitBatch(t *testing.T) {
+	_, vc := newTransitTestClient(t)
+	ctx := context.Background()
+
+	items, err := vc.TransitEncryptBatch(ctx, "transit", "app", []TransitItem{
+		{Plaintext: []byte("first"), Context: []byte("tenant-a")},
+		{Plaintext: []byte("second"), Context: []byte("tenant-b")},
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	for _, item := range items {
+		require.NoError(t, item.Err)
+		assert.NotEmpty(t, item.Ciphertext)
+	}
+
+	// the failed items don't fail the others
+	items[1].Context = []byte("tenant-a")
+	decrypted, err := vc.TransitDecryptBatch(ctx, "transit", "app", items)
+	require.NoError(t, err)
+	require.NoError(t, decrypted[0].Err)
+	assert.Equal(t, []byte("first"), decrypted[0].Plaintext)
+	assert.Error(t, decrypted[1].Err)
+
+	// neither when every item fails
+	decrypted, err = vc.TransitDecryptBatch(ctx, "transit", "app", []TransitItem{
+		{Ciphertext: "invalid"},
+		{Ciphertext: items[0].Ciphertext, Context: []byte("tenant-b")},
+	})
+	require.NoError(t, err)
+	assert.Error(t, decrypted[0].Err)
+	assert.Error(t, decrypted[1].Err)
+
+	rewrapped, err := vc.TransitRewrapBatch(ctx, "transit", "app", []TransitItem{items[0], {Ciphertext: "invalid"}})
+	require.NoError(t, err)
+	require.NoError(t, rewrapped[0].Err)
+	assert.Error(t, rewrapped[1].Err)
+	assert.Equal(t, "invalid", rewrapped[1].Ciphertext)
+
+	// the request fails when the key doesn't exist
+	_, err = vc.TransitDecrypt(ctx, "transit", "missing", items[0].Ciphertext, nil)
+	assert.Error(t, err)
+}
+
+func TestTransitSignAndHMAC(t *testing.T) {
+	_, vc := newTransitTestClient(t)
+	ctx := context.Background()
+
+	_, err := vc.TransitEncrypt(ctx, "transit", "app", []byte("create"), nil)
+	require.NoError(t, err)
+
+	signature, err := vc.TransitSign(ctx, "transit", "app", []byte("input"), "sha2-256")
+	require.NoError(t, err)
+	valid, err := vc.TransitVerify(ctx, "transit", "app", []byte("input"), signature, "sha2-256")
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = vc.TransitVerify(ctx, "transit", "app", []byte("other"), signature, "sha2-256")
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	hmac, err := vc.TransitHMAC(ctx, "transit", "app", []byte("input"), "")
+	require.NoError(t, err)
+	valid, err = vc.TransitVerifyHMAC(ctx, "transit", "app", []byte("other"), hmac, "")
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
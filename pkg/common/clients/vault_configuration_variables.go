@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydataai/go-core/pkg/common/config"
+)
+
+const defaultVaultConfigurationTimeout = 30 * time.Second
+
+// VaultConfigurationVariables fills a configuration struct with a secret from Vault, decoded with DecodeSecret,
+// so secrets are loaded in the same config.InitConfigurationVariables pass as the env vars.
+// Fields missing from the secret keep their value, so it can come after the env vars of the same struct.
+//
+// The Vault client is configured by env vars too, so the configuration is loaded in two phases: the
+// configuration of the client first, then, once the client is created, the configurations read from Vault:
+//
+//	vaultConfiguration := clients.VaultClientConfiguration{}
+//	if err := config.InitConfigurationVariables([]config.ConfigurationVariables{&vaultConfiguration}); err != nil {
+//		return err
+//	}
+//	authenticator, err := clients.NewAuthenticator(vaultConfiguration)
+//	if err != nil {
+//		return err
+//	}
+//	vaultClient, err := clients.NewVaultClient(logger, vaultConfiguration, role, authenticator)
+//	if err != nil {
+//		return err
+//	}
+//
+//	database := &DatabaseConfiguration{}
+//	err = config.InitConfigurationVariables([]config.ConfigurationVariables{
+//		database,
+//		clients.NewVaultKVConfigurationVariables(vaultClient, "secret", "database", database),
+//	})
+type VaultConfigurationVariables struct {
+	vc *VaultClient
+	// mount is only set for secrets of the KV v2 secrets engine.
+	mount   string
+	path    string
+	target  interface{}
+	timeout time.Duration
+}
+
+// NewVaultConfigurationVariables creates a config.ConfigurationVariables filling the struct pointed by target
+// with the secret in the path, read with VaultClient.GetInto.
+func NewVaultConfigurationVariables(vc *VaultClient, path string, target interface{}) config.ConfigurationVariables {
+	return &VaultConfigurationVariables{vc: vc, path: path, target: target, timeout: defaultVaultConfigurationTimeout}
+}
+
+// NewVaultKVConfigurationVariables creates a config.ConfigurationVariables filling the struct pointed by target
+// with the latest version of the secret in the path of the KV v2 secrets engine mounted at mount.
+func NewVaultKVConfigurationVariables(vc *VaultClient, mount, path string, target interface{}) config.ConfigurationVariables {
+	return &VaultConfigurationVariables{
+		vc:      vc,
+		mount:   mount,
+		path:    path,
+		target:  target,
+		timeout: defaultVaultConfigurationTimeout,
+	}
+}
+
+// LoadFromEnvVars reads the secret from Vault, despite the name required by config.ConfigurationVariables.
+func (c *VaultConfigurationVariables) LoadFromEnvVars() error {
+	if c.vc == nil {
+		return fmt.Errorf("unable to read '%s', the vault client must be created before loading it", c.path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if c.mount != "" {
+		return c.vc.KVGetInto(ctx, c.mount, c.path, c.target)
+	}
+	return c.vc.GetInto(ctx, c.path, c.target)
+}
@@ -0,0 +1,98 @@
+package vaulttest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleLogin logs in with the auth method mounted in the path, accepting any credentials.
+// Must be called with the lock held.
+func (s *Server) handleLogin(call Call) (int, map[string]interface{}) {
+	mountPath := strings.TrimSuffix(strings.TrimPrefix(call.Path, "auth/"), "/login")
+	method, ok := s.auths[mountPath]
+	if !ok || !strings.HasSuffix(call.Path, "/login") {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+	}
+	if call.Method != http.MethodPost && call.Method != http.MethodPut {
+		return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+	}
+
+	switch method {
+	case AuthKubernetes, AuthJWT:
+		if call.Body["jwt"] == nil || call.Body["jwt"] == "" {
+			return errorResponse(http.StatusBadRequest, "missing jwt")
+		}
+		if call.Body["role"] == nil || call.Body["role"] == "" {
+			return errorResponse(http.StatusBadRequest, "missing role")
+		}
+	case AuthAppRole:
+		if call.Body["role_id"] == nil || call.Body["role_id"] == "" {
+			return errorResponse(http.StatusBadRequest, "missing role_id")
+		}
+	}
+
+	return http.StatusOK, s.issueToken()
+}
+
+// handleToken handles the renewal and lookup of tokens. Must be called with the lock held.
+func (s *Server) handleToken(call Call) (int, map[string]interface{}) {
+	switch strings.TrimPrefix(call.Path, "auth/token/") {
+	case "renew-self":
+		return s.renewToken(call.Token, call.Body["increment"])
+	case "renew":
+		clientToken, _ := call.Body["token"].(string)
+		return s.renewToken(clientToken, call.Body["increment"])
+	case "lookup-self":
+		t := s.tokens[call.Token]
+		return http.StatusOK, dataResponse(map[string]interface{}{
+			"id":        call.Token,
+			"ttl":       int(t.ttl.Seconds()),
+			"renewable": t.renewable,
+		})
+	}
+	return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+}
+
+// issueToken creates a new token and returns its auth response. Must be called with the lock held.
+func (s *Server) issueToken() map[string]interface{} {
+	s.issued++
+	clientToken := fmt.Sprintf("s.vaulttest%d", s.issued)
+	t := &token{ttl: s.tokenTTL, renewable: s.tokenTTL > 0 && s.tokenRenewable}
+	if s.tokenTTL > 0 {
+		t.expiresAt = time.Now().Add(s.tokenTTL)
+	}
+	s.tokens[clientToken] = t
+	return authResponse(clientToken, t)
+}
+
+// renewToken extends the token by its TTL. Must be called with the lock held.
+func (s *Server) renewToken(clientToken string, increment interface{}) (int, map[string]interface{}) {
+	t, ok := s.tokens[clientToken]
+	if !ok || !s.authorized(clientToken) {
+		return permissionDenied()
+	}
+	if !t.renewable {
+		return errorResponse(http.StatusBadRequest, "lease is not renewable")
+	}
+
+	ttl := t.ttl
+	if seconds, ok := increment.(float64); ok && seconds > 0 && time.Duration(seconds)*time.Second < ttl {
+		ttl = time.Duration(seconds) * time.Second
+	}
+	t.expiresAt = time.Now().Add(ttl)
+	return http.StatusOK, authResponse(clientToken, &token{ttl: ttl, renewable: true})
+}
+
+func authResponse(clientToken string, t *token) map[string]interface{} {
+	return map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   clientToken,
+			"accessor":       clientToken + ".accessor",
+			"policies":       []string{"default"},
+			"lease_duration": int(t.ttl.Seconds()),
+			"renewable":      t.renewable,
+		},
+	}
+}
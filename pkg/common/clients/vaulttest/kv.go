@@ -0,0 +1,334 @@
+package vaulttest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kvVersion struct {
+	data         map[string]interface{}
+	createdTime  time.Time
+	deletionTime time.Time
+	destroyed    bool
+}
+
+type kvSecret struct {
+	// versions holds the version N at the index N-1, KV v1 secrets only have one.
+	versions           []*kvVersion
+	createdTime        time.Time
+	updatedTime        time.Time
+	customMetadata     map[string]interface{}
+	maxVersions        int
+	casRequired        bool
+	deleteVersionAfter string
+}
+
+func (s *kvSecret) currentVersion() int {
+	return len(s.versions)
+}
+
+// version returns the given version, or the current one for 0.
+func (s *kvSecret) version(version int) *kvVersion {
+	if version == 0 {
+		version = s.currentVersion()
+	}
+	if version < 1 || version > len(s.versions) {
+		return nil
+	}
+	return s.versions[version-1]
+}
+
+func handleKVv1(m *mount, call Call, p string) (int, map[string]interface{}) {
+	switch call.Method {
+	case http.MethodGet:
+		secret, ok := m.kv[p]
+		if !ok {
+			return errorResponse(http.StatusNotFound)
+		}
+		return http.StatusOK, dataResponse(secret.versions[0].data)
+	case http.MethodPut, http.MethodPost:
+		now := time.Now().UTC()
+		m.kv[p] = &kvSecret{
+			versions:    []*kvVersion{{data: call.Body, createdTime: now}},
+			createdTime: now,
+			updatedTime: now,
+		}
+		return http.StatusNoContent, nil
+	case http.MethodDelete:
+		delete(m.kv, p)
+		return http.StatusNoContent, nil
+	case "LIST":
+		return listKeys(m, p)
+	}
+	// KV v1 doesn't support PATCH, as Vault
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+func handleKVv2(m *mount, call Call, rest string, r *http.Request) (int, map[string]interface{}) {
+	operation, p, _ := strings.Cut(rest, "/")
+
+	switch operation + " " + call.Method {
+	case "data GET":
+		version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+		return readKVv2(m, p, version)
+	case "data PUT", "data POST":
+		data, _ := call.Body["data"].(map[string]interface{})
+		return writeKVv2(m, p, data, call.Body["options"], false)
+	case "data PATCH":
+		data, _ := call.Body["data"].(map[string]interface{})
+		return writeKVv2(m, p, data, call.Body["options"], true)
+	case "data DELETE":
+		secret, ok := m.kv[p]
+		if ok {
+			deleteVersions(secret, []interface{}{float64(secret.currentVersion())})
+		}
+		return http.StatusNoContent, nil
+	case "delete PUT", "delete POST":
+		if secret, ok := m.kv[p]; ok {
+			versions, _ := call.Body["versions"].([]interface{})
+			deleteVersions(secret, versions)
+		}
+		return http.StatusNoContent, nil
+	case "undelete PUT", "undelete POST":
+		if secret, ok := m.kv[p]; ok {
+			versions, _ := call.Body["versions"].([]interface{})
+			for _, version := range versions {
+				if v := secret.version(toInt(version)); v != nil && !v.destroyed {
+					v.deletionTime = time.Time{}
+				}
+			}
+		}
+		return http.StatusNoContent, nil
+	case "destroy PUT", "destroy POST":
+		if secret, ok := m.kv[p]; ok {
+			versions, _ := call.Body["versions"].([]interface{})
+			for _, version := range versions {
+				if v := secret.version(toInt(version)); v != nil {
+					v.destroyed = true
+					v.data = nil
+				}
+			}
+		}
+		return http.StatusNoContent, nil
+	case "metadata GET":
+		secret, ok := m.kv[p]
+		if !ok {
+			return errorResponse(http.StatusNotFound)
+		}
+		return http.StatusOK, dataResponse(fullMetadata(secret))
+	case "metadata LIST":
+		return listKeys(m, p)
+	case "metadata PUT", "metadata POST", "metadata PATCH":
+		secret, ok := m.kv[p]
+		if !ok {
+			now := time.Now().UTC()
+			secret = &kvSecret{createdTime: now, updatedTime: now}
+			m.kv[p] = secret
+		}
+		writeMetadata(secret, call.Body, call.Method != http.MethodPatch)
+		return http.StatusNoContent, nil
+	case "metadata DELETE":
+		delete(m.kv, p)
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+func readKVv2(m *mount, p string, version int) (int, map[string]interface{}) {
+	secret, ok := m.kv[p]
+	if !ok {
+		return errorResponse(http.StatusNotFound)
+	}
+	if version == 0 {
+		version = secret.currentVersion()
+	}
+	v := secret.version(version)
+	if v == nil {
+		return errorResponse(http.StatusNotFound)
+	}
+
+	metadata := versionMetadata(secret, v, version)
+	if v.destroyed || !v.deletionTime.IsZero() {
+		// as Vault, deleted versions are not found but their metadata is returned
+		return http.StatusNotFound, dataResponse(map[string]interface{}{"data": nil, "metadata": metadata})
+	}
+	return http.StatusOK, dataResponse(map[string]interface{}{"data": v.data, "metadata": metadata})
+}
+
+func writeKVv2(m *mount, p string, data map[string]interface{}, rawOptions interface{}, patch bool) (int, map[string]interface{}) {
+	secret, ok := m.kv[p]
+	options, _ := rawOptions.(map[string]interface{})
+	cas, hasCAS := options["cas"]
+
+	if patch {
+		current := (*kvVersion)(nil)
+		if ok {
+			current = secret.version(0)
+		}
+		if current == nil || current.destroyed || !current.deletionTime.IsZero() {
+			return errorResponse(http.StatusNotFound)
+		}
+		merged := map[string]interface{}{}
+		for key, value := range current.data {
+			merged[key] = value
+		}
+		for key, value := range data {
+			if value == nil {
+				delete(merged, key)
+			} else {
+				merged[key] = value
+			}
+		}
+		data = merged
+	}
+
+	if !ok {
+		secret = &kvSecret{}
+	}
+	if secret.casRequired && !hasCAS {
+		return errorResponse(http.StatusBadRequest, "check-and-set parameter required for this call")
+	}
+	if hasCAS && toInt(cas) != secret.currentVersion() {
+		return errorResponse(http.StatusBadRequest, "check-and-set parameter did not match the current version")
+	}
+
+	now := time.Now().UTC()
+	if !ok {
+		secret.createdTime = now
+		m.kv[p] = secret
+	}
+	v := &kvVersion{data: data, createdTime: now}
+	secret.versions = append(secret.versions, v)
+	secret.updatedTime = now
+	if secret.maxVersions > 0 {
+		for i := 0; i < len(secret.versions)-secret.maxVersions; i++ {
+			secret.versions[i].destroyed = true
+			secret.versions[i].data = nil
+		}
+	}
+
+	return http.StatusOK, dataResponse(versionMetadata(secret, v, secret.currentVersion()))
+}
+
+func deleteVersions(secret *kvSecret, versions []interface{}) {
+	now := time.Now().UTC()
+	for _, version := range versions {
+		if v := secret.version(toInt(version)); v != nil && v.deletionTime.IsZero() {
+			v.deletionTime = now
+		}
+	}
+}
+
+func writeMetadata(secret *kvSecret, body map[string]interface{}, replace bool) {
+	if replace {
+		secret.maxVersions, secret.casRequired, secret.deleteVersionAfter, secret.customMetadata = 0, false, "", nil
+	}
+	if value, ok := body["max_versions"]; ok {
+		secret.maxVersions = toInt(value)
+	}
+	if value, ok := body["cas_required"].(bool); ok {
+		secret.casRequired = value
+	}
+	if value, ok := body["delete_version_after"]; ok {
+		secret.deleteVersionAfter = fmt.Sprintf("%v", value)
+	}
+	if value, ok := body["custom_metadata"].(map[string]interface{}); ok {
+		secret.customMetadata = value
+	}
+	secret.updatedTime = time.Now().UTC()
+}
+
+func versionMetadata(secret *kvSecret, v *kvVersion, version int) map[string]interface{} {
+	return map[string]interface{}{
+		"version":         version,
+		"created_time":    v.createdTime.Format(time.RFC3339Nano),
+		"deletion_time":   formatTime(v.deletionTime),
+		"destroyed":       v.destroyed,
+		"custom_metadata": secret.customMetadata,
+	}
+}
+
+func fullMetadata(secret *kvSecret) map[string]interface{} {
+	versions := map[string]interface{}{}
+	oldest := 0
+	for i, v := range secret.versions {
+		if !v.destroyed && oldest == 0 {
+			oldest = i + 1
+		}
+		versions[strconv.Itoa(i+1)] = map[string]interface{}{
+			"created_time":  v.createdTime.Format(time.RFC3339Nano),
+			"deletion_time": formatTime(v.deletionTime),
+			"destroyed":     v.destroyed,
+		}
+	}
+	deleteVersionAfter := secret.deleteVersionAfter
+	if deleteVersionAfter == "" {
+		deleteVersionAfter = "0s"
+	}
+	return map[string]interface{}{
+		"cas_required":         secret.casRequired,
+		"created_time":         secret.createdTime.Format(time.RFC3339Nano),
+		"current_version":      secret.currentVersion(),
+		"custom_metadata":      secret.customMetadata,
+		"delete_version_after": deleteVersionAfter,
+		"max_versions":         secret.maxVersions,
+		"oldest_version":       oldest,
+		"updated_time":         secret.updatedTime.Format(time.RFC3339Nano),
+		"versions":             versions,
+	}
+}
+
+// listKeys returns the keys right under the path, folders ending with a slash.
+func listKeys(m *mount, p string) (int, map[string]interface{}) {
+	prefix := strings.Trim(p, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	unique := map[string]struct{}{}
+	for secretPath := range m.kv {
+		if !strings.HasPrefix(secretPath, prefix) {
+			continue
+		}
+		key, _, isFolder := strings.Cut(strings.TrimPrefix(secretPath, prefix), "/")
+		if isFolder {
+			key += "/"
+		}
+		unique[key] = struct{}{}
+	}
+	if len(unique) == 0 {
+		return errorResponse(http.StatusNotFound)
+	}
+
+	keys := make([]string, 0, len(unique))
+	for key := range unique {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return http.StatusOK, dataResponse(map[string]interface{}{"keys": keys})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// toInt converts the numbers decoded from JSON bodies.
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
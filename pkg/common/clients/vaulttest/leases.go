@@ -0,0 +1,103 @@
+package vaulttest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lease is the lease of the credentials issued by a Database engine.
+type lease struct {
+	expiresAt time.Time
+	renewable bool
+}
+
+// SetLeaseTTL sets the TTL of the leases issued from now on by the Database engines, and of their renewals.
+func (s *Server) SetLeaseTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaseTTL = ttl
+}
+
+// SetLeaseRenewable sets whether the leases issued from now on can be renewed, as they are by default.
+func (s *Server) SetLeaseRenewable(renewable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaseRenewable = renewable
+}
+
+// Leases returns the IDs of the leases that were neither revoked nor expired, sorted.
+func (s *Server) Leases() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var leaseIDs []string
+	for leaseID, l := range s.leases {
+		if time.Now().Before(l.expiresAt) {
+			leaseIDs = append(leaseIDs, leaseID)
+		}
+	}
+	sort.Strings(leaseIDs)
+	return leaseIDs
+}
+
+// handleDatabase issues credentials with a lease from creds/<role>. Must be called with the lock held.
+func (s *Server) handleDatabase(mountPath string, call Call, rest string) (int, map[string]interface{}) {
+	role, ok := strings.CutPrefix(rest, "creds/")
+	if !ok || role == "" || strings.Contains(role, "/") {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+	}
+	if call.Method != http.MethodGet {
+		return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+	}
+
+	s.leaseCount++
+	leaseID := fmt.Sprintf("%s/creds/%s/%d", mountPath, role, s.leaseCount)
+	s.leases[leaseID] = &lease{expiresAt: time.Now().Add(s.leaseTTL), renewable: s.leaseRenewable}
+	return http.StatusOK, map[string]interface{}{
+		"lease_id":       leaseID,
+		"lease_duration": int(s.leaseTTL.Seconds()),
+		"renewable":      s.leaseRenewable,
+		"data": map[string]interface{}{
+			"username": fmt.Sprintf("v-%s-%d", role, s.leaseCount),
+			"password": fmt.Sprintf("password-%d", s.leaseCount),
+		},
+	}
+}
+
+// handleLeases renews and revokes leases. Must be called with the lock held.
+func (s *Server) handleLeases(call Call) (int, map[string]interface{}) {
+	if call.Method != http.MethodPost && call.Method != http.MethodPut {
+		return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+	}
+	leaseID, _ := call.Body["lease_id"].(string)
+
+	switch strings.TrimPrefix(call.Path, "sys/leases/") {
+	case "renew":
+		l, ok := s.leases[leaseID]
+		if !ok || !time.Now().Before(l.expiresAt) {
+			return errorResponse(http.StatusBadRequest, "lease not found")
+		}
+		if !l.renewable {
+			return errorResponse(http.StatusBadRequest, "lease is not renewable")
+		}
+
+		ttl := s.leaseTTL
+		if seconds, ok := call.Body["increment"].(float64); ok && seconds > 0 && time.Duration(seconds)*time.Second < ttl {
+			ttl = time.Duration(seconds) * time.Second
+		}
+		l.expiresAt = time.Now().Add(ttl)
+		return http.StatusOK, map[string]interface{}{
+			"lease_id":       leaseID,
+			"lease_duration": int(ttl.Seconds()),
+			"renewable":      true,
+		}
+	case "revoke":
+		// as Vault, revoking an unknown lease succeeds
+		delete(s.leases, leaseID)
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+}
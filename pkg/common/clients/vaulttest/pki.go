@@ -0,0 +1,139 @@
+package vaulttest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// certificateAuthority signs the certificates issued by a PKI engine, numbering them from 1.
+type certificateAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         string
+	serial      int64
+}
+
+func newCertificateAuthority() (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0),
+		Subject:               pkix.Name{CommonName: "vaulttest"},
+		NotBefore:             now,
+		NotAfter:              now.Add(defaultMountTTL),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &certificateAuthority{
+		certificate: certificate,
+		key:         key,
+		pem:         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}, nil
+}
+
+// handlePKI issues certificates from issue/<role>, valid for the ttl of the request or the default lease TTL
+// of the mount. Any role is accepted.
+func handlePKI(m *mount, call Call, rest string) (int, map[string]interface{}) {
+	role, ok := strings.CutPrefix(rest, "issue/")
+	if !ok || role == "" || strings.Contains(role, "/") {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+	}
+	if call.Method != http.MethodPost && call.Method != http.MethodPut {
+		return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+	}
+	commonName, _ := call.Body["common_name"].(string)
+	if commonName == "" {
+		return errorResponse(http.StatusBadRequest, "the common_name field is required")
+	}
+
+	ttl := time.Duration(ttlSeconds(m.defaultLeaseTTL)) * time.Second
+	if rawTTL, ok := call.Body["ttl"].(string); ok && rawTTL != "" {
+		ttl = time.Duration(ttlSeconds(rawTTL)) * time.Second
+	}
+
+	if m.ca == nil {
+		ca, err := newCertificateAuthority()
+		if err != nil {
+			return errorResponse(http.StatusInternalServerError, err.Error())
+		}
+		m.ca = ca
+	}
+	certificate, privateKey, err := m.ca.issue(commonName, call.Body, ttl)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	return http.StatusOK, dataResponse(map[string]interface{}{
+		"certificate":      certificate,
+		"issuing_ca":       m.ca.pem,
+		"ca_chain":         []string{m.ca.pem},
+		"private_key":      privateKey,
+		"private_key_type": "ec",
+		"serial_number":    fmt.Sprintf("%x", m.ca.serial),
+	})
+}
+
+// issue signs a new certificate for the common name and the alt_names and ip_sans of the body, and returns it
+// along with its private key, PEM encoded.
+func (ca *certificateAuthority) issue(commonName string, body map[string]interface{}, ttl time.Duration) (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	ca.serial++
+	now := time.Now().Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    now,
+		NotAfter:     now.Add(ttl),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if altNames, _ := body["alt_names"].(string); altNames != "" {
+		template.DNSNames = append(template.DNSNames, strings.Split(altNames, ",")...)
+	}
+	if ipSANs, _ := body["ip_sans"].(string); ipSANs != "" {
+		for _, ipSAN := range strings.Split(ipSANs, ",") {
+			ip := net.ParseIP(ipSAN)
+			if ip == nil {
+				return "", "", fmt.Errorf("the value %q is not a valid IP address", ipSAN)
+			}
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})), nil
+}
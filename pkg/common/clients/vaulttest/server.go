@@ -0,0 +1,438 @@
+// Package vaulttest provides an in-memory fake of the Vault HTTP API for unit tests and local development.
+//
+// The Server implements the subset of the API used by clients.VaultClient: the KV v1 and v2, transit, database
+// and PKI secrets engines, the renewal and revocation of leases, the management of mounts, ACL policies and auth
+// roles, the login of the kubernetes, approle and jwt auth methods and the token renewal. Failures can be injected per method and path, and every call is recorded.
+// Note that the Vault client retries the requests failing with 5xx, unless api.Config.MaxRetries is 0.
+//
+//	server := vaulttest.NewServer()
+//	defer server.Close()
+//	server.Mount("secret", vaulttest.KVv2)
+//
+//	vc, _ := clients.NewVaultClient(logger, clients.VaultClientConfiguration{VaultURL: server.URL}, "",
+//		clients.NewLocalAuthenticator(vaulttest.RootToken))
+//
+//	server.FailOnce(http.MethodGet, "secret/data/*", http.StatusForbidden, "permission denied")
+//	vc.KVGet(ctx, "secret", "app") // clients.ErrPermissionDenied
+//
+//	server.CallsTo(http.MethodGet, "secret/data/app") // the recorded calls
+package vaulttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RootToken is always accepted by the Server.
+const RootToken = "root"
+
+// Engine types supported by Server.Mount.
+const (
+	KVv1     = "kv"
+	KVv2     = "kv-v2"
+	Transit  = "transit"
+	Database = "database"
+	PKI      = "pki"
+)
+
+// Auth method types supported by Server.EnableAuth.
+const (
+	AuthKubernetes = "kubernetes"
+	AuthAppRole    = "approle"
+	AuthJWT        = "jwt"
+)
+
+// DefaultTokenTTL is the TTL of the tokens issued on login, unless changed with Server.SetTokenTTL.
+const DefaultTokenTTL = time.Hour
+
+// DefaultLeaseTTL is the TTL of the leases issued by the Database engines, unless changed with Server.SetLeaseTTL.
+const DefaultLeaseTTL = time.Hour
+
+// Call is a request received by the Server.
+type Call struct {
+	Method string
+	// Path is the path of the request without the /v1/ prefix, e.g. secret/data/app.
+	Path  string
+	Query string
+	Token string
+	Body  map[string]interface{}
+}
+
+type failure struct {
+	status int
+	errors []string
+}
+
+type mount struct {
+	engine string
+	kv     map[string]*kvSecret
+	keys   map[string]*transitKey
+	ca     *certificateAuthority
+
+	description     string
+	defaultLeaseTTL string
+	maxLeaseTTL     string
+	options         map[string]interface{}
+}
+
+func newMount(engine string) *mount {
+	return &mount{
+		engine: engine,
+		kv:     map[string]*kvSecret{},
+		keys:   map[string]*transitKey{},
+	}
+}
+
+type token struct {
+	expiresAt time.Time
+	ttl       time.Duration
+	renewable bool
+}
+
+// Server is an in-memory fake of the Vault HTTP API, listening on URL.
+type Server struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	mounts         map[string]*mount
+	auths          map[string]string
+	roles          map[string]map[string]interface{}
+	policies       map[string]string
+	tokens         map[string]*token
+	tokenTTL       time.Duration
+	tokenRenewable bool
+	issued         int
+	leases         map[string]*lease
+	leaseTTL       time.Duration
+	leaseRenewable bool
+	leaseCount     int
+
+	failures     map[string]failure
+	onceFailures map[string][]failure
+	calls        []Call
+}
+
+// NewServer starts a Server, with no mounts.
+func NewServer() *Server {
+	s := &Server{
+		tokenTTL:       DefaultTokenTTL,
+		tokenRenewable: true,
+		leaseTTL:       DefaultLeaseTTL,
+		leaseRenewable: true,
+	}
+	s.Reset()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Reset removes all the mounts, auth methods, issued tokens and leases, failures and recorded calls.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mounts = map[string]*mount{}
+	s.auths = map[string]string{}
+	s.roles = map[string]map[string]interface{}{}
+	s.policies = map[string]string{}
+	s.tokens = map[string]*token{RootToken: {}}
+	s.leases = map[string]*lease{}
+	s.failures = map[string]failure{}
+	s.onceFailures = map[string][]failure{}
+	s.calls = nil
+}
+
+// Mount enables a secrets engine of the given type (KVv1, KVv2, Transit, Database or PKI) at the path.
+func (s *Server) Mount(path, engine string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mounts[strings.Trim(path, "/")] = newMount(engine)
+}
+
+// Mounted returns the type of the secrets engine mounted at the path, if any.
+func (s *Server) Mounted(path string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mounts[strings.Trim(path, "/")]
+	if !ok {
+		return "", false
+	}
+	return m.engine, true
+}
+
+// Policy returns the rules of the ACL policy, if it exists.
+func (s *Server) Policy(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules, ok := s.policies[name]
+	return rules, ok
+}
+
+// AuthRole returns the data written to the role of the auth method mounted at auth/<path>, if it exists.
+func (s *Server) AuthRole(path, name string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[strings.Trim(path, "/")+"/"+name]
+	return role, ok
+}
+
+// EnableAuth enables an auth method of the given type (AuthKubernetes, AuthAppRole or AuthJWT) at auth/<path>.
+// Any credentials are accepted on login.
+func (s *Server) EnableAuth(path, method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[strings.Trim(path, "/")] = method
+}
+
+// SetTokenTTL sets the TTL of the tokens issued from now on. A TTL of 0 issues tokens that don't expire.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenTTL = ttl
+}
+
+// SetTokenRenewable sets whether the tokens issued from now on can be renewed, as they are by default.
+func (s *Server) SetTokenRenewable(renewable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRenewable = renewable
+}
+
+// IssueToken issues a token as on login, e.g. to be read by clients.NewTokenFileAuthenticator.
+func (s *Server) IssueToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, _ := s.issueToken()["auth"].(map[string]interface{})
+	clientToken, _ := auth["client_token"].(string)
+	return clientToken
+}
+
+// RevokeToken invalidates a token, so the requests made with it are denied.
+func (s *Server) RevokeToken(clientToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, clientToken)
+}
+
+// FailOn makes every request to the method and path fail with the status and errors. The method "*" matches
+// every method, and the path may be a pattern as supported by path.Match, e.g. secret/data/*.
+func (s *Server) FailOn(method, path string, status int, errors ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[failureKey(method, path)] = failure{status: status, errors: errors}
+}
+
+// FailOnce makes the next request to the method and path fail with the status and errors.
+// Calling it several times queues the failures.
+func (s *Server) FailOnce(method, path string, status int, errors ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := failureKey(method, path)
+	s.onceFailures[key] = append(s.onceFailures[key], failure{status: status, errors: errors})
+}
+
+// ClearFailures removes all the injected failures.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = map[string]failure{}
+	s.onceFailures = map[string][]failure{}
+}
+
+// Calls returns all the recorded calls, in the order they were received.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the recorded calls to the method and path, that support the same patterns as FailOn.
+func (s *Server) CallsTo(method, path string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []Call
+	for _, call := range s.calls {
+		if matchCall(method, path, call.Method, call.Path) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// ResetCalls removes the recorded calls.
+func (s *Server) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	call := Call{
+		Method: r.Method,
+		Path:   strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/"),
+		Query:  r.URL.RawQuery,
+		Token:  r.Header.Get("X-Vault-Token"),
+	}
+	if call.Method == http.MethodGet && r.URL.Query().Get("list") == "true" {
+		call.Method = "LIST"
+	}
+	if body, err := io.ReadAll(r.Body); err == nil && len(body) > 0 {
+		if err := json.Unmarshal(body, &call.Body); err != nil {
+			writeErrors(w, http.StatusBadRequest, "failed to parse JSON input: "+err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, call)
+	if f, ok := s.injectedFailure(call.Method, call.Path); ok {
+		writeErrors(w, f.status, f.errors...)
+		return
+	}
+
+	status, response := s.route(call, r)
+	if response == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// route handles the call and returns the status and body of the response. Must be called with the lock held.
+func (s *Server) route(call Call, r *http.Request) (int, map[string]interface{}) {
+	switch {
+	case strings.HasPrefix(call.Path, "auth/token/"):
+		if !s.authorized(call.Token) {
+			return permissionDenied()
+		}
+		return s.handleToken(call)
+	case strings.HasPrefix(call.Path, "auth/") && strings.HasSuffix(call.Path, "/login"):
+		return s.handleLogin(call)
+	}
+
+	if !s.authorized(call.Token) {
+		return permissionDenied()
+	}
+
+	switch {
+	case strings.HasPrefix(call.Path, "sys/mounts/"):
+		return s.handleMounts(call)
+	case strings.HasPrefix(call.Path, "sys/leases/"):
+		return s.handleLeases(call)
+	case strings.HasPrefix(call.Path, "sys/policies/acl/"), strings.HasPrefix(call.Path, "sys/policy/"):
+		return s.handlePolicies(call)
+	case strings.HasPrefix(call.Path, "auth/"):
+		return s.handleRoles(call)
+	}
+
+	mountPath, m := s.mountOf(call.Path)
+	if m == nil {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+	}
+	rest := strings.Trim(strings.TrimPrefix(call.Path, mountPath), "/")
+
+	switch m.engine {
+	case KVv1:
+		return handleKVv1(m, call, rest)
+	case KVv2:
+		return handleKVv2(m, call, rest, r)
+	case Transit:
+		return handleTransit(m, call, rest)
+	case Database:
+		return s.handleDatabase(mountPath, call, rest)
+	case PKI:
+		return handlePKI(m, call, rest)
+	}
+	return errorResponse(http.StatusNotFound, "unsupported engine "+m.engine)
+}
+
+// mountOf returns the mount with the longest path prefixing the path.
+func (s *Server) mountOf(p string) (string, *mount) {
+	var mountPath string
+	var m *mount
+	for candidate, candidateMount := range s.mounts {
+		if (p == candidate || strings.HasPrefix(p, candidate+"/")) && len(candidate) > len(mountPath) {
+			mountPath, m = candidate, candidateMount
+		}
+	}
+	return mountPath, m
+}
+
+// authorized returns whether the token is valid. Must be called with the lock held.
+func (s *Server) authorized(clientToken string) bool {
+	t, ok := s.tokens[clientToken]
+	if !ok {
+		return false
+	}
+	if !t.expiresAt.IsZero() && time.Now().After(t.expiresAt) {
+		delete(s.tokens, clientToken)
+		return false
+	}
+	return true
+}
+
+// injectedFailure returns the failure injected for the call, if any. Must be called with the lock held.
+func (s *Server) injectedFailure(method, p string) (failure, bool) {
+	for key, queue := range s.onceFailures {
+		if len(queue) > 0 && matchFailure(key, method, p) {
+			s.onceFailures[key] = queue[1:]
+			return queue[0], true
+		}
+	}
+	for key, f := range s.failures {
+		if matchFailure(key, method, p) {
+			return f, true
+		}
+	}
+	return failure{}, false
+}
+
+func failureKey(method, p string) string {
+	return strings.ToUpper(method) + " " + strings.Trim(p, "/")
+}
+
+func matchFailure(key, method, p string) bool {
+	patternMethod, pattern, _ := strings.Cut(key, " ")
+	return matchCall(patternMethod, pattern, method, p)
+}
+
+func matchCall(patternMethod, pattern, method, p string) bool {
+	if patternMethod != "*" && !strings.EqualFold(patternMethod, method) {
+		return false
+	}
+	matched, err := path.Match(strings.Trim(pattern, "/"), p)
+	return err == nil && matched
+}
+
+func dataResponse(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"data": data}
+}
+
+func errorResponse(status int, errors ...string) (int, map[string]interface{}) {
+	if errors == nil {
+		errors = []string{}
+	}
+	return status, map[string]interface{}{"errors": errors}
+}
+
+func permissionDenied() (int, map[string]interface{}) {
+	return errorResponse(http.StatusForbidden, "permission denied")
+}
+
+func writeErrors(w http.ResponseWriter, status int, errors ...string) {
+	_, response := errorResponse(status, errors...)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
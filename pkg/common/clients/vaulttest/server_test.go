@@ -0,0 +1,148 @@
+package vaulttest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/clients"
+	"github.com/ydataai/go-core/pkg/common/clients/vaulttest"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+func newVaultClient(t *testing.T, server *vaulttest.Server, authenticator clients.Authenticator) *clients.VaultClient {
+	vc, err := clients.NewVaultClient(logging.NewLogger(logging.LoggerConfiguration{}),
+		clients.VaultClientConfiguration{VaultURL: server.URL}, "app", authenticator)
+	require.NoError(t, err)
+	t.Cleanup(vc.Close)
+	return vc
+}
+
+func TestServerKV(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("secret", vaulttest.KVv2)
+	server.Mount("kv", vaulttest.KVv1)
+
+	vc := newVaultClient(t, server, clients.NewLocalAuthenticator(vaulttest.RootToken))
+	ctx := context.Background()
+
+	_, err := vc.KVPut(ctx, "secret", "apps/web", map[string]interface{}{"password": "first"})
+	require.NoError(t, err)
+	_, err = vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "second"}, 1)
+	require.NoError(t, err)
+	_, err = vc.KVPutCAS(ctx, "secret", "apps/web", map[string]interface{}{"password": "third"}, 1)
+	assert.ErrorIs(t, err, clients.ErrCheckAndSetMismatch)
+
+	secret, err := vc.KVGetVersion(ctx, "secret", "apps/web", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "first", secret.Data["password"])
+
+	require.NoError(t, vc.KVDelete(ctx, "secret", "apps/web"))
+	secret, err = vc.KVGet(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Nil(t, secret.Data)
+	require.NoError(t, vc.KVUndelete(ctx, "secret", "apps/web", 2))
+
+	metadata, err := vc.KVGetMetadata(ctx, "secret", "apps/web")
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.CurrentVersion)
+
+	_, err = vc.KVPut(ctx, "secret", "apps/api/db", map[string]interface{}{"password": "db"})
+	require.NoError(t, err)
+	paths, err := vc.KVListRecursive(ctx, "secret", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"apps/web", "apps/api/db"}, paths)
+
+	_, err = vc.KVGet(ctx, "secret", "missing")
+	assert.ErrorIs(t, err, clients.ErrSecretNotFound)
+
+	// KV v1 doesn't support PATCH, so Patch falls back to read and write
+	require.NoError(t, vc.Put("kv/app", map[string]interface{}{"a": "1"}))
+	require.NoError(t, vc.Patch("kv/app", map[string]interface{}{"b": "2"}))
+	data, err := vc.Get("kv/app")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, data)
+	listed, err := vc.List("kv/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"keys": []interface{}{"app"}}, listed)
+
+	assert.True(t, vc.CheckIfEngineExists("secret"))
+	assert.False(t, vc.CheckIfEngineExists("missing"))
+}
+
+func TestServerTransit(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("transit", vaulttest.Transit)
+
+	vc := newVaultClient(t, server, clients.NewLocalAuthenticator(vaulttest.RootToken))
+	ctx := context.Background()
+
+	ciphertext, err := vc.TransitEncrypt(ctx, "transit", "app", []byte("secret"), []byte("tenant"))
+	require.NoError(t, err)
+	plaintext, err := vc.TransitDecrypt(ctx, "transit", "app", ciphertext, []byte("tenant"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+	_, err = vc.TransitDecrypt(ctx, "transit", "app", ciphertext, []byte("other"))
+	assert.Error(t, err)
+
+	hmac, err := vc.TransitHMAC(ctx, "transit", "app", []byte("input"), "")
+	require.NoError(t, err)
+	valid, err := vc.TransitVerifyHMAC(ctx, "transit", "app", []byte("input"), hmac, "")
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestServerLeasesAndPKI(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("database", vaulttest.Database)
+	server.Mount("pki", vaulttest.PKI)
+
+	vc := newVaultClient(t, server, clients.NewLocalAuthenticator(vaulttest.RootToken))
+	ctx := context.Background()
+
+	manager := clients.NewLeaseManager(vc)
+	secret, err := manager.Request(ctx, "database/creds/app")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(vaulttest.DefaultLeaseTTL), secret.ExpiresAt(), 5*time.Second)
+	assert.Equal(t, []string{secret.LeaseID()}, server.Leases())
+
+	renewed, err := vc.RenewLease(ctx, secret.LeaseID(), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 60, renewed.LeaseDuration)
+	require.NoError(t, manager.Revoke(ctx, secret))
+	assert.Empty(t, server.Leases())
+	_, err = vc.RenewLease(ctx, secret.LeaseID(), 0)
+	assert.Error(t, err)
+
+	certificate, err := vc.IssueCertificate(ctx, "pki", "web", clients.CertificateRequest{CommonName: "app.local", TTL: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), certificate.Leaf.SerialNumber.Int64())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), certificate.Leaf.NotAfter, 5*time.Second)
+}
+
+func TestServerFailuresAndCalls(t *testing.T) {
+	server := vaulttest.NewServer()
+	defer server.Close()
+	server.Mount("secret", vaulttest.KVv2)
+	server.EnableAuth("approle", vaulttest.AuthAppRole)
+
+	vc := newVaultClient(t, server, clients.NewAppRoleAuthenticator("approle", "role-id", "", "", ""))
+	ctx := context.Background()
+
+	server.FailOnce(http.MethodGet, "secret/data/*", http.StatusForbidden, "permission denied")
+	_, err := vc.KVGet(ctx, "secret", "app")
+	assert.ErrorIs(t, err, clients.ErrPermissionDenied)
+	_, err = vc.KVGet(ctx, "secret", "app")
+	assert.ErrorIs(t, err, clients.ErrSecretNotFound)
+
+	calls := server.CallsTo(http.MethodGet, "secret/data/app")
+	require.Len(t, calls, 2)
+	assert.NotEqual(t, vaulttest.RootToken, calls[0].Token)
+	assert.Len(t, server.CallsTo("*", "auth/approle/login"), 1)
+}
@@ -0,0 +1,144 @@
+package vaulttest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleMounts enables, disables and tunes secrets engines. Must be called with the lock held.
+func (s *Server) handleMounts(call Call) (int, map[string]interface{}) {
+	p := strings.TrimPrefix(call.Path, "sys/mounts/")
+	if mountPath, ok := strings.CutSuffix(p, "/tune"); ok {
+		return s.handleTune(call, strings.Trim(mountPath, "/"))
+	}
+	p = strings.Trim(p, "/")
+
+	switch call.Method {
+	case http.MethodPost, http.MethodPut:
+		if _, ok := s.mounts[p]; ok {
+			return errorResponse(http.StatusBadRequest, fmt.Sprintf("path is already in use at %s/", p))
+		}
+		engine, _ := call.Body["type"].(string)
+		options, _ := call.Body["options"].(map[string]interface{})
+		switch {
+		case engine == KVv1 && options["version"] == "2":
+			engine = KVv2
+		case engine != KVv1 && engine != KVv2 && engine != Transit && engine != Database && engine != PKI:
+			return errorResponse(http.StatusBadRequest, fmt.Sprintf("plugin not found in the catalog: %s", engine))
+		}
+		m := newMount(engine)
+		m.description, _ = call.Body["description"].(string)
+		m.options = options
+		if config, ok := call.Body["config"].(map[string]interface{}); ok {
+			m.defaultLeaseTTL, _ = config["default_lease_ttl"].(string)
+			m.maxLeaseTTL, _ = config["max_lease_ttl"].(string)
+		}
+		s.mounts[p] = m
+		return http.StatusNoContent, nil
+	case http.MethodDelete:
+		delete(s.mounts, p)
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+func (s *Server) handleTune(call Call, p string) (int, map[string]interface{}) {
+	m, ok := s.mounts[p]
+	if !ok {
+		return errorResponse(http.StatusBadRequest, "cannot fetch sysview for path "+p+"/")
+	}
+
+	switch call.Method {
+	case http.MethodGet:
+		return http.StatusOK, dataResponse(map[string]interface{}{
+			"description":       m.description,
+			"default_lease_ttl": ttlSeconds(m.defaultLeaseTTL),
+			"max_lease_ttl":     ttlSeconds(m.maxLeaseTTL),
+			"options":           m.options,
+		})
+	case http.MethodPost, http.MethodPut:
+		if description, ok := call.Body["description"].(string); ok {
+			m.description = description
+		}
+		if ttl, ok := call.Body["default_lease_ttl"].(string); ok {
+			m.defaultLeaseTTL = ttl
+		}
+		if ttl, ok := call.Body["max_lease_ttl"].(string); ok {
+			m.maxLeaseTTL = ttl
+		}
+		if options, ok := call.Body["options"].(map[string]interface{}); ok {
+			m.options = options
+		}
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+// handlePolicies reads, writes and deletes ACL policies. Must be called with the lock held.
+func (s *Server) handlePolicies(call Call) (int, map[string]interface{}) {
+	name := strings.TrimPrefix(strings.TrimPrefix(call.Path, "sys/policies/acl/"), "sys/policy/")
+
+	switch call.Method {
+	case http.MethodGet:
+		rules, ok := s.policies[name]
+		if !ok {
+			return errorResponse(http.StatusNotFound)
+		}
+		return http.StatusOK, dataResponse(map[string]interface{}{"name": name, "policy": rules, "rules": rules})
+	case http.MethodPost, http.MethodPut:
+		rules, _ := call.Body["policy"].(string)
+		if rules == "" {
+			rules, _ = call.Body["rules"].(string)
+		}
+		if rules == "" {
+			return errorResponse(http.StatusBadRequest, "'policy' parameter not supplied or empty")
+		}
+		s.policies[name] = rules
+		return http.StatusNoContent, nil
+	case http.MethodDelete:
+		delete(s.policies, name)
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+// handleRoles reads, writes and deletes the roles of the enabled auth methods. Must be called with the lock held.
+func (s *Server) handleRoles(call Call) (int, map[string]interface{}) {
+	mountPath, name, ok := strings.Cut(strings.TrimPrefix(call.Path, "auth/"), "/role/")
+	if _, enabled := s.auths[mountPath]; !ok || !enabled || name == "" {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+	}
+	key := mountPath + "/" + name
+
+	switch call.Method {
+	case http.MethodGet:
+		role, ok := s.roles[key]
+		if !ok {
+			return errorResponse(http.StatusNotFound)
+		}
+		return http.StatusOK, dataResponse(role)
+	case http.MethodPost, http.MethodPut:
+		s.roles[key] = call.Body
+		return http.StatusNoContent, nil
+	case http.MethodDelete:
+		delete(s.roles, key)
+		return http.StatusNoContent, nil
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+}
+
+// defaultMountTTL is the default and max lease TTL of the mounts, as the system defaults of Vault.
+const defaultMountTTL = 32 * 24 * time.Hour
+
+// ttlSeconds converts the TTLs written as durations, e.g. 3600s, to the seconds returned by Vault.
+func ttlSeconds(ttl string) int {
+	if ttl == "" {
+		return int(defaultMountTTL.Seconds())
+	}
+	if d, err := time.ParseDuration(ttl); err == nil {
+		return int(d.Seconds())
+	}
+	return toInt(ttl)
+}
@@ -0,0 +1,181 @@
+package vaulttest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// transitKey holds a random secret per version. The fake encryption only encodes the plaintext
+// along with the derivation context, it must never be used outside of tests.
+type transitKey struct {
+	versions [][]byte
+}
+
+func newTransitKey() *transitKey {
+	k := &transitKey{}
+	k.rotate()
+	return k
+}
+
+func (k *transitKey) rotate() {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	k.versions = append(k.versions, secret)
+}
+
+func (k *transitKey) latest() int {
+	return len(k.versions)
+}
+
+func handleTransit(m *mount, call Call, rest string) (int, map[string]interface{}) {
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || (call.Method != http.MethodPost && call.Method != http.MethodPut && call.Method != http.MethodGet) {
+		return errorResponse(http.StatusMethodNotAllowed, "unsupported operation")
+	}
+	operation, name := parts[0], parts[1]
+
+	key, ok := m.keys[name]
+	if !ok {
+		// as Vault, keys are created on the first encryption
+		if operation != "encrypt" && operation != "keys" {
+			return errorResponse(http.StatusBadRequest, "encryption key not found")
+		}
+		key = newTransitKey()
+		m.keys[name] = key
+	}
+
+	switch operation {
+	case "keys":
+		if len(parts) == 3 && parts[2] == "rotate" {
+			key.rotate()
+		}
+		return http.StatusOK, dataResponse(map[string]interface{}{"name": name, "latest_version": key.latest()})
+	case "encrypt":
+		return transitItems(call.Body, func(item map[string]interface{}) (map[string]interface{}, error) {
+			plaintext, _ := item["plaintext"].(string)
+			context, _ := item["context"].(string)
+			return map[string]interface{}{"ciphertext": key.encrypt(key.latest(), plaintext, context)}, nil
+		})
+	case "decrypt":
+		return transitItems(call.Body, func(item map[string]interface{}) (map[string]interface{}, error) {
+			ciphertext, _ := item["ciphertext"].(string)
+			context, _ := item["context"].(string)
+			plaintext, err := key.decrypt(ciphertext, context)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"plaintext": plaintext}, nil
+		})
+	case "rewrap":
+		return transitItems(call.Body, func(item map[string]interface{}) (map[string]interface{}, error) {
+			ciphertext, _ := item["ciphertext"].(string)
+			context, _ := item["context"].(string)
+			plaintext, err := key.decrypt(ciphertext, context)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"ciphertext": key.encrypt(key.latest(), plaintext, context)}, nil
+		})
+	case "sign", "hmac":
+		field := map[string]string{"sign": "signature", "hmac": "hmac"}[operation]
+		input, _ := call.Body["input"].(string)
+		return http.StatusOK, dataResponse(map[string]interface{}{field: key.mac(key.latest(), input)})
+	case "verify":
+		input, _ := call.Body["input"].(string)
+		signature, _ := call.Body["signature"].(string)
+		if signature == "" {
+			signature, _ = call.Body["hmac"].(string)
+		}
+		version, _, err := parseCiphertext(signature)
+		if err != nil || version > key.latest() {
+			return errorResponse(http.StatusBadRequest, "invalid signature")
+		}
+		valid := hmac.Equal([]byte(signature), []byte(key.mac(version, input)))
+		return http.StatusOK, dataResponse(map[string]interface{}{"valid": valid})
+	}
+	return errorResponse(http.StatusNotFound, fmt.Sprintf("no handler for route %q. route entry not found.", call.Path))
+}
+
+// transitItems applies the operation to the batch_input of the body, or to the body itself.
+func transitItems(body map[string]interface{}, operation func(map[string]interface{}) (map[string]interface{}, error)) (int, map[string]interface{}) {
+	batch, isBatch := body["batch_input"].([]interface{})
+	if !isBatch {
+		result, err := operation(body)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, err.Error())
+		}
+		return http.StatusOK, dataResponse(result)
+	}
+
+	results := make([]interface{}, len(batch))
+	failed := 0
+	for i, rawItem := range batch {
+		item, _ := rawItem.(map[string]interface{})
+		result, err := operation(item)
+		if err != nil {
+			result = map[string]interface{}{"error": err.Error()}
+			failed++
+		}
+		results[i] = result
+	}
+
+	// as Vault, a batch with failed items is a bad request, unless some succeeded and the caller
+	// asked for another status code
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusBadRequest
+		if code, ok := body["partial_failure_response_code"].(float64); ok && failed < len(batch) {
+			status = int(code)
+		}
+	}
+	return status, dataResponse(map[string]interface{}{"batch_results": results})
+}
+
+func (k *transitKey) encrypt(version int, plaintext, context string) string {
+	payload := base64.StdEncoding.EncodeToString([]byte(context + ":" + plaintext))
+	return fmt.Sprintf("vault:v%d:%s", version, payload)
+}
+
+func (k *transitKey) decrypt(ciphertext, context string) (string, error) {
+	version, payload, err := parseCiphertext(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if version > k.latest() {
+		return "", errors.New("invalid ciphertext: key version not found")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %v", err)
+	}
+	encryptedContext, plaintext, ok := strings.Cut(string(decoded), ":")
+	if !ok || encryptedContext != context {
+		return "", errors.New("cipher: message authentication failed")
+	}
+	return plaintext, nil
+}
+
+func (k *transitKey) mac(version int, input string) string {
+	h := hmac.New(sha256.New, k.versions[version-1])
+	h.Write([]byte(input))
+	return fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+}
+
+func parseCiphertext(ciphertext string) (int, string, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return 0, "", errors.New("invalid ciphertext: no prefix")
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil || version < 1 {
+		return 0, "", errors.New("invalid ciphertext: invalid key version")
+	}
+	return version, parts[2], nil
+}
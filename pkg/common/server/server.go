@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -39,6 +40,7 @@ type server struct {
 	router *gin.Engine
 
 	readyzFunc func() bool
+	tlsConfig  *tls.Config
 }
 
 // NewServer initializes a server
@@ -60,6 +62,14 @@ func NewServer(logger logging.Logger, configuration HTTPServerConfiguration) Ser
 	return s
 }
 
+// NewServerWithTLSConfig initializes a server which RunSecurely uses the TLS config instead of the certificate
+// files of the configuration, e.g. to serve certificates rotated without a restart through GetCertificate.
+func NewServerWithTLSConfig(logger logging.Logger, configuration HTTPServerConfiguration, tlsConfig *tls.Config) Server {
+	s := NewServer(logger, configuration).(*server)
+	s.tlsConfig = tlsConfig
+	return s
+}
+
 func (s *server) Router() *gin.Engine {
 	return s.router
 }
@@ -88,7 +98,11 @@ func (s *server) RunSecurely(ctx context.Context, readyCallbacks ...func()) {
 
 	go func() {
 		s.logger.Infof("Server Running on [%v:%v]", s.configuration.Host, s.configuration.Port)
-		if err := s.httpServer.ListenAndServeTLS(s.configuration.CertificateFile, s.configuration.CertificateKeyFile); err != http.ErrServerClosed {
+		certificateFile, certificateKeyFile := s.configuration.CertificateFile, s.configuration.CertificateKeyFile
+		if s.tlsConfig != nil && (s.tlsConfig.GetCertificate != nil || len(s.tlsConfig.Certificates) > 0) {
+			certificateFile, certificateKeyFile = "", ""
+		}
+		if err := s.httpServer.ListenAndServeTLS(certificateFile, certificateKeyFile); err != http.ErrServerClosed {
 			s.logger.Errorf("unexpected error while running server %v", err.Error())
 		}
 	}()
@@ -119,8 +133,9 @@ func (s *server) AddReadyz(status *func() bool, urls ...string) {
 
 func (s *server) httpServerSetup() {
 	s.httpServer = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.configuration.Host, s.configuration.Port),
-		Handler: s.router,
+		Addr:      fmt.Sprintf("%s:%d", s.configuration.Host, s.configuration.Port),
+		Handler:   s.router,
+		TLSConfig: s.tlsConfig,
 	}
 }
 
@@ -7,10 +7,13 @@ import (
 	"time"
 )
 
-var defaultHTTPClient *http.Client
+var (
+	defaultTransport  *http.Transport
+	defaultHTTPClient *http.Client
+)
 
 func init() {
-	defaultTransport := &http.Transport{
+	defaultTransport = &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
@@ -1,6 +1,9 @@
 package http
 
-import "net/http"
+import (
+	"crypto/tls"
+	"net/http"
+)
 
 type pipeline struct {
 	*http.Client
@@ -14,6 +17,14 @@ func NewPipeline() Pipeline {
 	return pipeline{defaultHTTPClient}
 }
 
+// NewPipelineWithTLSConfig returns a Pipeline using the TLS config, e.g. to present client certificates rotated
+// without a restart through GetClientCertificate. The other settings are the ones of the default pipeline.
+func NewPipelineWithTLSConfig(tlsConfig *tls.Config) Pipeline {
+	transport := defaultTransport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	return pipeline{&http.Client{Transport: transport}}
+}
+
 func (p pipeline) Do(req *Request) (*Response, error) {
 	resp, err := p.Client.Do(req.Request)
 	if err != nil {
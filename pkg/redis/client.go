@@ -14,7 +14,19 @@ import (
 type RedisClient interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Exists(ctx context.Context, keys ...string) *redis.IntCmd
+
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd
+	TTL(ctx context.Context, key string) *redis.DurationCmd
+	Persist(ctx context.Context, key string) *redis.BoolCmd
+
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd
+	Decr(ctx context.Context, key string) *redis.IntCmd
+	DecrBy(ctx context.Context, key string, decrement int64) *redis.IntCmd
 
 	JSONGet(ctx context.Context, key string, paths ...string) *redis.JSONCmd
 	JSONSet(ctx context.Context, key, path string, value interface{}) *redis.StatusCmd
@@ -22,9 +34,46 @@ type RedisClient interface {
 
 	HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
 	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd
+	HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd
+	HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd
+
+	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
+	SIsMember(ctx context.Context, key string, member interface{}) *redis.BoolCmd
+	SCard(ctx context.Context, key string) *redis.IntCmd
+
+	ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	ZScore(ctx context.Context, key, member string) *redis.FloatCmd
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) *redis.FloatCmd
+	ZCard(ctx context.Context, key string) *redis.IntCmd
+	ZCount(ctx context.Context, key, min, max string) *redis.IntCmd
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd
+	ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd
+	ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd
+
+	// Scan iterates over the keys matching the pattern. On a cluster, every master shard is scanned.
+	Scan(ctx context.Context, match string, count int64) ScanIterator
+	HScan(ctx context.Context, key string, match string, count int64) ScanIterator
+	SScan(ctx context.Context, key string, match string, count int64) ScanIterator
+	ZScan(ctx context.Context, key string, match string, count int64) ScanIterator
+
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error
+
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd
+	ScriptLoad(ctx context.Context, script string) *redis.StringCmd
 
 	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
 	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+	PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub
 	Ping(ctx context.Context) *redis.StatusCmd
 }
 
@@ -97,7 +146,7 @@ func newRedisSingleNodeClient(config RedisConfiguration) RedisClient {
 		})}
 }
 
-func (c redisClientImpl) get() RedisClient {
+func (c redisClientImpl) get() redis.UniversalClient {
 	if c.cluster != nil {
 		return c.cluster
 	}
@@ -112,10 +161,50 @@ func (c redisClientImpl) Set(ctx context.Context, key string, value interface{},
 	return c.get().Set(ctx, key, value, expiration)
 }
 
+func (c redisClientImpl) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	return c.get().SetNX(ctx, key, value, expiration)
+}
+
 func (c redisClientImpl) Del(ctx context.Context, keys ...string) *redis.IntCmd {
 	return c.get().Del(ctx, keys...)
 }
 
+func (c redisClientImpl) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	return c.get().Exists(ctx, keys...)
+}
+
+func (c redisClientImpl) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return c.get().Expire(ctx, key, expiration)
+}
+
+func (c redisClientImpl) ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd {
+	return c.get().ExpireAt(ctx, key, tm)
+}
+
+func (c redisClientImpl) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	return c.get().TTL(ctx, key)
+}
+
+func (c redisClientImpl) Persist(ctx context.Context, key string) *redis.BoolCmd {
+	return c.get().Persist(ctx, key)
+}
+
+func (c redisClientImpl) Incr(ctx context.Context, key string) *redis.IntCmd {
+	return c.get().Incr(ctx, key)
+}
+
+func (c redisClientImpl) IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd {
+	return c.get().IncrBy(ctx, key, value)
+}
+
+func (c redisClientImpl) Decr(ctx context.Context, key string) *redis.IntCmd {
+	return c.get().Decr(ctx, key)
+}
+
+func (c redisClientImpl) DecrBy(ctx context.Context, key string, decrement int64) *redis.IntCmd {
+	return c.get().DecrBy(ctx, key, decrement)
+}
+
 func (c redisClientImpl) JSONGet(ctx context.Context, key string, paths ...string) *redis.JSONCmd {
 	return c.get().JSONGet(ctx, key, paths...)
 }
@@ -136,6 +225,129 @@ func (c redisClientImpl) HGet(ctx context.Context, key, field string) *redis.Str
 	return c.get().HGet(ctx, key, field)
 }
 
+func (c redisClientImpl) HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd {
+	return c.get().HGetAll(ctx, key)
+}
+
+func (c redisClientImpl) HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd {
+	return c.get().HDel(ctx, key, fields...)
+}
+
+func (c redisClientImpl) HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd {
+	return c.get().HIncrBy(ctx, key, field, incr)
+}
+
+func (c redisClientImpl) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return c.get().SAdd(ctx, key, members...)
+}
+
+func (c redisClientImpl) SRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return c.get().SRem(ctx, key, members...)
+}
+
+func (c redisClientImpl) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.get().SMembers(ctx, key)
+}
+
+func (c redisClientImpl) SIsMember(ctx context.Context, key string, member interface{}) *redis.BoolCmd {
+	return c.get().SIsMember(ctx, key, member)
+}
+
+func (c redisClientImpl) SCard(ctx context.Context, key string) *redis.IntCmd {
+	return c.get().SCard(ctx, key)
+}
+
+func (c redisClientImpl) ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	return c.get().ZAdd(ctx, key, members...)
+}
+
+func (c redisClientImpl) ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return c.get().ZRem(ctx, key, members...)
+}
+
+func (c redisClientImpl) ZScore(ctx context.Context, key, member string) *redis.FloatCmd {
+	return c.get().ZScore(ctx, key, member)
+}
+
+func (c redisClientImpl) ZIncrBy(ctx context.Context, key string, increment float64, member string) *redis.FloatCmd {
+	return c.get().ZIncrBy(ctx, key, increment, member)
+}
+
+func (c redisClientImpl) ZCard(ctx context.Context, key string) *redis.IntCmd {
+	return c.get().ZCard(ctx, key)
+}
+
+func (c redisClientImpl) ZCount(ctx context.Context, key, min, max string) *redis.IntCmd {
+	return c.get().ZCount(ctx, key, min, max)
+}
+
+func (c redisClientImpl) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	return c.get().ZRangeWithScores(ctx, key, start, stop)
+}
+
+func (c redisClientImpl) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.get().ZRangeByScore(ctx, key, opt)
+}
+
+func (c redisClientImpl) ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd {
+	return c.get().ZRemRangeByScore(ctx, key, min, max)
+}
+
+func (c redisClientImpl) Scan(ctx context.Context, match string, count int64) ScanIterator {
+	if c.cluster != nil {
+		return newClusterScanIterator(ctx, c.cluster, match, count)
+	}
+	return c.client.Scan(ctx, 0, match, count).Iterator()
+}
+
+func (c redisClientImpl) HScan(ctx context.Context, key string, match string, count int64) ScanIterator {
+	return c.get().HScan(ctx, key, 0, match, count).Iterator()
+}
+
+func (c redisClientImpl) SScan(ctx context.Context, key string, match string, count int64) ScanIterator {
+	return c.get().SScan(ctx, key, 0, match, count).Iterator()
+}
+
+func (c redisClientImpl) ZScan(ctx context.Context, key string, match string, count int64) ScanIterator {
+	return c.get().ZScan(ctx, key, 0, match, count).Iterator()
+}
+
+func (c redisClientImpl) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.get().Pipelined(ctx, fn)
+}
+
+func (c redisClientImpl) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.get().TxPipelined(ctx, fn)
+}
+
+func (c redisClientImpl) Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	return c.get().Watch(ctx, fn, keys...)
+}
+
+func (c redisClientImpl) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.get().Eval(ctx, script, keys, args...)
+}
+
+func (c redisClientImpl) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.get().EvalSha(ctx, sha1, keys, args...)
+}
+
+func (c redisClientImpl) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.get().EvalRO(ctx, script, keys, args...)
+}
+
+func (c redisClientImpl) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.get().EvalShaRO(ctx, sha1, keys, args...)
+}
+
+func (c redisClientImpl) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	return c.get().ScriptExists(ctx, hashes...)
+}
+
+func (c redisClientImpl) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	return c.get().ScriptLoad(ctx, script)
+}
+
 func (c redisClientImpl) Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd {
 	return c.get().Publish(ctx, channel, message)
 }
@@ -144,6 +356,10 @@ func (c redisClientImpl) Subscribe(ctx context.Context, channels ...string) *red
 	return c.get().Subscribe(ctx, channels...)
 }
 
+func (c redisClientImpl) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	return c.get().PSubscribe(ctx, patterns...)
+}
+
 func (c redisClientImpl) Ping(ctx context.Context) *redis.StatusCmd {
 	return c.get().Ping(ctx)
 }
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrEventBusClosed is returned when publishing or subscribing on a closed EventBus.
+var ErrEventBusClosed = errors.New("event bus is closed")
+
+// EventHandler handles an event received on a topic. Events of a subscription are handled one at a time,
+// in the order they were received.
+type EventHandler[T any] func(ctx context.Context, topic string, event T)
+
+// Subscription represents an active subscription of an EventBus.
+type Subscription interface {
+	// Unsubscribe stops the subscription and waits for the handler to return.
+	// It must not be called from the subscription handler.
+	Unsubscribe()
+}
+
+// EventBus publishes and subscribes typed events, encoded as JSON, on named topics.
+//
+//	bus := redis.NewRedisEventBus[JobFinished](client, logger)
+//	defer bus.Close()
+//
+//	sub, err := bus.Subscribe(ctx, "jobs.finished", func(ctx context.Context, topic string, event JobFinished) {
+//		...
+//	})
+//	err = bus.Publish(ctx, "jobs.finished", JobFinished{ID: id})
+type EventBus[T any] interface {
+	// Publish sends the event to all the subscribers of the topic.
+	Publish(ctx context.Context, topic string, event T) error
+	// Subscribe calls the handler for each event published on the topic, until the context is done
+	// or the subscription is stopped.
+	Subscribe(ctx context.Context, topic string, handler EventHandler[T]) (Subscription, error)
+	// PSubscribe is like Subscribe, for all the topics matching a glob-style pattern (e.g. "jobs.*").
+	PSubscribe(ctx context.Context, pattern string, handler EventHandler[T]) (Subscription, error)
+	// Close stops all the subscriptions and waits for their handlers to return.
+	Close() error
+}
@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// inProcessEventBufferSize is the number of events queued per subscription before Publish blocks.
+const inProcessEventBufferSize = 256
+
+type inProcessEventBus[T any] struct {
+	logger logging.Logger
+
+	mu            sync.RWMutex
+	subscriptions map[*inProcessSubscription]struct{}
+	closed        bool
+}
+
+type inProcessSubscription struct {
+	topic   string
+	pattern bool
+
+	events chan inProcessEvent
+	cancel context.CancelFunc
+	ctx    context.Context
+	done   chan struct{}
+}
+
+type inProcessEvent struct {
+	topic   string
+	payload []byte
+}
+
+// NewInProcessEventBus creates an EventBus that delivers events to the subscribers of the same process.
+// It has the same semantics as the Redis one, events are encoded as JSON so handlers never share values
+// with the publisher, which makes it suitable for tests and single-replica deployments.
+func NewInProcessEventBus[T any](logger logging.Logger) EventBus[T] {
+	return &inProcessEventBus[T]{
+		logger:        logger,
+		subscriptions: map[*inProcessSubscription]struct{}{},
+	}
+}
+
+func (b *inProcessEventBus[T]) Publish(ctx context.Context, topic string, event T) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrEventBusClosed
+	}
+	var receivers []*inProcessSubscription
+	for sub := range b.subscriptions {
+		if sub.matches(topic) {
+			receivers = append(receivers, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range receivers {
+		select {
+		case sub.events <- inProcessEvent{topic: topic, payload: payload}:
+		case <-sub.ctx.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *inProcessEventBus[T]) Subscribe(ctx context.Context, topic string, handler EventHandler[T]) (Subscription, error) {
+	return b.subscribe(ctx, topic, false, handler)
+}
+
+func (b *inProcessEventBus[T]) PSubscribe(ctx context.Context, pattern string, handler EventHandler[T]) (Subscription, error) {
+	return b.subscribe(ctx, pattern, true, handler)
+}
+
+func (b *inProcessEventBus[T]) subscribe(ctx context.Context, topic string, pattern bool, handler EventHandler[T]) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrEventBusClosed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &inProcessSubscription{
+		topic:   topic,
+		pattern: pattern,
+		events:  make(chan inProcessEvent, inProcessEventBufferSize),
+		cancel:  cancel,
+		ctx:     ctx,
+		done:    make(chan struct{}),
+	}
+	b.subscriptions[sub] = struct{}{}
+
+	go b.receive(sub, handler)
+
+	return sub, nil
+}
+
+func (b *inProcessEventBus[T]) receive(sub *inProcessSubscription, handler EventHandler[T]) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscriptions, sub)
+		b.mu.Unlock()
+		close(sub.done)
+	}()
+
+	for {
+		select {
+		case <-sub.ctx.Done():
+			return
+		case e := <-sub.events:
+			var event T
+			if err := json.Unmarshal(e.payload, &event); err != nil {
+				b.logger.Errorf("[EventBus] unable to decode event from topic '%s'. Err: %v", e.topic, err)
+				continue
+			}
+			handler(sub.ctx, e.topic, event)
+		}
+	}
+}
+
+func (b *inProcessEventBus[T]) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	subscriptions := make([]*inProcessSubscription, 0, len(b.subscriptions))
+	for sub := range b.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		sub.Unsubscribe()
+	}
+	return nil
+}
+
+func (s *inProcessSubscription) matches(topic string) bool {
+	if s.pattern {
+		return MatchPattern(s.topic, topic)
+	}
+	return s.topic == topic
+}
+
+func (s *inProcessSubscription) Unsubscribe() {
+	s.cancel()
+	<-s.done
+}
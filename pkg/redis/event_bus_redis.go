@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+const (
+	eventBusMinBackoff = 100 * time.Millisecond
+	eventBusMaxBackoff = 30 * time.Second
+)
+
+type redisEventBus[T any] struct {
+	client RedisClient
+	logger logging.Logger
+
+	mu            sync.Mutex
+	subscriptions map[*redisSubscription]struct{}
+	closed        bool
+}
+
+type redisSubscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisEventBus creates an EventBus backed by Redis pub/sub. Subscriptions resubscribe automatically
+// after a connection loss, with an exponential backoff between attempts.
+func NewRedisEventBus[T any](client RedisClient, logger logging.Logger) EventBus[T] {
+	return &redisEventBus[T]{
+		client:        client,
+		logger:        logger,
+		subscriptions: map[*redisSubscription]struct{}{},
+	}
+}
+
+func (b *redisEventBus[T]) Publish(ctx context.Context, topic string, event T) error {
+	if b.isClosed() {
+		return ErrEventBusClosed
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, topic, payload).Err()
+}
+
+func (b *redisEventBus[T]) Subscribe(ctx context.Context, topic string, handler EventHandler[T]) (Subscription, error) {
+	return b.subscribe(ctx, b.client.Subscribe(ctx, topic), handler)
+}
+
+func (b *redisEventBus[T]) PSubscribe(ctx context.Context, pattern string, handler EventHandler[T]) (Subscription, error) {
+	return b.subscribe(ctx, b.client.PSubscribe(ctx, pattern), handler)
+}
+
+func (b *redisEventBus[T]) subscribe(ctx context.Context, pubsub *redis.PubSub, handler EventHandler[T]) (Subscription, error) {
+	// wait for the subscription to be confirmed, so no event published after Subscribe returns is lost.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		_ = pubsub.Close()
+		return nil, ErrEventBusClosed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &redisSubscription{cancel: cancel, done: make(chan struct{})}
+	b.subscriptions[sub] = struct{}{}
+
+	go func() {
+		// Receive does not watch the context, closing the connection unblocks it.
+		<-ctx.Done()
+		_ = pubsub.Close()
+	}()
+	go b.receive(ctx, pubsub, handler, sub)
+
+	return sub, nil
+}
+
+func (b *redisEventBus[T]) receive(ctx context.Context, pubsub *redis.PubSub, handler EventHandler[T], sub *redisSubscription) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscriptions, sub)
+		b.mu.Unlock()
+		close(sub.done)
+	}()
+
+	backoff := eventBusMinBackoff
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// the next Receive reconnects and resubscribes to the same channels.
+			b.logger.Warnf("[EventBus] receive failed, retrying in %v. Err: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, eventBusMaxBackoff)
+			continue
+		}
+		backoff = eventBusMinBackoff
+
+		if m, ok := msg.(*redis.Message); ok {
+			var event T
+			if err := json.Unmarshal([]byte(m.Payload), &event); err != nil {
+				b.logger.Errorf("[EventBus] unable to decode event from topic '%s'. Err: %v", m.Channel, err)
+				continue
+			}
+			handler(ctx, m.Channel, event)
+		}
+	}
+}
+
+func (b *redisEventBus[T]) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	subscriptions := make([]*redisSubscription, 0, len(b.subscriptions))
+	for sub := range b.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		sub.Unsubscribe()
+	}
+	return nil
+}
+
+func (b *redisEventBus[T]) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func (s *redisSubscription) Unsubscribe() {
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,70 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/redis"
+	"github.com/ydataai/go-core/pkg/redis/redistest"
+)
+
+type jobEvent struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type received struct {
+	topic string
+	event jobEvent
+}
+
+func testEventBus(t *testing.T, bus redis.EventBus[jobEvent]) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer bus.Close()
+
+	exact := make(chan received, 10)
+	_, err := bus.Subscribe(ctx, "jobs.finished", func(_ context.Context, topic string, event jobEvent) {
+		exact <- received{topic, event}
+	})
+	require.NoError(t, err)
+
+	pattern := make(chan received, 10)
+	sub, err := bus.PSubscribe(ctx, "jobs.*", func(_ context.Context, topic string, event jobEvent) {
+		pattern <- received{topic, event}
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(ctx, "jobs.finished", jobEvent{ID: "1", Status: "done"}))
+	require.NoError(t, bus.Publish(ctx, "jobs.started", jobEvent{ID: "2", Status: "running"}))
+
+	assert.Equal(t, received{"jobs.finished", jobEvent{ID: "1", Status: "done"}}, <-exact)
+	assert.Equal(t, received{"jobs.finished", jobEvent{ID: "1", Status: "done"}}, <-pattern)
+	assert.Equal(t, received{"jobs.started", jobEvent{ID: "2", Status: "running"}}, <-pattern)
+
+	sub.Unsubscribe()
+	require.NoError(t, bus.Publish(ctx, "jobs.started", jobEvent{ID: "3"}))
+	select {
+	case r := <-pattern:
+		t.Fatalf("unexpected event after unsubscribe: %v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, bus.Close())
+	assert.ErrorIs(t, bus.Publish(ctx, "jobs.finished", jobEvent{}), redis.ErrEventBusClosed)
+}
+
+func TestRedisEventBus(t *testing.T) {
+	client := redistest.NewClient(nil)
+	defer client.Close()
+
+	testEventBus(t, redis.NewRedisEventBus[jobEvent](client, logging.NewLogger(logging.LoggerConfiguration{})))
+}
+
+func TestInProcessEventBus(t *testing.T) {
+	testEventBus(t, redis.NewInProcessEventBus[jobEvent](logging.NewLogger(logging.LoggerConfiguration{})))
+}
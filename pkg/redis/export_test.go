@@ -0,0 +1,14 @@
+package redis
+
+import "context"
+
+// NewShardsScanIterator scans the clients one after the other, as the master shards of a cluster.
+func NewShardsScanIterator(match string, count int64, shards ...RedisClient) ScanIterator {
+	scans := make([]shardScan, 0, len(shards))
+	for _, shard := range shards {
+		scans = append(scans, func(ctx context.Context) ScanIterator {
+			return shard.Scan(ctx, match, count)
+		})
+	}
+	return newShardsScanIterator(scans...)
+}
@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// LeaderElectionConfiguration represents the configuration of a LeaderElector.
+type LeaderElectionConfiguration struct {
+	// LeaseName identifies the election, all the candidates of the same election must use the same name.
+	LeaseName string `envconfig:"LEADER_ELECTION_LEASE_NAME" required:"true"`
+	// Identity identifies the candidate, defaults to the hostname followed by a random suffix.
+	Identity string `envconfig:"LEADER_ELECTION_IDENTITY"`
+	// LeaseDuration is how long the other candidates wait before taking over a lease that is not renewed.
+	LeaseDuration time.Duration `envconfig:"LEADER_ELECTION_LEASE_DURATION" default:"15s"`
+	// RenewDeadline is how long the leader keeps retrying to renew the lease before giving up leadership.
+	RenewDeadline time.Duration `envconfig:"LEADER_ELECTION_RENEW_DEADLINE" default:"10s"`
+	// RetryPeriod is the interval between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration `envconfig:"LEADER_ELECTION_RETRY_PERIOD" default:"2s"`
+}
+
+// LoadFromEnvVars for LeaderElectionConfiguration.
+func (c *LeaderElectionConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
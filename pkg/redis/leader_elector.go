@@ -0,0 +1,307 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+var (
+	leaderElectionIsLeader = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "redis_leader_election_is_leader",
+			Help: "Whether the candidate is currently the leader (1) or not (0).",
+		},
+		[]string{"lease", "identity"},
+	)
+	leaderElectionTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redis_leader_election_transitions_total",
+			Help: "Number of times the candidate acquired the leadership.",
+		},
+		[]string{"lease", "identity"},
+	)
+	registerLeaderElectionMetrics sync.Once
+)
+
+// acquireLeaseScript starts a new leadership term if the lease is free, or held by a previous term of the
+// candidate, incrementing the fencing token. Terms are extended by renewLeaseScript, which keeps the token.
+//
+//	KEYS[1] lease key, KEYS[2] fencing token counter key
+//	ARGV[1] identity, ARGV[2] lease duration in ms
+//	returns the fencing token, or 0 if the lease is held by another candidate
+var acquireLeaseScript = NewScript("acquire_lease", `
+local current = redis.call('GET', KEYS[1])
+if current then
+	local identity = string.match(current, '^(.*):%d+$')
+	if identity ~= ARGV[1] then
+		return 0
+	end
+end
+local token = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[1], ARGV[1] .. ':' .. token, 'PX', ARGV[2])
+return token
+`)
+
+// renewLeaseScript extends the lease only if it is still held with the same fencing token.
+//
+//	KEYS[1] lease key
+//	ARGV[1] lease value, ARGV[2] lease duration in ms
+var renewLeaseScript = NewScript("renew_lease", `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease only if it is still held with the same fencing token.
+//
+//	KEYS[1] lease key
+//	ARGV[1] lease value
+var releaseLeaseScript = NewScript("release_lease", `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LeaderElector elects a single leader among the replicas sharing the same Redis, using a lease key
+// that the leader renews periodically. Each leadership term gets a monotonically increasing fencing token,
+// that should be passed along with writes to other systems so they can reject writes of stale leaders.
+type LeaderElector struct {
+	client RedisClient
+	config LeaderElectionConfiguration
+	logger logging.Logger
+
+	leaseKey   string
+	counterKey string
+
+	mu       sync.RWMutex
+	isLeader bool
+	token    int64
+}
+
+// NewLeaderElector creates a LeaderElector and registers its metrics in the default Prometheus registry.
+func NewLeaderElector(client RedisClient, config LeaderElectionConfiguration, logger logging.Logger) (*LeaderElector, error) {
+	if config.LeaseName == "" {
+		return nil, errors.New("missing lease name")
+	}
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, errors.New("lease duration must be greater than renew deadline")
+	}
+	if config.RenewDeadline <= config.RetryPeriod || config.RetryPeriod <= 0 {
+		return nil, errors.New("renew deadline must be greater than retry period, which must be positive")
+	}
+	if config.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		config.Identity = fmt.Sprintf("%s_%s", hostname, uuid.New().String())
+	}
+
+	registerLeaderElectionMetrics.Do(func() {
+		prometheus.MustRegister(leaderElectionIsLeader, leaderElectionTransitions)
+	})
+	leaderElectionIsLeader.WithLabelValues(config.LeaseName, config.Identity).Set(0)
+
+	return &LeaderElector{
+		client: client,
+		config: config,
+		logger: logger,
+		// the hash tag keeps both keys in the same cluster slot, as required by the scripts.
+		leaseKey:   fmt.Sprintf("leader-election:{%s}:lease", config.LeaseName),
+		counterKey: fmt.Sprintf("leader-election:{%s}:fencing-token", config.LeaseName),
+	}, nil
+}
+
+// Identity returns the identity of the candidate.
+func (e *LeaderElector) Identity() string {
+	return e.config.Identity
+}
+
+// IsLeader returns whether the candidate currently holds the lease.
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// FencingToken returns the fencing token of the current leadership term, or 0 when not leading.
+func (e *LeaderElector) FencingToken() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !e.isLeader {
+		return 0
+	}
+	return e.token
+}
+
+// Leader returns the identity of the current leader, or an empty string if there is none.
+func (e *LeaderElector) Leader(ctx context.Context) (string, error) {
+	value, err := e.client.Get(ctx, e.leaseKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	identity, _, _ := parseLeaseValue(value)
+	return identity, nil
+}
+
+// Run campaigns for the leadership until the context is done. When the lease is acquired, onStartedLeading
+// is called in a new goroutine with a context that is cancelled once the leadership is lost. Once it returns,
+// onStoppedLeading is called and the candidate campaigns again. When the context is done while leading,
+// the lease is released so another candidate can take over right away.
+func (e *LeaderElector) Run(ctx context.Context, onStartedLeading func(ctx context.Context), onStoppedLeading func()) {
+	e.logger.Infof("[LeaderElection] %s campaigning for lease %s", e.config.Identity, e.config.LeaseName)
+
+	for {
+		token, acquiredAt, ok := e.acquire(ctx)
+		if !ok {
+			return
+		}
+
+		e.setLeader(true, token)
+		e.logger.Infof("[LeaderElection] %s acquired lease %s with fencing token %d",
+			e.config.Identity, e.config.LeaseName, token)
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			onStartedLeading(leaderCtx)
+		}()
+
+		e.renew(ctx, token, acquiredAt)
+
+		// the previous leader must have stopped before campaigning for a new term
+		cancel()
+		<-done
+		e.setLeader(false, 0)
+		e.logger.Infof("[LeaderElection] %s stopped leading lease %s", e.config.Identity, e.config.LeaseName)
+		if onStoppedLeading != nil {
+			onStoppedLeading()
+		}
+
+		if ctx.Err() != nil {
+			e.release(token)
+			return
+		}
+	}
+}
+
+// acquire retries to acquire the lease until it succeeds or the context is done, returning the fencing token
+// and when the successful attempt was sent.
+func (e *LeaderElector) acquire(ctx context.Context) (int64, time.Time, bool) {
+	ticker := time.NewTicker(e.config.RetryPeriod)
+	defer ticker.Stop()
+
+	keys := []string{e.leaseKey, e.counterKey}
+	for {
+		sentAt := time.Now()
+		token, err := acquireLeaseScript.Run(ctx, e.client, keys, e.config.Identity, e.config.LeaseDuration.Milliseconds()).Int64()
+		if err != nil {
+			e.logger.Warnf("[LeaderElection] unable to acquire lease %s. Err: %v", e.config.LeaseName, err)
+		} else if token > 0 {
+			return token, sentAt, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, time.Time{}, false
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew keeps renewing the lease until it is lost, it can't be renewed within the renew deadline
+// or the context is done.
+//
+// The lease was set by Redis after the last successful attempt was sent, so it doesn't expire before the
+// send time plus the lease duration. Every attempt is bounded by the send time of the last successful one
+// plus the renew deadline, which is shorter, so the leadership is given up before another candidate can
+// take over.
+func (e *LeaderElector) renew(ctx context.Context, token int64, lastRenew time.Time) {
+	ticker := time.NewTicker(e.config.RetryPeriod)
+	defer ticker.Stop()
+
+	keys := []string{e.leaseKey}
+	value := leaseValue(e.config.Identity, token)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deadline := lastRenew.Add(e.config.RenewDeadline)
+		sentAt := time.Now()
+		renewCtx, cancel := context.WithDeadline(ctx, deadline)
+		renewed, err := renewLeaseScript.Run(renewCtx, e.client, keys, value, e.config.LeaseDuration.Milliseconds()).Int64()
+		cancel()
+
+		switch {
+		case err == nil && renewed == 1:
+			lastRenew = sentAt
+		case err == nil:
+			e.logger.Warnf("[LeaderElection] lease %s was taken over by another candidate", e.config.LeaseName)
+			return
+		case !time.Now().Before(deadline):
+			e.logger.Errorf("[LeaderElection] unable to renew lease %s within %v. Err: %v",
+				e.config.LeaseName, e.config.RenewDeadline, err)
+			return
+		default:
+			e.logger.Warnf("[LeaderElection] unable to renew lease %s, retrying. Err: %v", e.config.LeaseName, err)
+		}
+	}
+}
+
+func (e *LeaderElector) release(token int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RetryPeriod)
+	defer cancel()
+
+	value := leaseValue(e.config.Identity, token)
+	if err := releaseLeaseScript.Run(ctx, e.client, []string{e.leaseKey}, value).Err(); err != nil {
+		e.logger.Warnf("[LeaderElection] unable to release lease %s. Err: %v", e.config.LeaseName, err)
+	}
+}
+
+func (e *LeaderElector) setLeader(isLeader bool, token int64) {
+	e.mu.Lock()
+	e.isLeader = isLeader
+	e.token = token
+	e.mu.Unlock()
+
+	gauge := leaderElectionIsLeader.WithLabelValues(e.config.LeaseName, e.config.Identity)
+	if isLeader {
+		gauge.Set(1)
+		leaderElectionTransitions.WithLabelValues(e.config.LeaseName, e.config.Identity).Inc()
+	} else {
+		gauge.Set(0)
+	}
+}
+
+func leaseValue(identity string, token int64) string {
+	return fmt.Sprintf("%s:%d", identity, token)
+}
+
+func parseLeaseValue(value string) (string, int64, error) {
+	i := strings.LastIndex(value, ":")
+	if i < 0 {
+		return value, 0, fmt.Errorf("invalid lease value %s", value)
+	}
+	token, err := strconv.ParseInt(value[i+1:], 10, 64)
+	return value[:i], token, err
+}
@@ -0,0 +1,234 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/common/logging"
+	"github.com/ydataai/go-core/pkg/redis"
+	"github.com/ydataai/go-core/pkg/redis/redistest"
+)
+
+const (
+	leaseKey   = "leader-election:{jobs}:lease"
+	counterKey = "leader-election:{jobs}:fencing-token"
+)
+
+// candidate runs a LeaderElector and records its leadership terms.
+type candidate struct {
+	elector *redis.LeaderElector
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu      sync.Mutex
+	events  []string
+	started chan int64
+	stopped chan struct{}
+}
+
+func startCandidate(t *testing.T, client redis.RedisClient, identity string) *candidate {
+	elector, err := redis.NewLeaderElector(client, redis.LeaderElectionConfiguration{
+		LeaseName:     "jobs",
+		Identity:      identity,
+		LeaseDuration: time.Second,
+		RenewDeadline: 500 * time.Millisecond,
+		RetryPeriod:   10 * time.Millisecond,
+	}, logging.NewLogger(logging.LoggerConfiguration{}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &candidate{
+		elector: elector,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		started: make(chan int64, 10),
+		stopped: make(chan struct{}, 10),
+	}
+	go func() {
+		defer close(c.done)
+		elector.Run(ctx, func(ctx context.Context) {
+			c.record("started")
+			c.started <- elector.FencingToken()
+			<-ctx.Done()
+			// the leader takes a while to stop, onStoppedLeading must wait for it
+			time.Sleep(20 * time.Millisecond)
+			c.record("leader returned")
+		}, func() {
+			c.record("stopped")
+			c.stopped <- struct{}{}
+		})
+	}()
+	t.Cleanup(c.stop)
+	return c
+}
+
+func (c *candidate) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *candidate) recorded() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.events...)
+}
+
+func (c *candidate) stop() {
+	c.cancel()
+	<-c.done
+}
+
+// unresponsiveClient makes scripts hang until their context is done once hanging is set.
+type unresponsiveClient struct {
+	*redistest.Client
+	hanging atomic.Bool
+}
+
+func (c *unresponsiveClient) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *goredis.Cmd {
+	if c.hanging.Load() {
+		return c.hang(ctx)
+	}
+	return c.Client.EvalSha(ctx, sha1, keys, args...)
+}
+
+func (c *unresponsiveClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *goredis.Cmd {
+	if c.hanging.Load() {
+		return c.hang(ctx)
+	}
+	return c.Client.Eval(ctx, script, keys, args...)
+}
+
+func (c *unresponsiveClient) hang(ctx context.Context) *goredis.Cmd {
+	<-ctx.Done()
+	cmd := goredis.NewCmd(ctx)
+	cmd.SetErr(ctx.Err())
+	return cmd
+}
+
+func waitStarted(t *testing.T, c *candidate) int64 {
+	select {
+	case token := <-c.started:
+		return token
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the candidate did not start leading")
+		return 0
+	}
+}
+
+func waitStopped(t *testing.T, c *candidate) {
+	select {
+	case <-c.stopped:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the candidate did not stop leading")
+	}
+}
+
+func TestLeaderElectionAcquireRenewRelease(t *testing.T) {
+	ctx := context.Background()
+	clock := redistest.NewFakeClock(time.Now())
+	client := redistest.NewClient(clock)
+	defer client.Close()
+
+	a := startCandidate(t, client, "a")
+	assert.Equal(t, int64(1), waitStarted(t, a))
+	assert.True(t, a.elector.IsLeader())
+	assert.Equal(t, int64(1), a.elector.FencingToken())
+	leader, err := a.elector.Leader(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader)
+
+	// renewals keep the lease and the fencing token past the lease duration
+	for i := 0; i < 3; i++ {
+		clock.Advance(800 * time.Millisecond)
+		assert.Eventually(t, func() bool {
+			return client.TTL(ctx, leaseKey).Val() > 900*time.Millisecond
+		}, time.Second, 5*time.Millisecond)
+	}
+	assert.Equal(t, "a:1", client.Get(ctx, leaseKey).Val())
+	assert.Equal(t, int64(1), a.elector.FencingToken())
+
+	a.stop()
+	assert.Equal(t, []string{"started", "leader returned", "stopped"}, a.recorded())
+	assert.False(t, a.elector.IsLeader())
+	assert.Equal(t, int64(0), a.elector.FencingToken())
+
+	// releasing lets another candidate take over without waiting for the lease to expire
+	assert.Equal(t, int64(0), client.Exists(ctx, leaseKey).Val())
+	b := startCandidate(t, client, "b")
+	assert.Equal(t, int64(2), waitStarted(t, b))
+}
+
+func TestLeaderElectionTakeoverAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	clock := redistest.NewFakeClock(time.Now())
+	client := redistest.NewClient(clock)
+	defer client.Close()
+
+	// a candidate that crashed without releasing the lease
+	require.NoError(t, client.Set(ctx, counterKey, 7, 0).Err())
+	require.NoError(t, client.Set(ctx, leaseKey, "crashed:7", time.Second).Err())
+
+	b := startCandidate(t, client, "b")
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, b.elector.IsLeader())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(8), waitStarted(t, b))
+	assert.Equal(t, "b:8", client.Get(ctx, leaseKey).Val())
+
+	// the lease is taken over by another candidate, so b stops leading before campaigning again
+	require.NoError(t, client.Set(ctx, leaseKey, "c:9", time.Second).Err())
+	require.NoError(t, client.Set(ctx, counterKey, 9, 0).Err())
+	waitStopped(t, b)
+	assert.Equal(t, []string{"started", "leader returned", "stopped"}, b.recorded())
+	assert.False(t, b.elector.IsLeader())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(10), waitStarted(t, b))
+}
+
+func TestLeaderElectionTokenIncrementsOnEveryTerm(t *testing.T) {
+	ctx := context.Background()
+	clock := redistest.NewFakeClock(time.Now())
+	client := redistest.NewClient(clock)
+	defer client.Close()
+
+	// a previous term of the same identity that was not released
+	require.NoError(t, client.Set(ctx, counterKey, 3, 0).Err())
+	require.NoError(t, client.Set(ctx, leaseKey, "a:3", time.Second).Err())
+
+	a := startCandidate(t, client, "a")
+	assert.Equal(t, int64(4), waitStarted(t, a))
+	a.stop()
+
+	a = startCandidate(t, client, "a")
+	assert.Equal(t, int64(5), waitStarted(t, a))
+	assert.Equal(t, "a:5", client.Get(ctx, leaseKey).Val())
+}
+
+func TestLeaderElectionRenewDeadline(t *testing.T) {
+	clock := redistest.NewFakeClock(time.Now())
+	client := &unresponsiveClient{Client: redistest.NewClient(clock)}
+	defer client.Close()
+
+	a := startCandidate(t, client, "a")
+	waitStarted(t, a)
+
+	// the renewals fail for a while and then hang, the deadline still counts from the last successful renewal
+	lastRenew := time.Now()
+	client.FailOn("evalsha", errors.New("connection reset"))
+	time.Sleep(300 * time.Millisecond)
+	client.hanging.Store(true)
+
+	waitStopped(t, a)
+	assert.Less(t, time.Since(lastRenew), 700*time.Millisecond)
+	assert.False(t, a.elector.IsLeader())
+}
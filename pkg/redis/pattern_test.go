@@ -0,0 +1,16 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, MatchPattern("user:*", "user:1"))
+	assert.True(t, MatchPattern("h?llo", "hello"))
+	assert.True(t, MatchPattern("h[a-e]llo", "hello"))
+	assert.False(t, MatchPattern("h[^e]llo", "hello"))
+	assert.True(t, MatchPattern(`a\*`, "a*"))
+	assert.False(t, MatchPattern("user:*", "account:1"))
+}
@@ -0,0 +1,223 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kelseyhightower/envconfig"
+)
+
+// RateLimitAlgorithm defines how the requests are counted.
+type RateLimitAlgorithm string
+
+const (
+	// SlidingWindow allows Limit requests in any window of Period.
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+	// TokenBucket allows bursts of up to Limit requests, refilling the bucket completely in Period.
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+)
+
+// RateLimitConfiguration defines a quota.
+type RateLimitConfiguration struct {
+	// Name identifies the quota, so different quotas applied to the same key are counted separately.
+	Name      string             `envconfig:"RATE_LIMIT_NAME" default:"api"`
+	Limit     int64              `envconfig:"RATE_LIMIT" default:"100"`
+	Period    time.Duration      `envconfig:"RATE_LIMIT_PERIOD" default:"1m"`
+	Algorithm RateLimitAlgorithm `envconfig:"RATE_LIMIT_ALGORITHM" default:"sliding_window"`
+}
+
+// LoadFromEnvVars for RateLimitConfiguration.
+func (c *RateLimitConfiguration) LoadFromEnvVars() error {
+	return envconfig.Process("", c)
+}
+
+// RateLimitResult is the outcome of a rate limit check.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// ResetAfter is the time until the quota is fully available again, when Remaining would be Limit
+	// without new requests.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the request would be allowed. Zero when allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter checks quotas shared by all the replicas using the same Redis.
+type RateLimiter interface {
+	// Allow consumes one request of the quota for the key.
+	Allow(ctx context.Context, key string, limit RateLimitConfiguration) (RateLimitResult, error)
+	// AllowN consumes n requests of the quota for the key, only if all of them are available.
+	// n must be at least 1.
+	AllowN(ctx context.Context, key string, limit RateLimitConfiguration, n int64) (RateLimitResult, error)
+}
+
+// slidingWindowScript keeps a sorted set with the timestamp of each request in the window.
+//
+//	KEYS[1] quota key
+//	ARGV[1] limit, ARGV[2] window in ms, ARGV[3] cost, ARGV[4] unique request id
+//	returns {allowed, remaining, reset in ms, retry after in ms}
+//
+// The quota is fully available again once the newest request leaves the window.
+var slidingWindowScript = NewScript("sliding_window", `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count + cost <= limit then
+	for i = 1, cost do
+		redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+	end
+	-- the key is kept until the newest request leaves the window
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + cost
+	allowed = 1
+end
+
+local reset = 0
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if newest[2] then
+	reset = tonumber(newest[2]) + window - now
+end
+
+local retry = 0
+if allowed == 0 then
+	if cost > limit then
+		retry = -1
+	else
+		local entry = redis.call('ZRANGE', KEYS[1], count + cost - limit - 1, count + cost - limit - 1, 'WITHSCORES')
+		retry = tonumber(entry[2]) + window - now
+	end
+end
+
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript keeps a hash with the available tokens and the time they were computed.
+//
+//	KEYS[1] quota key
+//	ARGV[1] capacity, ARGV[2] time to refill the bucket in ms, ARGV[3] cost
+//	returns {allowed, remaining, reset in ms, retry after in ms}
+//
+// The quota is fully available again once the bucket is refilled to its capacity.
+var tokenBucketScript = NewScript("token_bucket", `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local capacity = tonumber(ARGV[1])
+local rate = capacity / tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+elseif cost > capacity then
+	retry = -1
+else
+	retry = math.ceil((cost - tokens) / rate)
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+if reset > 0 then
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+	redis.call('PEXPIRE', KEYS[1], reset)
+else
+	-- a full bucket is the same as no bucket
+	redis.call('DEL', KEYS[1])
+end
+
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+type redisRateLimiter struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRateLimiter creates a RateLimiter storing its state in Redis under keys starting with the prefix.
+// The algorithms run atomically as Lua scripts and use the Redis server time, so the replicas
+// don't need synchronized clocks.
+func NewRateLimiter(client RedisClient, prefix string) RateLimiter {
+	return redisRateLimiter{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (r redisRateLimiter) Allow(ctx context.Context, key string, limit RateLimitConfiguration) (RateLimitResult, error) {
+	return r.AllowN(ctx, key, limit, 1)
+}
+
+func (r redisRateLimiter) AllowN(ctx context.Context, key string, limit RateLimitConfiguration, n int64) (RateLimitResult, error) {
+	if limit.Limit <= 0 || limit.Period <= 0 {
+		return RateLimitResult{}, fmt.Errorf("invalid rate limit %d per %v", limit.Limit, limit.Period)
+	}
+	if n < 1 {
+		return RateLimitResult{}, fmt.Errorf("invalid rate limit cost %d", n)
+	}
+
+	keys := []string{fmt.Sprintf("%s:%s:%s", r.prefix, limit.Name, key)}
+	period := limit.Period.Milliseconds()
+
+	var values []interface{}
+	var err error
+	switch limit.Algorithm {
+	case SlidingWindow, "":
+		values, err = slidingWindowScript.Run(ctx, r.client, keys, limit.Limit, period, n, uuid.New().String()).Slice()
+	case TokenBucket:
+		values, err = tokenBucketScript.Run(ctx, r.client, keys, limit.Limit, period, n).Slice()
+	default:
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm %s", limit.Algorithm)
+	}
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return parseRateLimitResult(limit, values)
+}
+
+func parseRateLimitResult(limit RateLimitConfiguration, values []interface{}) (RateLimitResult, error) {
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		n, ok := value.(int64)
+		if !ok {
+			return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", values)
+		}
+		ints[i] = n
+	}
+
+	result := RateLimitResult{
+		Allowed:    ints[0] == 1,
+		Limit:      limit.Limit,
+		Remaining:  max(ints[1], 0),
+		ResetAfter: time.Duration(ints[2]) * time.Millisecond,
+		RetryAfter: time.Duration(ints[3]) * time.Millisecond,
+	}
+	// the request costs more than the limit, it will never be allowed
+	if ints[3] < 0 {
+		result.RetryAfter = limit.Period
+	}
+	return result, nil
+}
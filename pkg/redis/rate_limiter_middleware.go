@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+// RateLimitMiddleware limits the requests according to the quota, per user when the userID is set in
+// the gin context (see server.NewServer) or per namespace when the ns query param is present.
+// Requests without any of them are not limited.
+//
+// The RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers are set on every
+// limited response, plus Retry-After when the request is rejected with 429 Too Many Requests.
+// Should the limiter fail, the request is let through.
+func RateLimitMiddleware(limiter RateLimiter, limit RateLimitConfiguration, logger logging.Logger) gin.HandlerFunc {
+	policy := fmt.Sprintf("%d;w=%d", limit.Limit, int64(limit.Period.Seconds()))
+
+	return func(c *gin.Context) {
+		key := rateLimitKey(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			logger.Errorf("unable to check the rate limit of %s. Err: %v", key, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.ResetAfter), 10))
+		c.Header("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+			errM := "rate limit exceeded."
+			logger.Warnf("%s %s", key, errM)
+			c.JSON(http.StatusTooManyRequests, errM)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	if namespace := c.Query("ns"); namespace != "" {
+		return "ns:" + namespace
+	}
+	return ""
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ydataai/go-core/pkg/common/logging"
+)
+
+type fakeRateLimiter struct {
+	keys   []string
+	result RateLimitResult
+}
+
+func (f *fakeRateLimiter) Allow(ctx context.Context, key string, limit RateLimitConfiguration) (RateLimitResult, error) {
+	return f.AllowN(ctx, key, limit, 1)
+}
+
+func (f *fakeRateLimiter) AllowN(_ context.Context, key string, _ RateLimitConfiguration, _ int64) (RateLimitResult, error) {
+	f.keys = append(f.keys, key)
+	return f.result, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limit := RateLimitConfiguration{Name: "api", Limit: 10, Period: time.Minute}
+
+	limiter := &fakeRateLimiter{result: RateLimitResult{
+		Allowed:    false,
+		Limit:      10,
+		Remaining:  0,
+		ResetAfter: 1500 * time.Millisecond,
+		RetryAfter: 200 * time.Millisecond,
+	}}
+
+	router := gin.New()
+	router.Use(RateLimitMiddleware(limiter, limit, logging.NewLogger(logging.LoggerConfiguration{})))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?ns=team", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, []string{"ns:team"}, limiter.keys)
+	assert.Equal(t, "10", recorder.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", recorder.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "10;w=60", recorder.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	limiter.result.Allowed = true
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Len(t, limiter.keys, 1)
+}
@@ -0,0 +1,183 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/redis"
+	"github.com/ydataai/go-core/pkg/redis/redistest"
+)
+
+func TestSlidingWindowRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	clock := redistest.NewFakeClock(time.UnixMilli(1700000000000))
+	client := redistest.NewClient(clock)
+	defer client.Close()
+
+	limiter := redis.NewRateLimiter(client, "quota")
+	limit := redis.RateLimitConfiguration{Name: "api", Limit: 3, Period: time.Second, Algorithm: redis.SlidingWindow}
+
+	// the quota is fully available once the newest request leaves the window
+	for _, remaining := range []int64{2, 1, 0} {
+		result, err := limiter.Allow(ctx, "user", limit)
+		require.NoError(t, err)
+		assert.Equal(t, redis.RateLimitResult{
+			Allowed:    true,
+			Limit:      3,
+			Remaining:  remaining,
+			ResetAfter: time.Second,
+		}, result)
+		clock.Advance(200 * time.Millisecond)
+	}
+
+	// the requests were made at 0, 200ms and 400ms, the oldest leaves the window at 1s
+	clock.Advance(100 * time.Millisecond)
+	result, err := limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.Equal(t, redis.RateLimitResult{
+		Allowed:    false,
+		Limit:      3,
+		Remaining:  0,
+		ResetAfter: 700 * time.Millisecond,
+		RetryAfter: 300 * time.Millisecond,
+	}, result)
+
+	// two requests are only available once the second oldest leaves the window
+	result, err = limiter.AllowN(ctx, "user", limit, 2)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// other keys and quotas are counted separately
+	result, err = limiter.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Remaining)
+	result, err = limiter.Allow(ctx, "user", redis.RateLimitConfiguration{Name: "uploads", Limit: 3, Period: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Remaining)
+
+	// window rollover: the requests made at 0 and 200ms left the window
+	clock.Advance(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.Equal(t, redis.RateLimitResult{
+		Allowed:    true,
+		Limit:      3,
+		Remaining:  1,
+		ResetAfter: time.Second,
+	}, result)
+
+	// a cost greater than the limit is never allowed
+	result, err = limiter.AllowN(ctx, "fresh", limit, 4)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, int64(3), result.Remaining)
+	assert.Zero(t, result.ResetAfter)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// the quota expires once the newest request leaves the window
+	clock.Advance(999 * time.Millisecond)
+	assert.Equal(t, int64(1), client.Exists(ctx, "quota:api:user").Val())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, int64(0), client.Exists(ctx, "quota:api:user").Val())
+}
+
+func TestTokenBucketRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	clock := redistest.NewFakeClock(time.UnixMilli(1700000000000))
+	client := redistest.NewClient(clock)
+	defer client.Close()
+
+	limiter := redis.NewRateLimiter(client, "quota")
+	limit := redis.RateLimitConfiguration{Name: "api", Limit: 4, Period: time.Second, Algorithm: redis.TokenBucket}
+
+	// bursts up to the capacity
+	result, err := limiter.AllowN(ctx, "user", limit, 4)
+	require.NoError(t, err)
+	assert.Equal(t, redis.RateLimitResult{
+		Allowed:    true,
+		Limit:      4,
+		Remaining:  0,
+		ResetAfter: time.Second,
+	}, result)
+
+	// a token is refilled every 250ms
+	clock.Advance(100 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.Equal(t, redis.RateLimitResult{
+		Allowed:    false,
+		Limit:      4,
+		Remaining:  0,
+		ResetAfter: 900 * time.Millisecond,
+		RetryAfter: 150 * time.Millisecond,
+	}, result)
+
+	result, err = limiter.AllowN(ctx, "user", limit, 2)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 400*time.Millisecond, result.RetryAfter)
+
+	clock.Advance(150 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.Equal(t, redis.RateLimitResult{
+		Allowed:    true,
+		Limit:      4,
+		Remaining:  0,
+		ResetAfter: time.Second,
+	}, result)
+
+	// partially refilled
+	clock.Advance(600 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(1), result.Remaining)
+	assert.Equal(t, 650*time.Millisecond, result.ResetAfter)
+
+	// a cost greater than the capacity is never allowed
+	result, err = limiter.AllowN(ctx, "user", limit, 5)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// a full bucket is not stored
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(0), client.Exists(ctx, "quota:api:user").Val())
+	result, err = limiter.Allow(ctx, "user", limit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Remaining)
+	assert.Equal(t, 250*time.Millisecond, result.ResetAfter)
+}
+
+func TestRateLimiterErrors(t *testing.T) {
+	ctx := context.Background()
+	client := redistest.NewClient(nil)
+	defer client.Close()
+
+	limiter := redis.NewRateLimiter(client, "quota")
+
+	_, err := limiter.Allow(ctx, "user", redis.RateLimitConfiguration{Name: "api", Limit: 0, Period: time.Second})
+	assert.Error(t, err)
+	_, err = limiter.Allow(ctx, "user", redis.RateLimitConfiguration{Name: "api", Limit: 1, Period: time.Second, Algorithm: "fixed"})
+	assert.Error(t, err)
+
+	// costs below 1 would give back requests to the quota
+	for _, algorithm := range []redis.RateLimitAlgorithm{redis.SlidingWindow, redis.TokenBucket} {
+		limit := redis.RateLimitConfiguration{Name: "api", Limit: 1, Period: time.Second, Algorithm: algorithm}
+		_, err = limiter.AllowN(ctx, "user", limit, 0)
+		assert.Error(t, err)
+		_, err = limiter.AllowN(ctx, "user", limit, -5)
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), client.Exists(ctx, "quota:api:user").Val())
+	}
+
+	// errors of the scripts are returned
+	require.NoError(t, client.Set(ctx, "quota:api:user", "not a hash", 0).Err())
+	_, err = limiter.Allow(ctx, "user", redis.RateLimitConfiguration{Name: "api", Limit: 1, Period: time.Second, Algorithm: redis.TokenBucket})
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package redistest
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// formatArg converts a command argument to the string the server would receive, following the rules of
+// the go-redis protocol writer.
+func formatArg(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case net.IP:
+		return string(v), nil
+	default:
+		return "", fmt.Errorf("redis: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+	}
+}
+
+// flattenArgs expands a single slice or map argument into its elements, like go-redis does for
+// variadic arguments, and formats every element.
+func flattenArgs(args []interface{}) ([]string, error) {
+	if len(args) == 1 {
+		switch arg := args[0].(type) {
+		case []string:
+			return arg, nil
+		case []interface{}:
+			args = arg
+		case map[string]interface{}:
+			args = make([]interface{}, 0, len(arg)*2)
+			for k, v := range arg {
+				args = append(args, k, v)
+			}
+		case map[string]string:
+			args = make([]interface{}, 0, len(arg)*2)
+			for k, v := range arg {
+				args = append(args, k, v)
+			}
+		}
+	}
+
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		s, err := formatArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
@@ -0,0 +1,479 @@
+// Package redistest provides an in-memory implementation of redis.RedisClient for unit tests.
+//
+// The Client keeps strings, hashes, sets, sorted sets and JSON documents in memory, honours key
+// expiration through a controllable Clock and delivers pub/sub messages to subscribers, so code
+// depending on redis.RedisClient can be tested without a Redis server.
+//
+//	clock := redistest.NewFakeClock(time.Now())
+//	client := redistest.NewClient(clock)
+//	defer client.Close()
+//
+//	client.Set(ctx, "key", "value", time.Minute)
+//	clock.Advance(2 * time.Minute)
+//	client.Get(ctx, "key").Err() // redis.Nil
+//
+//	client.FailOnce("get", errors.New("connection reset"))
+//	client.Get(ctx, "key").Err() // connection reset
+//
+// Lua scripts run through EVAL and EVALSHA with the common string, key, hash, set and sorted set commands
+// available to redis.call. Pipelines and transactions are not supported and return ErrNotSupported.
+package redistest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/ydataai/go-core/pkg/redis"
+)
+
+// ErrNotSupported is returned by the commands the in-memory client does not implement.
+var ErrNotSupported = errors.New("redistest: command not supported by the in-memory client")
+
+var (
+	errWrongType  = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+)
+
+var _ redis.RedisClient = (*Client)(nil)
+
+// Client is an in-memory redis.RedisClient.
+type Client struct {
+	mu    sync.Mutex
+	clock Clock
+	keys  map[string]*entry
+
+	failures     map[string]error
+	onceFailures map[string][]error
+
+	broker *broker
+	pubsub *goredis.Client
+
+	// scripts by SHA1 digest, and scriptMu runs them one at a time
+	scripts  map[string]string
+	scriptMu sync.Mutex
+}
+
+type entry struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+type (
+	hashValue map[string]string
+	setValue  map[string]struct{}
+	zsetValue map[string]float64
+)
+
+// NewClient creates an in-memory Client. A nil clock falls back to the system clock.
+func NewClient(clock Clock) *Client {
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	c := &Client{
+		clock:        clock,
+		keys:         map[string]*entry{},
+		failures:     map[string]error{},
+		onceFailures: map[string][]error{},
+		scripts:      map[string]string{},
+	}
+	c.broker = newBroker(c)
+	c.pubsub = goredis.NewClient(&goredis.Options{
+		Addr:            "redistest",
+		Dialer:          c.broker.dial,
+		DisableIdentity: true,
+	})
+
+	return c
+}
+
+// Close disconnects all the subscribers.
+func (c *Client) Close() error {
+	c.broker.close()
+	return c.pubsub.Close()
+}
+
+// FailOn makes every call to the given command (e.g. "get", "json.set", "publish") return err
+// until ClearFailures is called. The command "*" matches every command.
+func (c *Client) FailOn(command string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[strings.ToLower(command)] = err
+}
+
+// FailOnce makes the next call to the given command return err. Calling it several times queues the errors.
+// The command "*" matches every command.
+func (c *Client) FailOnce(command string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	command = strings.ToLower(command)
+	c.onceFailures[command] = append(c.onceFailures[command], err)
+}
+
+// ClearFailures removes all the injected failures.
+func (c *Client) ClearFailures() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = map[string]error{}
+	c.onceFailures = map[string][]error{}
+}
+
+// FlushAll removes every key.
+func (c *Client) FlushAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = map[string]*entry{}
+}
+
+// injectedFailure returns the error injected for the command, if any. Must be called with the lock held.
+func (c *Client) injectedFailure(command string) error {
+	for _, name := range []string{command, "*"} {
+		if queue := c.onceFailures[name]; len(queue) > 0 {
+			c.onceFailures[name] = queue[1:]
+			return queue[0]
+		}
+	}
+	for _, name := range []string{command, "*"} {
+		if err, ok := c.failures[name]; ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// begin locks the client and checks the context and the injected failures for the command.
+// It returns false, with the error set on the command and the lock released, if the command must not
+// proceed. Otherwise, the lock is held and must be released by the caller.
+func (c *Client) begin(ctx context.Context, cmd goredis.Cmder) bool {
+	return c.beginCommand(ctx, cmd.Name(), cmd)
+}
+
+// beginCommand is like begin, for commands whose name can't be derived from their arguments.
+func (c *Client) beginCommand(ctx context.Context, name string, cmd goredis.Cmder) bool {
+	c.mu.Lock()
+	err := ctx.Err()
+	if err == nil {
+		err = c.injectedFailure(name)
+	}
+	if err != nil {
+		c.mu.Unlock()
+		cmd.SetErr(err)
+		return false
+	}
+	return true
+}
+
+// lookup returns the entry of a key, removing it if expired. Must be called with the lock held.
+func (c *Client) lookup(key string) *entry {
+	e, ok := c.keys[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !c.clock.Now().Before(e.expireAt) {
+		delete(c.keys, key)
+		return nil
+	}
+	return e
+}
+
+func (c *Client) lookupString(key string) (string, bool, error) {
+	e := c.lookup(key)
+	if e == nil {
+		return "", false, nil
+	}
+	s, ok := e.value.(string)
+	if !ok {
+		return "", false, errWrongType
+	}
+	return s, true, nil
+}
+
+func (c *Client) setExpiration(e *entry, expiration time.Duration) {
+	switch {
+	case expiration > 0:
+		e.expireAt = c.clock.Now().Add(expiration)
+	case expiration != goredis.KeepTTL:
+		e.expireAt = time.Time{}
+	}
+}
+
+func (c *Client) Get(ctx context.Context, key string) *goredis.StringCmd {
+	cmd := goredis.NewStringCmd(ctx, "get", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	value, ok, err := c.lookupString(key)
+	switch {
+	case err != nil:
+		cmd.SetErr(err)
+	case !ok:
+		cmd.SetErr(goredis.Nil)
+	default:
+		cmd.SetVal(value)
+	}
+	return cmd
+}
+
+func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *goredis.StatusCmd {
+	cmd := goredis.NewStatusCmd(ctx, "set", key, value)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	s, err := formatArg(value)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	e := c.lookup(key)
+	if e == nil || expiration != goredis.KeepTTL {
+		e = &entry{}
+	}
+	e.value = s
+	c.setExpiration(e, expiration)
+	c.keys[key] = e
+
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *goredis.BoolCmd {
+	cmd := goredis.NewBoolCmd(ctx, "setnx", key, value)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	s, err := formatArg(value)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if c.lookup(key) != nil {
+		cmd.SetVal(false)
+		return cmd
+	}
+
+	e := &entry{value: s}
+	c.setExpiration(e, expiration)
+	c.keys[key] = e
+
+	cmd.SetVal(true)
+	return cmd
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "del")
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	var deleted int64
+	for _, key := range keys {
+		if c.lookup(key) != nil {
+			delete(c.keys, key)
+			deleted++
+		}
+	}
+
+	cmd.SetVal(deleted)
+	return cmd
+}
+
+func (c *Client) Exists(ctx context.Context, keys ...string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "exists")
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	var found int64
+	for _, key := range keys {
+		if c.lookup(key) != nil {
+			found++
+		}
+	}
+
+	cmd.SetVal(found)
+	return cmd
+}
+
+func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) *goredis.BoolCmd {
+	cmd := goredis.NewBoolCmd(ctx, "expire", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	cmd.SetVal(c.expireAt(key, c.clock.Now().Add(expiration)))
+	return cmd
+}
+
+func (c *Client) ExpireAt(ctx context.Context, key string, tm time.Time) *goredis.BoolCmd {
+	cmd := goredis.NewBoolCmd(ctx, "expireat", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	cmd.SetVal(c.expireAt(key, tm))
+	return cmd
+}
+
+func (c *Client) expireAt(key string, tm time.Time) bool {
+	e := c.lookup(key)
+	if e == nil {
+		return false
+	}
+	if !c.clock.Now().Before(tm) {
+		delete(c.keys, key)
+		return true
+	}
+	e.expireAt = tm
+	return true
+}
+
+func (c *Client) TTL(ctx context.Context, key string) *goredis.DurationCmd {
+	cmd := goredis.NewDurationCmd(ctx, time.Second, "ttl", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	switch {
+	case e == nil:
+		cmd.SetVal(-2)
+	case e.expireAt.IsZero():
+		cmd.SetVal(-1)
+	default:
+		cmd.SetVal((e.expireAt.Sub(c.clock.Now()) + time.Second/2).Truncate(time.Second))
+	}
+	return cmd
+}
+
+func (c *Client) Persist(ctx context.Context, key string) *goredis.BoolCmd {
+	cmd := goredis.NewBoolCmd(ctx, "persist", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil || e.expireAt.IsZero() {
+		cmd.SetVal(false)
+		return cmd
+	}
+	e.expireAt = time.Time{}
+	cmd.SetVal(true)
+	return cmd
+}
+
+func (c *Client) Incr(ctx context.Context, key string) *goredis.IntCmd {
+	return c.incrBy(ctx, "incr", key, 1)
+}
+
+func (c *Client) IncrBy(ctx context.Context, key string, value int64) *goredis.IntCmd {
+	return c.incrBy(ctx, "incrby", key, value)
+}
+
+func (c *Client) Decr(ctx context.Context, key string) *goredis.IntCmd {
+	return c.incrBy(ctx, "decr", key, -1)
+}
+
+func (c *Client) DecrBy(ctx context.Context, key string, decrement int64) *goredis.IntCmd {
+	return c.incrBy(ctx, "decrby", key, -decrement)
+}
+
+func (c *Client) incrBy(ctx context.Context, name, key string, value int64) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, name, key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	current, ok, err := c.lookupString(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var n int64
+	if ok {
+		if n, err = strconv.ParseInt(current, 10, 64); err != nil {
+			cmd.SetErr(errNotInteger)
+			return cmd
+		}
+	}
+	n += value
+
+	if e := c.lookup(key); e != nil {
+		e.value = strconv.FormatInt(n, 10)
+	} else {
+		c.keys[key] = &entry{value: strconv.FormatInt(n, 10)}
+	}
+
+	cmd.SetVal(n)
+	return cmd
+}
+
+func (c *Client) Pipelined(ctx context.Context, _ func(goredis.Pipeliner) error) ([]goredis.Cmder, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *Client) TxPipelined(ctx context.Context, _ func(goredis.Pipeliner) error) ([]goredis.Cmder, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *Client) Watch(ctx context.Context, _ func(*goredis.Tx) error, _ ...string) error {
+	return ErrNotSupported
+}
+
+func (c *Client) Publish(ctx context.Context, channel string, message interface{}) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "publish", channel, message)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	c.mu.Unlock()
+
+	payload, err := formatArg(message)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd.SetVal(c.broker.publish(channel, payload))
+	return cmd
+}
+
+// Subscribe subscribes to the channels through an in-memory connection, so messages published with
+// Publish are delivered to the returned *redis.PubSub like a real server would.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) *goredis.PubSub {
+	return c.pubsub.Subscribe(ctx, channels...)
+}
+
+// PSubscribe subscribes to the channels matching the patterns through an in-memory connection.
+func (c *Client) PSubscribe(ctx context.Context, patterns ...string) *goredis.PubSub {
+	return c.pubsub.PSubscribe(ctx, patterns...)
+}
+
+func (c *Client) Ping(ctx context.Context) *goredis.StatusCmd {
+	cmd := goredis.NewStatusCmd(ctx, "ping")
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	cmd.SetVal("PONG")
+	return cmd
+}
@@ -0,0 +1,137 @@
+package redistest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringExpiration(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Now())
+	client := NewClient(clock)
+	defer client.Close()
+
+	require.NoError(t, client.Set(ctx, "key", "value", time.Minute).Err())
+	assert.Equal(t, "value", client.Get(ctx, "key").Val())
+	assert.Equal(t, time.Minute, client.TTL(ctx, "key").Val())
+
+	clock.Advance(time.Minute)
+	assert.ErrorIs(t, client.Get(ctx, "key").Err(), goredis.Nil)
+	assert.Equal(t, time.Duration(-2), client.TTL(ctx, "key").Val())
+}
+
+func TestHashes(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+	defer client.Close()
+
+	assert.Equal(t, int64(2), client.HSet(ctx, "hash", "a", 1, "b", "two").Val())
+	assert.Equal(t, int64(0), client.HSet(ctx, "hash", map[string]interface{}{"a": 3}).Val())
+	assert.Equal(t, map[string]string{"a": "3", "b": "two"}, client.HGetAll(ctx, "hash").Val())
+
+	assert.Equal(t, int64(2), client.HDel(ctx, "hash", "a", "b").Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "hash").Val())
+
+	client.Set(ctx, "string", "value", 0)
+	assert.ErrorIs(t, client.HGet(ctx, "string", "a").Err(), errWrongType)
+}
+
+func TestJSONPaths(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+	defer client.Close()
+
+	require.NoError(t, client.JSONSet(ctx, "doc", "$", map[string]interface{}{"a": map[string]interface{}{"b": []int{1, 2}}}).Err())
+	require.NoError(t, client.JSONSet(ctx, "doc", "$.a.c", `"x"`).Err())
+	assert.ErrorIs(t, client.JSONSet(ctx, "doc", "$.missing.c", 1).Err(), goredis.Nil)
+
+	assert.Equal(t, `[[1,2]]`, client.JSONGet(ctx, "doc", "$.a.b").Val())
+	assert.Equal(t, `2`, client.JSONGet(ctx, "doc", ".a.b[-1]").Val())
+	assert.Equal(t, `[]`, client.JSONGet(ctx, "doc", "$.nothing").Val())
+
+	assert.Equal(t, int64(1), client.JSONDel(ctx, "doc", "$.a.b[0]").Val())
+	assert.Equal(t, `{"a":{"b":[2],"c":"x"}}`, client.JSONGet(ctx, "doc").Val())
+
+	assert.Equal(t, int64(1), client.JSONDel(ctx, "doc", "$").Val())
+	assert.ErrorIs(t, client.JSONGet(ctx, "doc").Err(), goredis.Nil)
+}
+
+func TestPubSub(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := NewClient(nil)
+	defer client.Close()
+
+	pubsub := client.Subscribe(ctx, "events")
+	defer pubsub.Close()
+	_, err := pubsub.Receive(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), client.Publish(ctx, "events", "hello").Val())
+	assert.Equal(t, int64(0), client.Publish(ctx, "other", "ignored").Val())
+
+	msg, err := pubsub.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "events", msg.Channel)
+	assert.Equal(t, "hello", msg.Payload)
+}
+
+func TestFailureInjection(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(nil)
+	defer client.Close()
+	errBoom := errors.New("boom")
+
+	client.FailOnce("get", errBoom)
+	assert.ErrorIs(t, client.Get(ctx, "key").Err(), errBoom)
+	assert.ErrorIs(t, client.Get(ctx, "key").Err(), goredis.Nil)
+
+	client.FailOn("*", errBoom)
+	assert.ErrorIs(t, client.Ping(ctx).Err(), errBoom)
+	assert.ErrorIs(t, client.JSONGet(ctx, "key").Err(), errBoom)
+	assert.ErrorIs(t, client.Scan(ctx, "*", 0).Err(), errBoom)
+
+	client.ClearFailures()
+	assert.NoError(t, client.Ping(ctx).Err())
+}
+
+func TestScripts(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	client := NewClient(clock)
+	defer client.Close()
+
+	script := `
+		local current = redis.call("INCRBY", KEYS[1], ARGV[1])
+		redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		if redis.call("GET", KEYS[2]) == false then
+			return {current, redis.call("TIME")[1], redis.call("PTTL", KEYS[1])}
+		end
+		return redis.error_reply("ERR unexpected")
+	`
+	result, err := client.Eval(ctx, script, []string{"counter", "missing"}, 3, 1000).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(3), "1700000000", int64(1000)}, result)
+
+	sha := client.ScriptLoad(ctx, script).Val()
+	assert.Equal(t, []bool{true, false}, client.ScriptExists(ctx, sha, "0000").Val())
+	assert.Equal(t, []interface{}{int64(5), "1700000000", int64(1000)}, client.EvalSha(ctx, sha, []string{"counter", "missing"}, 2, 1000).Val())
+
+	client.Set(ctx, "missing", "present", 0)
+	assert.EqualError(t, client.EvalSha(ctx, sha, []string{"counter", "missing"}, 1, 1000).Err(), "ERR unexpected")
+
+	client.ScriptFlush()
+	err = client.EvalSha(ctx, sha, []string{"counter", "missing"}, 1, 1000).Err()
+	assert.True(t, goredis.HasErrorPrefix(err, "NOSCRIPT"))
+
+	assert.ErrorIs(t, client.Eval(ctx, `return nil`, nil).Err(), goredis.Nil)
+	assert.EqualError(t, client.Eval(ctx, `return redis.call("HGET", "counter", "a")`, nil).Err(), errWrongType.Error())
+	assert.Equal(t, "OK", client.Eval(ctx, `return redis.call("SET", "a", "b", "PX", 10, "NX")`, nil).Val())
+	assert.ErrorIs(t, client.Eval(ctx, `return redis.call("SET", "a", "b", "NX")`, nil).Err(), goredis.Nil)
+}
@@ -0,0 +1,169 @@
+package redistest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// lookupHash returns the hash stored in the key, creating it when create is true.
+// Must be called with the lock held.
+func (c *Client) lookupHash(key string, create bool) (hashValue, error) {
+	e := c.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		h := hashValue{}
+		c.keys[key] = &entry{value: h}
+		return h, nil
+	}
+	h, ok := e.value.(hashValue)
+	if !ok {
+		return nil, errWrongType
+	}
+	return h, nil
+}
+
+// dropIfEmpty removes the key when its collection became empty, like Redis does.
+func (c *Client) dropIfEmpty(key string, size int) {
+	if size == 0 {
+		delete(c.keys, key)
+	}
+}
+
+func (c *Client) HSet(ctx context.Context, key string, values ...interface{}) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "hset", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	fields, err := flattenArgs(values)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		cmd.SetErr(errors.New("ERR wrong number of arguments for 'hset' command"))
+		return cmd
+	}
+
+	h, err := c.lookupHash(key, true)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var added int64
+	for i := 0; i < len(fields); i += 2 {
+		if _, ok := h[fields[i]]; !ok {
+			added++
+		}
+		h[fields[i]] = fields[i+1]
+	}
+
+	cmd.SetVal(added)
+	return cmd
+}
+
+func (c *Client) HGet(ctx context.Context, key, field string) *goredis.StringCmd {
+	cmd := goredis.NewStringCmd(ctx, "hget", key, field)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	h, err := c.lookupHash(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	value, ok := h[field]
+	if !ok {
+		cmd.SetErr(goredis.Nil)
+		return cmd
+	}
+
+	cmd.SetVal(value)
+	return cmd
+}
+
+func (c *Client) HGetAll(ctx context.Context, key string) *goredis.MapStringStringCmd {
+	cmd := goredis.NewMapStringStringCmd(ctx, "hgetall", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	h, err := c.lookupHash(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	values := make(map[string]string, len(h))
+	for field, value := range h {
+		values[field] = value
+	}
+
+	cmd.SetVal(values)
+	return cmd
+}
+
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "hdel", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	h, err := c.lookupHash(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var deleted int64
+	for _, field := range fields {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			deleted++
+		}
+	}
+	if h != nil {
+		c.dropIfEmpty(key, len(h))
+	}
+
+	cmd.SetVal(deleted)
+	return cmd
+}
+
+func (c *Client) HIncrBy(ctx context.Context, key, field string, incr int64) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "hincrby", key, field, incr)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	h, err := c.lookupHash(key, true)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var n int64
+	if current, ok := h[field]; ok {
+		if n, err = strconv.ParseInt(current, 10, 64); err != nil {
+			cmd.SetErr(errors.New("ERR hash value is not an integer"))
+			return cmd
+		}
+	}
+	n += incr
+	h[field] = strconv.FormatInt(n, 10)
+
+	cmd.SetVal(n)
+	return cmd
+}
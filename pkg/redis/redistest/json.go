@@ -0,0 +1,362 @@
+package redistest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// jsonDocument is the value of a key written with JSON.SET.
+type jsonDocument struct {
+	root interface{}
+}
+
+// jsonPath is a parsed RedisJSON path. Only the dot notation, bracketed member names and array
+// indexes are supported (e.g. "$.a.b", ".a[0]", `$["a"]`), wildcards and filters are not.
+type jsonPath struct {
+	legacy   bool
+	segments []interface{} // string member names or int array indexes
+}
+
+func parseJSONPath(raw string) (jsonPath, error) {
+	p := jsonPath{legacy: !strings.HasPrefix(raw, "$")}
+	s := strings.TrimPrefix(raw, "$")
+	if p.legacy && s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				if len(s) == 0 && p.legacy && len(p.segments) == 0 {
+					// "." is the legacy root
+					return p, nil
+				}
+				return p, fmt.Errorf("ERR invalid JSON path '%s'", raw)
+			}
+			p.segments = append(p.segments, s[:end])
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return p, fmt.Errorf("ERR invalid JSON path '%s'", raw)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			if unquoted, err := strconv.Unquote(strings.ReplaceAll(inner, "'", `"`)); err == nil {
+				p.segments = append(p.segments, unquoted)
+			} else if index, err := strconv.Atoi(inner); err == nil {
+				p.segments = append(p.segments, index)
+			} else {
+				return p, fmt.Errorf("ERR invalid JSON path '%s'", raw)
+			}
+			s = s[end+1:]
+		default:
+			return p, fmt.Errorf("ERR invalid JSON path '%s'", raw)
+		}
+	}
+	return p, nil
+}
+
+func (p jsonPath) isRoot() bool {
+	return len(p.segments) == 0
+}
+
+// child returns the element of the container addressed by the segment.
+func child(container interface{}, segment interface{}) (interface{}, bool) {
+	switch segment := segment.(type) {
+	case string:
+		object, ok := container.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok := object[segment]
+		return value, ok
+	case int:
+		array, ok := container.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		if segment < 0 {
+			segment += len(array)
+		}
+		if segment < 0 || segment >= len(array) {
+			return nil, false
+		}
+		return array[segment], true
+	}
+	return nil, false
+}
+
+func (p jsonPath) get(root interface{}) (interface{}, bool) {
+	value := root
+	for _, segment := range p.segments {
+		var ok bool
+		if value, ok = child(value, segment); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// set replaces the value addressed by the path. The parent must exist; object members are created
+// but arrays are never extended.
+func (p jsonPath) set(root interface{}, value interface{}) bool {
+	parent, ok := jsonPath{segments: p.segments[:len(p.segments)-1]}.get(root)
+	if !ok {
+		return false
+	}
+
+	switch last := p.segments[len(p.segments)-1].(type) {
+	case string:
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		object[last] = value
+		return true
+	case int:
+		array, ok := parent.([]interface{})
+		if !ok {
+			return false
+		}
+		if last < 0 {
+			last += len(array)
+		}
+		if last < 0 || last >= len(array) {
+			return false
+		}
+		array[last] = value
+		return true
+	}
+	return false
+}
+
+// del removes the value addressed by the path, returning whether it existed.
+func (p jsonPath) del(root interface{}) (interface{}, bool) {
+	parentPath := jsonPath{segments: p.segments[:len(p.segments)-1]}
+	parent, ok := parentPath.get(root)
+	if !ok {
+		return root, false
+	}
+
+	switch last := p.segments[len(p.segments)-1].(type) {
+	case string:
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return root, false
+		}
+		if _, ok := object[last]; !ok {
+			return root, false
+		}
+		delete(object, last)
+		return root, true
+	case int:
+		array, ok := parent.([]interface{})
+		if !ok {
+			return root, false
+		}
+		if last < 0 {
+			last += len(array)
+		}
+		if last < 0 || last >= len(array) {
+			return root, false
+		}
+		array = append(array[:last], array[last+1:]...)
+		if parentPath.isRoot() {
+			return array, true
+		}
+		parentPath.set(root, array)
+		return root, true
+	}
+	return root, false
+}
+
+// decodeJSON parses the value like go-redis sends it: strings and byte slices are taken as JSON text,
+// anything else is marshalled.
+func decodeJSON(value interface{}) (interface{}, error) {
+	var raw []byte
+	switch v := value.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		var err error
+		if raw, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, errors.New("ERR expected value")
+	}
+	return decoded, nil
+}
+
+// lookupJSON returns the JSON document stored in the key. Must be called with the lock held.
+func (c *Client) lookupJSON(key string) (*jsonDocument, error) {
+	e := c.lookup(key)
+	if e == nil {
+		return nil, nil
+	}
+	doc, ok := e.value.(*jsonDocument)
+	if !ok {
+		return nil, errWrongType
+	}
+	return doc, nil
+}
+
+func (c *Client) JSONGet(ctx context.Context, key string, paths ...string) *goredis.JSONCmd {
+	// JSONCmd can't be created with arguments outside go-redis, so the command name is given explicitly
+	cmd := &goredis.JSONCmd{}
+	if !c.beginCommand(ctx, "json.get", cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	doc, err := c.lookupJSON(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if doc == nil {
+		cmd.SetErr(goredis.Nil)
+		return cmd
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	results := map[string]interface{}{}
+	for _, raw := range paths {
+		path, err := parseJSONPath(raw)
+		if err != nil {
+			cmd.SetErr(err)
+			return cmd
+		}
+
+		value, ok := path.get(doc.root)
+		switch {
+		case !path.legacy && ok:
+			results[raw] = []interface{}{value}
+		case !path.legacy:
+			results[raw] = []interface{}{}
+		case ok:
+			results[raw] = value
+		default:
+			cmd.SetErr(fmt.Errorf("ERR Path '%s' does not exist", raw))
+			return cmd
+		}
+	}
+
+	var result interface{} = results
+	if len(paths) == 1 {
+		result = results[paths[0]]
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd.SetVal(string(encoded))
+	return cmd
+}
+
+func (c *Client) JSONSet(ctx context.Context, key, path string, value interface{}) *goredis.StatusCmd {
+	cmd := goredis.NewStatusCmd(ctx, "json.set", key, path)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	decoded, err := decodeJSON(value)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	doc, err := c.lookupJSON(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	switch {
+	case p.isRoot():
+		if doc == nil {
+			c.keys[key] = &entry{value: &jsonDocument{root: decoded}}
+		} else {
+			doc.root = decoded
+		}
+	case doc == nil:
+		cmd.SetErr(errors.New("ERR new objects must be created at the root"))
+		return cmd
+	case !p.set(doc.root, decoded):
+		cmd.SetErr(goredis.Nil)
+		return cmd
+	}
+
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (c *Client) JSONDel(ctx context.Context, key string, path string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "json.del", key, path)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	doc, err := c.lookupJSON(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if doc == nil {
+		cmd.SetVal(0)
+		return cmd
+	}
+
+	if p.isRoot() {
+		delete(c.keys, key)
+		cmd.SetVal(1)
+		return cmd
+	}
+
+	root, deleted := p.del(doc.root)
+	doc.root = root
+	if !deleted {
+		cmd.SetVal(0)
+		return cmd
+	}
+	cmd.SetVal(1)
+	return cmd
+}
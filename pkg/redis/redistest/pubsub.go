@@ -0,0 +1,291 @@
+package redistest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ydataai/go-core/pkg/redis"
+)
+
+// broker is a minimal in-process server speaking the RESP2 subset used by go-redis subscriptions,
+// so Subscribe can return a real *redis.PubSub connected through net.Pipe.
+type broker struct {
+	client *Client
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+type subscriber struct {
+	conn     net.Conn
+	channels map[string]struct{}
+	patterns map[string]struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  [][]byte
+	closed bool
+}
+
+func newBroker(client *Client) *broker {
+	return &broker{
+		client:      client,
+		subscribers: map[*subscriber]struct{}{},
+	}
+}
+
+// dial is the go-redis Dialer that connects a new subscriber to the broker.
+func (b *broker) dial(ctx context.Context, _, _ string) (net.Conn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errors.New("redistest: client is closed")
+	}
+
+	server, client := net.Pipe()
+	s := &subscriber{
+		conn:     server,
+		channels: map[string]struct{}{},
+		patterns: map[string]struct{}{},
+	}
+	s.cond = sync.NewCond(&s.mu)
+	b.subscribers[s] = struct{}{}
+
+	go s.writeLoop()
+	go b.readLoop(s)
+
+	return client, nil
+}
+
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		s.close()
+	}
+	b.subscribers = map[*subscriber]struct{}{}
+}
+
+// publish delivers the payload to the subscribers of the channel, returning how many received it.
+func (b *broker) publish(channel, payload string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var receivers int64
+	for s := range b.subscribers {
+		if _, ok := s.channels[channel]; ok {
+			s.send(encodeArray("message", channel, payload))
+			receivers++
+		}
+		for pattern := range s.patterns {
+			if redis.MatchPattern(pattern, channel) {
+				s.send(encodeArray("pmessage", pattern, channel, payload))
+				receivers++
+			}
+		}
+	}
+	return receivers
+}
+
+func (b *broker) readLoop(s *subscriber) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscribers, s)
+		b.mu.Unlock()
+		s.close()
+	}()
+
+	reader := bufio.NewReader(s.conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		b.handle(s, strings.ToLower(args[0]), args[1:])
+	}
+}
+
+func (b *broker) handle(s *subscriber, command string, args []string) {
+	switch command {
+	case "subscribe", "psubscribe":
+		b.client.mu.Lock()
+		err := b.client.injectedFailure(command)
+		b.client.mu.Unlock()
+		if err != nil {
+			s.send(encodeError("ERR " + err.Error()))
+			return
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch command {
+	case "subscribe":
+		for _, channel := range args {
+			s.channels[channel] = struct{}{}
+			s.send(encodeSubscription(command, channel, s.count()))
+		}
+	case "psubscribe":
+		for _, pattern := range args {
+			s.patterns[pattern] = struct{}{}
+			s.send(encodeSubscription(command, pattern, s.count()))
+		}
+	case "unsubscribe":
+		unsubscribe(s, s.channels, command, args)
+	case "punsubscribe":
+		unsubscribe(s, s.patterns, command, args)
+	case "ping":
+		payload := ""
+		if len(args) > 0 {
+			payload = args[0]
+		}
+		s.send(encodeArray("pong", payload))
+	case "quit":
+		s.send([]byte("+OK\r\n"))
+		s.close()
+	default:
+		s.send(encodeError(fmt.Sprintf("ERR unknown command '%s'", command)))
+	}
+}
+
+func unsubscribe(s *subscriber, subscriptions map[string]struct{}, command string, names []string) {
+	if len(names) == 0 {
+		for name := range subscriptions {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		s.send([]byte(fmt.Sprintf("*3\r\n$%d\r\n%s\r\n$-1\r\n:%d\r\n", len(command), command, s.count())))
+		return
+	}
+	for _, name := range names {
+		delete(subscriptions, name)
+		s.send(encodeSubscription(command, name, s.count()))
+	}
+}
+
+func (s *subscriber) count() int {
+	return len(s.channels) + len(s.patterns)
+}
+
+// send queues a reply to the subscriber without blocking the publisher.
+func (s *subscriber) send(frame []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.queue = append(s.queue, frame)
+	s.cond.Signal()
+}
+
+func (s *subscriber) writeLoop() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		frames := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, frame := range frames {
+			if _, err := s.conn.Write(frame); err != nil {
+				s.close()
+				return
+			}
+		}
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	_ = s.conn.Close()
+}
+
+// readCommand reads a RESP array of bulk strings, as sent by go-redis.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("redistest: unexpected RESP header %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func encodeArray(values ...string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(values))
+	for _, value := range values {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(value), value)
+	}
+	return []byte(sb.String())
+}
+
+func encodeSubscription(kind, name string, count int) []byte {
+	return []byte(fmt.Sprintf("*3\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:%d\r\n", len(kind), kind, len(name), name, count))
+}
+
+func encodeError(message string) []byte {
+	return []byte("-" + strings.ReplaceAll(message, "\r\n", " ") + "\r\n")
+}
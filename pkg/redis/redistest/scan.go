@@ -0,0 +1,149 @@
+package redistest
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/ydataai/go-core/pkg/redis"
+)
+
+// sliceIterator is a redis.ScanIterator over a snapshot of the matching values. The in-memory client
+// has no cursors, so the values are collected when the scan starts.
+type sliceIterator struct {
+	values []string
+	pos    int
+	err    error
+}
+
+func (it *sliceIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	if it.pos >= len(it.values) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *sliceIterator) Val() string {
+	if it.pos == 0 || it.pos > len(it.values) {
+		return ""
+	}
+	return it.values[it.pos-1]
+}
+
+func (it *sliceIterator) Err() error {
+	return it.err
+}
+
+func matchAll(match string) string {
+	if match == "" {
+		return "*"
+	}
+	return match
+}
+
+// beginScan starts a scan command, returning the iterator with the error set if it must not proceed.
+// On success, the lock is held and must be released by the caller.
+func (c *Client) beginScan(ctx context.Context, name string) (*sliceIterator, bool) {
+	cmd := goredis.NewCmd(ctx, name)
+	if !c.begin(ctx, cmd) {
+		return &sliceIterator{err: cmd.Err()}, false
+	}
+	return &sliceIterator{}, true
+}
+
+func (c *Client) Scan(ctx context.Context, match string, count int64) redis.ScanIterator {
+	it, ok := c.beginScan(ctx, "scan")
+	if !ok {
+		return it
+	}
+	defer c.mu.Unlock()
+
+	for key := range c.keys {
+		if c.lookup(key) != nil && redis.MatchPattern(matchAll(match), key) {
+			it.values = append(it.values, key)
+		}
+	}
+	sort.Strings(it.values)
+	return it
+}
+
+// HScan iterates over the fields and values of a hash, yielding each field followed by its value.
+func (c *Client) HScan(ctx context.Context, key string, match string, count int64) redis.ScanIterator {
+	it, ok := c.beginScan(ctx, "hscan")
+	if !ok {
+		return it
+	}
+	defer c.mu.Unlock()
+
+	h, err := c.lookupHash(key, false)
+	if err != nil {
+		it.err = err
+		return it
+	}
+
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		if redis.MatchPattern(matchAll(match), field) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		it.values = append(it.values, field, h[field])
+	}
+	return it
+}
+
+func (c *Client) SScan(ctx context.Context, key string, match string, count int64) redis.ScanIterator {
+	it, ok := c.beginScan(ctx, "sscan")
+	if !ok {
+		return it
+	}
+	defer c.mu.Unlock()
+
+	s, err := c.lookupSet(key, false)
+	if err != nil {
+		it.err = err
+		return it
+	}
+
+	for member := range s {
+		if redis.MatchPattern(matchAll(match), member) {
+			it.values = append(it.values, member)
+		}
+	}
+	sort.Strings(it.values)
+	return it
+}
+
+// ZScan iterates over the members and scores of a sorted set, yielding each member followed by its score.
+func (c *Client) ZScan(ctx context.Context, key string, match string, count int64) redis.ScanIterator {
+	it, ok := c.beginScan(ctx, "zscan")
+	if !ok {
+		return it
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		it.err = err
+		return it
+	}
+
+	for _, m := range z.sorted() {
+		member := m.Member.(string)
+		if redis.MatchPattern(matchAll(match), member) {
+			it.values = append(it.values, member, strconv.FormatFloat(m.Score, 'f', -1, 64))
+		}
+	}
+	return it
+}
@@ -0,0 +1,534 @@
+package redistest
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptError is an error replied by a script, recognised by go-redis as a server error, e.g. NOSCRIPT.
+type scriptError string
+
+func (e scriptError) Error() string { return string(e) }
+
+// RedisError marks scriptError as an error replied by the server.
+func (e scriptError) RedisError() {}
+
+const errNoScript = scriptError("NOSCRIPT No matching script. Please use EVAL.")
+
+func scriptHash(script string) string {
+	hash := sha1.Sum([]byte(script))
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *goredis.Cmd {
+	return c.eval(ctx, "eval", script, keys, args)
+}
+
+func (c *Client) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *goredis.Cmd {
+	return c.evalSha(ctx, "evalsha", sha1, keys, args)
+}
+
+func (c *Client) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *goredis.Cmd {
+	return c.eval(ctx, "eval_ro", script, keys, args)
+}
+
+func (c *Client) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *goredis.Cmd {
+	return c.evalSha(ctx, "evalsha_ro", sha1, keys, args)
+}
+
+func (c *Client) ScriptExists(ctx context.Context, hashes ...string) *goredis.BoolSliceCmd {
+	cmd := goredis.NewBoolSliceCmd(ctx, "script", "exists")
+	if !c.beginCommand(ctx, "script", cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	exists := make([]bool, len(hashes))
+	for i, hash := range hashes {
+		_, exists[i] = c.scripts[strings.ToLower(hash)]
+	}
+	cmd.SetVal(exists)
+	return cmd
+}
+
+func (c *Client) ScriptLoad(ctx context.Context, script string) *goredis.StringCmd {
+	cmd := goredis.NewStringCmd(ctx, "script", "load")
+	if !c.beginCommand(ctx, "script", cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	hash := scriptHash(script)
+	c.scripts[hash] = script
+	cmd.SetVal(hash)
+	return cmd
+}
+
+// ScriptFlush removes all the scripts from the script cache, so EVALSHA replies NOSCRIPT.
+func (c *Client) ScriptFlush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scripts = map[string]string{}
+}
+
+func (c *Client) eval(ctx context.Context, name, script string, keys []string, args []interface{}) *goredis.Cmd {
+	cmd := goredis.NewCmd(ctx, name)
+	if !c.beginCommand(ctx, name, cmd) {
+		return cmd
+	}
+	c.scripts[scriptHash(script)] = script
+	c.mu.Unlock()
+
+	c.runScript(ctx, cmd, script, keys, args)
+	return cmd
+}
+
+func (c *Client) evalSha(ctx context.Context, name, sha1 string, keys []string, args []interface{}) *goredis.Cmd {
+	cmd := goredis.NewCmd(ctx, name)
+	if !c.beginCommand(ctx, name, cmd) {
+		return cmd
+	}
+	script, ok := c.scripts[strings.ToLower(sha1)]
+	c.mu.Unlock()
+	if !ok {
+		cmd.SetErr(errNoScript)
+		return cmd
+	}
+
+	c.runScript(ctx, cmd, script, keys, args)
+	return cmd
+}
+
+// runScript runs the Lua script with the commands of the client available through redis.call and redis.pcall.
+// Scripts run one at a time, so they are atomic with respect to each other, like in Redis.
+func (c *Client) runScript(ctx context.Context, cmd *goredis.Cmd, script string, keys []string, args []interface{}) {
+	c.scriptMu.Lock()
+	defer c.scriptMu.Unlock()
+
+	L := lua.NewState()
+	defer L.Close()
+
+	keysTable := L.NewTable()
+	for _, key := range keys {
+		keysTable.Append(lua.LString(key))
+	}
+	L.SetGlobal("KEYS", keysTable)
+
+	argvTable := L.NewTable()
+	for _, arg := range args {
+		s, err := formatArg(arg)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		argvTable.Append(lua.LString(s))
+	}
+	L.SetGlobal("ARGV", argvTable)
+
+	redisTable := L.NewTable()
+	L.SetField(redisTable, "call", L.NewFunction(func(L *lua.LState) int { return c.luaCall(ctx, L, true) }))
+	L.SetField(redisTable, "pcall", L.NewFunction(func(L *lua.LState) int { return c.luaCall(ctx, L, false) }))
+	L.SetField(redisTable, "error_reply", L.NewFunction(func(L *lua.LState) int {
+		reply := L.NewTable()
+		L.SetField(reply, "err", lua.LString(L.CheckString(1)))
+		L.Push(reply)
+		return 1
+	}))
+	L.SetField(redisTable, "status_reply", L.NewFunction(func(L *lua.LState) int {
+		reply := L.NewTable()
+		L.SetField(reply, "ok", lua.LString(L.CheckString(1)))
+		L.Push(reply)
+		return 1
+	}))
+	L.SetGlobal("redis", redisTable)
+
+	if err := L.DoString(script); err != nil {
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			if reply, ok := apiErr.Object.(*lua.LTable); ok {
+				if msg, ok := reply.RawGetString("err").(lua.LString); ok {
+					cmd.SetErr(scriptError(msg))
+					return
+				}
+			}
+		}
+		cmd.SetErr(scriptError(fmt.Sprintf("ERR Error running script: %v", err)))
+		return
+	}
+
+	value, err := fromLua(L.Get(-1))
+	switch {
+	case err != nil:
+		cmd.SetErr(err)
+	case value == nil:
+		cmd.SetErr(goredis.Nil)
+	default:
+		cmd.SetVal(value)
+	}
+}
+
+// luaCall runs the command of redis.call, raising its errors when raise is set, or returning them as error
+// replies like redis.pcall.
+func (c *Client) luaCall(ctx context.Context, L *lua.LState, raise bool) int {
+	args := make([]string, 0, L.GetTop())
+	for i := 1; i <= L.GetTop(); i++ {
+		switch value := L.Get(i).(type) {
+		case lua.LString:
+			args = append(args, string(value))
+		case lua.LNumber:
+			args = append(args, value.String())
+		default:
+			L.RaiseError("ERR Lua redis lib command arguments must be strings or integers")
+			return 0
+		}
+	}
+	if len(args) == 0 {
+		L.RaiseError("ERR Please specify at least one argument for this redis lib call")
+		return 0
+	}
+
+	reply, err := c.command(ctx, strings.ToLower(args[0]), args[1:])
+	if err != nil {
+		errorReply := L.NewTable()
+		L.SetField(errorReply, "err", lua.LString(err.Error()))
+		if raise {
+			L.Error(errorReply, 1)
+			return 0
+		}
+		L.Push(errorReply)
+		return 1
+	}
+	L.Push(toLua(L, reply))
+	return 1
+}
+
+// command runs a command called by a script. Replies are int64, string, status, nil or []interface{}.
+func (c *Client) command(ctx context.Context, name string, args []string) (interface{}, error) {
+	arity := map[string]int{
+		"get": 1, "set": 2, "del": 1, "exists": 1, "incr": 1, "incrby": 2, "decr": 1, "decrby": 2,
+		"expire": 2, "pexpire": 2, "ttl": 1, "pttl": 1, "persist": 1, "time": 0,
+		"hset": 3, "hget": 2, "hmget": 2, "hdel": 2, "hgetall": 1, "hincrby": 3,
+		"sadd": 2, "srem": 2, "smembers": 1, "sismember": 2, "scard": 1,
+		"zadd": 3, "zrem": 2, "zscore": 2, "zincrby": 3, "zcard": 1, "zcount": 3, "zrange": 3, "zremrangebyscore": 3,
+	}
+	minArgs, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown command '%s' in redistest scripts", name)
+	}
+	if len(args) < minArgs {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", name)
+	}
+
+	switch name {
+	case "get":
+		return nilIfMissing(c.Get(ctx, args[0]).Result())
+	case "set":
+		return c.scriptSet(ctx, args)
+	case "del":
+		return c.Del(ctx, args...).Result()
+	case "exists":
+		return c.Exists(ctx, args...).Result()
+	case "incr":
+		return c.Incr(ctx, args[0]).Result()
+	case "decr":
+		return c.Decr(ctx, args[0]).Result()
+	case "incrby", "decrby":
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return nil, errNotInteger
+		}
+		if name == "decrby" {
+			n = -n
+		}
+		return c.IncrBy(ctx, args[0], n).Result()
+	case "expire", "pexpire":
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return nil, errNotInteger
+		}
+		unit := time.Second
+		if name == "pexpire" {
+			unit = time.Millisecond
+		}
+		ok, err := c.Expire(ctx, args[0], time.Duration(n)*unit).Result()
+		return boolReply(ok), err
+	case "ttl", "pttl":
+		return c.scriptTTL(ctx, args[0], name == "pttl")
+	case "persist":
+		ok, err := c.Persist(ctx, args[0]).Result()
+		return boolReply(ok), err
+	case "time":
+		now := c.clock.Now()
+		return []interface{}{strconv.FormatInt(now.Unix(), 10), strconv.FormatInt(int64(now.Nanosecond()/1000), 10)}, nil
+	case "hset":
+		return c.HSet(ctx, args[0], toInterfaces(args[1:])...).Result()
+	case "hget":
+		return nilIfMissing(c.HGet(ctx, args[0], args[1]).Result())
+	case "hmget":
+		values := make([]interface{}, 0, len(args)-1)
+		for _, field := range args[1:] {
+			value, err := nilIfMissing(c.HGet(ctx, args[0], field).Result())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case "hdel":
+		return c.HDel(ctx, args[0], args[1:]...).Result()
+	case "hgetall":
+		values, err := c.HGetAll(ctx, args[0]).Result()
+		reply := make([]interface{}, 0, 2*len(values))
+		for field, value := range values {
+			reply = append(reply, field, value)
+		}
+		return reply, err
+	case "hincrby":
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return nil, errNotInteger
+		}
+		return c.HIncrBy(ctx, args[0], args[1], n).Result()
+	case "sadd":
+		return c.SAdd(ctx, args[0], toInterfaces(args[1:])...).Result()
+	case "srem":
+		return c.SRem(ctx, args[0], toInterfaces(args[1:])...).Result()
+	case "smembers":
+		members, err := c.SMembers(ctx, args[0]).Result()
+		return toInterfaces(members), err
+	case "sismember":
+		ok, err := c.SIsMember(ctx, args[0], args[1]).Result()
+		return boolReply(ok), err
+	case "scard":
+		return c.SCard(ctx, args[0]).Result()
+	case "zadd":
+		return c.scriptZAdd(ctx, args)
+	case "zrem":
+		return c.ZRem(ctx, args[0], toInterfaces(args[1:])...).Result()
+	case "zscore":
+		score, err := c.ZScore(ctx, args[0], args[1]).Result()
+		if errors.Is(err, goredis.Nil) {
+			return nil, nil
+		}
+		return formatScore(score), err
+	case "zincrby":
+		increment, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return nil, errors.New("ERR value is not a valid float")
+		}
+		score, err := c.ZIncrBy(ctx, args[0], increment, args[2]).Result()
+		return formatScore(score), err
+	case "zcard":
+		return c.ZCard(ctx, args[0]).Result()
+	case "zcount":
+		return c.ZCount(ctx, args[0], args[1], args[2]).Result()
+	case "zrange":
+		return c.scriptZRange(ctx, args)
+	case "zremrangebyscore":
+		return c.ZRemRangeByScore(ctx, args[0], args[1], args[2]).Result()
+	}
+	return nil, fmt.Errorf("ERR unknown command '%s' in redistest scripts", name)
+}
+
+// scriptSet supports SET key value [NX] [EX seconds | PX milliseconds].
+func (c *Client) scriptSet(ctx context.Context, args []string) (interface{}, error) {
+	var nx bool
+	var expiration time.Duration
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToLower(args[i]); option {
+		case "nx":
+			nx = true
+		case "ex", "px":
+			if i+1 >= len(args) {
+				return nil, errors.New("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return nil, errors.New("ERR invalid expire time in 'set' command")
+			}
+			expiration = time.Duration(n) * time.Second
+			if option == "px" {
+				expiration = time.Duration(n) * time.Millisecond
+			}
+			i++
+		default:
+			return nil, errors.New("ERR syntax error")
+		}
+	}
+
+	if nx {
+		ok, err := c.SetNX(ctx, args[0], args[1], expiration).Result()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return statusReply("OK"), nil
+	}
+	if err := c.Set(ctx, args[0], args[1], expiration).Err(); err != nil {
+		return nil, err
+	}
+	return statusReply("OK"), nil
+}
+
+func (c *Client) scriptTTL(ctx context.Context, key string, milliseconds bool) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.lookup(key)
+	switch {
+	case e == nil:
+		return int64(-2), nil
+	case e.expireAt.IsZero():
+		return int64(-1), nil
+	}
+	ttl := e.expireAt.Sub(c.clock.Now())
+	if milliseconds {
+		return ttl.Milliseconds(), nil
+	}
+	return int64((ttl + time.Second/2) / time.Second), nil
+}
+
+// scriptZAdd supports ZADD key score member [score member ...].
+func (c *Client) scriptZAdd(ctx context.Context, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, errors.New("ERR syntax error")
+	}
+	members := make([]goredis.Z, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return nil, errors.New("ERR value is not a valid float")
+		}
+		members = append(members, goredis.Z{Score: score, Member: args[i+1]})
+	}
+	return c.ZAdd(ctx, args[0], members...).Result()
+}
+
+// scriptZRange supports ZRANGE key start stop [WITHSCORES].
+func (c *Client) scriptZRange(ctx context.Context, args []string) (interface{}, error) {
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+	stop, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+	withScores := len(args) > 3 && strings.EqualFold(args[3], "withscores")
+
+	members, err := c.ZRangeWithScores(ctx, args[0], start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	reply := make([]interface{}, 0, 2*len(members))
+	for _, member := range members {
+		reply = append(reply, fmt.Sprint(member.Member))
+		if withScores {
+			reply = append(reply, formatScore(member.Score))
+		}
+	}
+	return reply, nil
+}
+
+// statusReply is a status reply, such as OK, that scripts see as a table with an ok field.
+type statusReply string
+
+func nilIfMissing(value string, err error) (interface{}, error) {
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func boolReply(ok bool) int64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+// toLua converts a reply to a Lua value, as Redis does: nil replies are false.
+func toLua(L *lua.LState, reply interface{}) lua.LValue {
+	switch value := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(value)
+	case string:
+		return lua.LString(value)
+	case statusReply:
+		table := L.NewTable()
+		L.SetField(table, "ok", lua.LString(value))
+		return table
+	case []interface{}:
+		table := L.NewTable()
+		for _, item := range value {
+			table.Append(toLua(L, item))
+		}
+		return table
+	}
+	return lua.LFalse
+}
+
+// fromLua converts the value returned by a script to a reply, as Redis does: numbers are truncated to integers,
+// true is 1, false and nil are nil, and arrays stop at their first nil.
+func fromLua(value lua.LValue) (interface{}, error) {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return int64(math.Trunc(float64(v))), nil
+	case lua.LString:
+		return string(v), nil
+	case lua.LBool:
+		if v {
+			return int64(1), nil
+		}
+		return nil, nil
+	case *lua.LTable:
+		if msg, ok := v.RawGetString("err").(lua.LString); ok {
+			return nil, scriptError(msg)
+		}
+		if status, ok := v.RawGetString("ok").(lua.LString); ok {
+			return string(status), nil
+		}
+		var values []interface{}
+		for i := 1; ; i++ {
+			item := v.RawGetInt(i)
+			if item == lua.LNil {
+				break
+			}
+			converted, err := fromLua(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, converted)
+		}
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,154 @@
+package redistest
+
+import (
+	"context"
+	"sort"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// lookupSet returns the set stored in the key, creating it when create is true.
+// Must be called with the lock held.
+func (c *Client) lookupSet(key string, create bool) (setValue, error) {
+	e := c.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		s := setValue{}
+		c.keys[key] = &entry{value: s}
+		return s, nil
+	}
+	s, ok := e.value.(setValue)
+	if !ok {
+		return nil, errWrongType
+	}
+	return s, nil
+}
+
+func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "sadd", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	values, err := flattenArgs(members)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	s, err := c.lookupSet(key, true)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var added int64
+	for _, value := range values {
+		if _, ok := s[value]; !ok {
+			s[value] = struct{}{}
+			added++
+		}
+	}
+	c.dropIfEmpty(key, len(s))
+
+	cmd.SetVal(added)
+	return cmd
+}
+
+func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "srem", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	values, err := flattenArgs(members)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	s, err := c.lookupSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var removed int64
+	for _, value := range values {
+		if _, ok := s[value]; ok {
+			delete(s, value)
+			removed++
+		}
+	}
+	if s != nil {
+		c.dropIfEmpty(key, len(s))
+	}
+
+	cmd.SetVal(removed)
+	return cmd
+}
+
+func (c *Client) SMembers(ctx context.Context, key string) *goredis.StringSliceCmd {
+	cmd := goredis.NewStringSliceCmd(ctx, "smembers", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	s, err := c.lookupSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	members := make([]string, 0, len(s))
+	for member := range s {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	cmd.SetVal(members)
+	return cmd
+}
+
+func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) *goredis.BoolCmd {
+	cmd := goredis.NewBoolCmd(ctx, "sismember", key, member)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	value, err := formatArg(member)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	s, err := c.lookupSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	_, ok := s[value]
+	cmd.SetVal(ok)
+	return cmd
+}
+
+func (c *Client) SCard(ctx context.Context, key string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "scard", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	s, err := c.lookupSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd.SetVal(int64(len(s)))
+	return cmd
+}
@@ -0,0 +1,332 @@
+package redistest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// lookupSortedSet returns the sorted set stored in the key, creating it when create is true.
+// Must be called with the lock held.
+func (c *Client) lookupSortedSet(key string, create bool) (zsetValue, error) {
+	e := c.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		z := zsetValue{}
+		c.keys[key] = &entry{value: z}
+		return z, nil
+	}
+	z, ok := e.value.(zsetValue)
+	if !ok {
+		return nil, errWrongType
+	}
+	return z, nil
+}
+
+// sorted returns the members ordered by score and then lexicographically, like Redis does.
+func (z zsetValue) sorted() []goredis.Z {
+	members := make([]goredis.Z, 0, len(z))
+	for member, score := range z {
+		members = append(members, goredis.Z{Score: score, Member: member})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member.(string) < members[j].Member.(string)
+	})
+	return members
+}
+
+// scoreBound is a min/max argument of the *BYSCORE commands, e.g. "-inf", "(1.5" or "10".
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+func parseScoreBound(s string) (scoreBound, error) {
+	var b scoreBound
+	if strings.HasPrefix(s, "(") {
+		b.exclusive = true
+		s = s[1:]
+	}
+
+	switch strings.ToLower(s) {
+	case "-inf":
+		b.value = math.Inf(-1)
+	case "+inf", "inf":
+		b.value = math.Inf(1)
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return b, errors.New("ERR min or max is not a float")
+		}
+		b.value = v
+	}
+	return b, nil
+}
+
+func inScoreRange(score float64, min, max scoreBound) bool {
+	if score < min.value || (min.exclusive && score == min.value) {
+		return false
+	}
+	if score > max.value || (max.exclusive && score == max.value) {
+		return false
+	}
+	return true
+}
+
+func (c *Client) ZAdd(ctx context.Context, key string, members ...goredis.Z) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "zadd", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, true)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var added int64
+	for _, m := range members {
+		member, err := formatArg(m.Member)
+		if err != nil {
+			cmd.SetErr(err)
+			return cmd
+		}
+		if _, ok := z[member]; !ok {
+			added++
+		}
+		z[member] = m.Score
+	}
+	c.dropIfEmpty(key, len(z))
+
+	cmd.SetVal(added)
+	return cmd
+}
+
+func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "zrem", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	values, err := flattenArgs(members)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	var removed int64
+	for _, value := range values {
+		if _, ok := z[value]; ok {
+			delete(z, value)
+			removed++
+		}
+	}
+	if z != nil {
+		c.dropIfEmpty(key, len(z))
+	}
+
+	cmd.SetVal(removed)
+	return cmd
+}
+
+func (c *Client) ZScore(ctx context.Context, key, member string) *goredis.FloatCmd {
+	cmd := goredis.NewFloatCmd(ctx, "zscore", key, member)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	score, ok := z[member]
+	if !ok {
+		cmd.SetErr(goredis.Nil)
+		return cmd
+	}
+
+	cmd.SetVal(score)
+	return cmd
+}
+
+func (c *Client) ZIncrBy(ctx context.Context, key string, increment float64, member string) *goredis.FloatCmd {
+	cmd := goredis.NewFloatCmd(ctx, "zincrby", key, increment, member)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, true)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	z[member] += increment
+
+	cmd.SetVal(z[member])
+	return cmd
+}
+
+func (c *Client) ZCard(ctx context.Context, key string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "zcard", key)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd.SetVal(int64(len(z)))
+	return cmd
+}
+
+func (c *Client) ZCount(ctx context.Context, key, min, max string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "zcount", key, min, max)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	members, err := c.zrangeByScore(key, min, max)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd.SetVal(int64(len(members)))
+	return cmd
+}
+
+func (c *Client) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *goredis.ZSliceCmd {
+	cmd := goredis.NewZSliceCmd(ctx, "zrange", key, start, stop, "withscores")
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	members := z.sorted()
+	size := int64(len(members))
+	if start < 0 {
+		start = max(size+start, 0)
+	}
+	if stop < 0 {
+		stop = size + stop
+	}
+	stop = min(stop, size-1)
+	if start > stop {
+		cmd.SetVal([]goredis.Z{})
+		return cmd
+	}
+
+	cmd.SetVal(members[start : stop+1])
+	return cmd
+}
+
+func (c *Client) ZRangeByScore(ctx context.Context, key string, opt *goredis.ZRangeBy) *goredis.StringSliceCmd {
+	cmd := goredis.NewStringSliceCmd(ctx, "zrangebyscore", key, opt.Min, opt.Max)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	members, err := c.zrangeByScore(key, opt.Min, opt.Max)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	if opt.Offset > 0 || opt.Count != 0 {
+		offset := min(opt.Offset, int64(len(members)))
+		members = members[offset:]
+		if opt.Count >= 0 && opt.Count < int64(len(members)) {
+			members = members[:opt.Count]
+		}
+	}
+
+	values := make([]string, 0, len(members))
+	for _, m := range members {
+		values = append(values, m.Member.(string))
+	}
+
+	cmd.SetVal(values)
+	return cmd
+}
+
+func (c *Client) ZRemRangeByScore(ctx context.Context, key, min, max string) *goredis.IntCmd {
+	cmd := goredis.NewIntCmd(ctx, "zremrangebyscore", key, min, max)
+	if !c.begin(ctx, cmd) {
+		return cmd
+	}
+	defer c.mu.Unlock()
+
+	members, err := c.zrangeByScore(key, min, max)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	if len(members) > 0 {
+		z, _ := c.lookupSortedSet(key, false)
+		for _, m := range members {
+			delete(z, m.Member.(string))
+		}
+		c.dropIfEmpty(key, len(z))
+	}
+
+	cmd.SetVal(int64(len(members)))
+	return cmd
+}
+
+// zrangeByScore returns the ordered members with a score between min and max. Must be called with the lock held.
+func (c *Client) zrangeByScore(key, min, max string) ([]goredis.Z, error) {
+	lower, err := parseScoreBound(min)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := parseScoreBound(max)
+	if err != nil {
+		return nil, err
+	}
+	z, err := c.lookupSortedSet(key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []goredis.Z
+	for _, m := range z.sorted() {
+		if inScoreRange(m.Score, lower, upper) {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
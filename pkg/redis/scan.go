@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScanIterator iterates over the results of a cursor-based SCAN family command,
+// fetching the next page from the server when needed.
+//
+//	iter := client.Scan(ctx, "prefix:*", 100)
+//	for iter.Next(ctx) {
+//		key := iter.Val()
+//	}
+//	if err := iter.Err(); err != nil {
+//		return err
+//	}
+type ScanIterator interface {
+	// Next advances the cursor and returns true if more values can be read.
+	Next(ctx context.Context) bool
+	// Val returns the key/field at the current cursor position.
+	Val() string
+	// Err returns the last iterator error, if any.
+	Err() error
+}
+
+// shardScan starts the scan of a shard.
+type shardScan func(ctx context.Context) ScanIterator
+
+// clusterScanIterator scans each master shard of a cluster, one after the other.
+type clusterScanIterator struct {
+	shards  []shardScan
+	current ScanIterator
+	err     error
+}
+
+func newClusterScanIterator(ctx context.Context, cluster *redis.ClusterClient, match string, count int64) ScanIterator {
+	var mu sync.Mutex
+	var shards []shardScan
+	err := cluster.ForEachMaster(ctx, func(_ context.Context, shard *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		shards = append(shards, func(ctx context.Context) ScanIterator {
+			return shard.Scan(ctx, 0, match, count).Iterator()
+		})
+		return nil
+	})
+
+	it := newShardsScanIterator(shards...)
+	it.err = err
+	return it
+}
+
+func newShardsScanIterator(shards ...shardScan) *clusterScanIterator {
+	return &clusterScanIterator{shards: shards}
+}
+
+func (it *clusterScanIterator) Next(ctx context.Context) bool {
+	for it.err == nil {
+		if it.current != nil {
+			if it.current.Next(ctx) {
+				return true
+			}
+			if err := it.current.Err(); err != nil {
+				it.err = err
+				return false
+			}
+		}
+
+		if len(it.shards) == 0 {
+			return false
+		}
+
+		it.current = it.shards[0](ctx)
+		it.shards = it.shards[1:]
+	}
+	return false
+}
+
+func (it *clusterScanIterator) Val() string {
+	if it.current == nil {
+		return ""
+	}
+	return it.current.Val()
+}
+
+func (it *clusterScanIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,78 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/redis"
+	"github.com/ydataai/go-core/pkg/redis/redistest"
+)
+
+func newShards(t *testing.T, n int) ([]redis.RedisClient, []*redistest.Client) {
+	clients := make([]*redistest.Client, 0, n)
+	shards := make([]redis.RedisClient, 0, n)
+	for i := 0; i < n; i++ {
+		client := redistest.NewClient(nil)
+		t.Cleanup(func() { _ = client.Close() })
+		clients = append(clients, client)
+		shards = append(shards, client)
+	}
+	return shards, clients
+}
+
+func scanAll(ctx context.Context, iter redis.ScanIterator) []string {
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys
+}
+
+func TestClusterScan(t *testing.T) {
+	ctx := context.Background()
+	shards, clients := newShards(t, 3)
+
+	var expected []string
+	for i, client := range clients {
+		// the second shard has no matching keys
+		if i == 1 {
+			require.NoError(t, client.Set(ctx, "other:1", "value", 0).Err())
+			continue
+		}
+		for j := 0; j < 3; j++ {
+			key := fmt.Sprintf("job:%d:%d", i, j)
+			require.NoError(t, client.Set(ctx, key, "value", 0).Err())
+			expected = append(expected, key)
+		}
+	}
+
+	iter := redis.NewShardsScanIterator("job:*", 10, shards...)
+	assert.ElementsMatch(t, expected, scanAll(ctx, iter))
+	require.NoError(t, iter.Err())
+	assert.False(t, iter.Next(ctx))
+
+	// no shards, e.g. when the masters can't be listed
+	iter = redis.NewShardsScanIterator("job:*", 10)
+	assert.False(t, iter.Next(ctx))
+	assert.Empty(t, iter.Val())
+	assert.NoError(t, iter.Err())
+}
+
+func TestClusterScanError(t *testing.T) {
+	ctx := context.Background()
+	shards, clients := newShards(t, 3)
+	for i, client := range clients {
+		require.NoError(t, client.Set(ctx, fmt.Sprintf("job:%d", i), "value", 0).Err())
+	}
+
+	// the first failing shard stops the scan
+	clients[1].FailOn("scan", errors.New("LOADING Redis is loading the dataset in memory"))
+	iter := redis.NewShardsScanIterator("job:*", 10, shards...)
+	assert.Equal(t, []string{"job:0"}, scanAll(ctx, iter))
+	assert.EqualError(t, iter.Err(), "LOADING Redis is loading the dataset in memory")
+	assert.False(t, iter.Next(ctx))
+}
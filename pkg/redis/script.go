@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Script represents a Lua script that is registered in the Redis server script cache
+// and executed by its SHA1 digest.
+//
+//	var incrBy = redis.NewScript("incrBy", `return redis.call("INCRBY", KEYS[1], ARGV[1])`)
+//
+//	if err := incrBy.Load(ctx, client); err != nil {
+//		return err
+//	}
+//	n, err := incrBy.Run(ctx, client, []string{"counter"}, 10).Int64()
+type Script struct {
+	name   string
+	script *redis.Script
+}
+
+// NewScript creates a new Script with the given name and Lua source.
+func NewScript(name, src string) *Script {
+	return &Script{
+		name:   name,
+		script: redis.NewScript(src),
+	}
+}
+
+// Name returns the name of the script.
+func (s *Script) Name() string {
+	return s.name
+}
+
+// Hash returns the SHA1 digest of the script.
+func (s *Script) Hash() string {
+	return s.script.Hash()
+}
+
+// Load registers the script in the script cache. On a cluster, the script is loaded in every shard.
+func (s *Script) Load(ctx context.Context, client RedisClient) error {
+	return s.script.Load(ctx, client).Err()
+}
+
+// Run executes the script by its digest, falling back to EVAL when the script is not registered yet.
+func (s *Script) Run(ctx context.Context, client RedisClient, keys []string, args ...interface{}) *redis.Cmd {
+	return s.script.Run(ctx, client, keys, args...)
+}
+
+// RunRO executes the script as read-only by its digest, falling back to EVAL_RO when the script is not
+// registered yet.
+func (s *Script) RunRO(ctx context.Context, client RedisClient, keys []string, args ...interface{}) *redis.Cmd {
+	return s.script.RunRO(ctx, client, keys, args...)
+}
+
+// LoadScripts registers all the given scripts in the script cache.
+func LoadScripts(ctx context.Context, client RedisClient, scripts ...*Script) error {
+	for _, script := range scripts {
+		if err := script.Load(ctx, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ydataai/go-core/pkg/redis"
+	"github.com/ydataai/go-core/pkg/redis/redistest"
+)
+
+func TestScriptRun(t *testing.T) {
+	ctx := context.Background()
+	client := redistest.NewClient(nil)
+	defer client.Close()
+
+	incrBy := redis.NewScript("incrBy", `return redis.call("INCRBY", KEYS[1], ARGV[1])`)
+	assert.Equal(t, "incrBy", incrBy.Name())
+
+	// not loaded yet, EVALSHA replies NOSCRIPT and the script is sent with EVAL
+	assert.Equal(t, []bool{false}, client.ScriptExists(ctx, incrBy.Hash()).Val())
+	n, err := incrBy.Run(ctx, client, []string{"counter"}, 10).Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), n)
+	assert.Equal(t, []bool{true}, client.ScriptExists(ctx, incrBy.Hash()).Val())
+
+	// once loaded, EVAL is not used anymore
+	client.FailOn("eval", errors.New("eval must not be called"))
+	n, err = incrBy.Run(ctx, client, []string{"counter"}, 5).Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), n)
+
+	// after the script cache is flushed, e.g. on a failover, the script falls back to EVAL again
+	client.ScriptFlush()
+	assert.EqualError(t, incrBy.Run(ctx, client, []string{"counter"}, 5).Err(), "eval must not be called")
+	client.ClearFailures()
+	n, err = incrBy.Run(ctx, client, []string{"counter"}, 5).Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), n)
+
+	// errors other than NOSCRIPT are returned without falling back
+	client.ScriptFlush()
+	require.NoError(t, redis.LoadScripts(ctx, client, incrBy))
+	client.FailOnce("evalsha", errors.New("connection reset"))
+	client.FailOn("eval", errors.New("eval must not be called"))
+	assert.EqualError(t, incrBy.Run(ctx, client, []string{"counter"}, 5).Err(), "connection reset")
+}